@@ -1,13 +1,22 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"podlevskikh/awesomeProject/internal/database"
 	"podlevskikh/awesomeProject/internal/models"
 	"podlevskikh/awesomeProject/internal/scheduler"
+	"podlevskikh/awesomeProject/internal/settings"
+	"strings"
 )
 
 func main() {
+	orgID := flag.Uint("org", 0, "regenerate only this organization (0 = all organizations)")
+	days := flag.Int("days", 0, "number of days to regenerate (0 = organization's schedule_days_ahead)")
+	types := flag.String("types", "", "comma-separated task types to regenerate (default: "+strings.Join(scheduler.GeneratedTaskTypes, ",")+")")
+	reshuffle := flag.Bool("reshuffle", false, "pick new random recipes instead of reproducing the previous ones")
+	flag.Parse()
+
 	// Initialize database - will use DATABASE_URL from environment
 	if err := database.Initialize(""); err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
@@ -15,37 +24,49 @@ func main() {
 
 	db := database.GetDB()
 
-	log.Println("Clearing old schedules...")
+	// Initialize scheduler
+	sched := scheduler.NewScheduler(db)
+	settingsService := settings.NewService(db)
 
-	// Delete all existing schedules and tasks
-	if err := db.Exec("DELETE FROM schedule_tasks").Error; err != nil {
-		log.Printf("Warning: Failed to delete schedule tasks: %v", err)
+	var orgs []models.Organization
+	query := db.Order("id")
+	if *orgID != 0 {
+		query = query.Where("id = ?", *orgID)
+	}
+	if err := query.Find(&orgs).Error; err != nil {
+		log.Fatalf("Failed to load organizations: %v", err)
 	}
 
-	if err := db.Exec("DELETE FROM daily_schedules").Error; err != nil {
-		log.Printf("Warning: Failed to delete daily schedules: %v", err)
+	var taskTypes []string
+	if *types != "" {
+		taskTypes = strings.Split(*types, ",")
 	}
 
-	log.Println("Old schedules cleared successfully!")
+	// Regenerate each organization in its own transaction, keeping custom, edited and completed tasks
+	for _, org := range orgs {
+		n := *days
+		if n == 0 {
+			n = settingsService.Int(org.ID, settings.KeyScheduleDaysAhead)
+		}
+		from := org.Today()
 
-	// Initialize scheduler
-	sched := scheduler.NewScheduler(db)
+		log.Printf("Regenerating org %d (%s) for %d days from %s...", org.ID, org.Name, n, from)
+		diff, err := sched.RegenerateSchedules(org.ID, scheduler.RegenerateOptions{
+			From:      from,
+			To:        from.AddDays(n),
+			TaskTypes: taskTypes,
+			Reshuffle: *reshuffle,
+		})
+		if err != nil {
+			log.Fatalf("Failed to regenerate org %d: %v", org.ID, err)
+		}
 
-	// Generate schedules for the next 7 days
-	log.Println("Generating new schedules for the next 7 days...")
-	if err := sched.GenerateScheduleForNextDays(7); err != nil {
-		log.Fatalf("Failed to generate schedules: %v", err)
+		// Display summary
+		log.Printf("Org %d: %d tasks removed, %d added, %d kept", org.ID, len(diff.Removed), len(diff.Added), diff.Kept)
+		for _, t := range diff.Added {
+			log.Printf("  + %s %-9s %5s %s %s", t.Date, t.TaskType, t.Time, t.Title, t.Description)
+		}
 	}
 
 	log.Println("Schedules regenerated successfully!")
-
-	// Display summary
-	var scheduleCount int64
-	db.Model(&models.DailySchedule{}).Count(&scheduleCount)
-	log.Printf("Total schedules created: %d", scheduleCount)
-
-	var taskCount int64
-	db.Model(&models.ScheduleTask{}).Count(&taskCount)
-	log.Printf("Total tasks created: %d", taskCount)
 }
-
@@ -3,6 +3,7 @@ package main
 import (
 	"log"
 	"podlevskikh/awesomeProject/internal/database"
+	"podlevskikh/awesomeProject/internal/ingredients"
 	"podlevskikh/awesomeProject/internal/models"
 
 	"gorm.io/gorm"
@@ -154,6 +155,9 @@ func seedRecipes(db *gorm.DB) {
 			log.Printf("Warning: Failed to create recipe %s: %v", recipe.Name, err)
 		} else {
 			log.Printf("Created recipe: %s", recipe.Name)
+			if err := ingredients.ReplaceFromText(db, &recipe); err != nil {
+				log.Printf("Warning: Failed to parse ingredients of %s: %v", recipe.Name, err)
+			}
 		}
 	}
 }
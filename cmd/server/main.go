@@ -1,14 +1,23 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"podlevskikh/awesomeProject/internal/database"
 	"podlevskikh/awesomeProject/internal/handlers"
+	"podlevskikh/awesomeProject/internal/jobs"
 	"podlevskikh/awesomeProject/internal/middleware"
+	"podlevskikh/awesomeProject/internal/models"
 	"podlevskikh/awesomeProject/internal/scheduler"
+	"podlevskikh/awesomeProject/internal/settings"
+	"podlevskikh/awesomeProject/internal/shopping"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -39,24 +48,52 @@ func main() {
 
 	// Initialize scheduler
 	sched := scheduler.NewScheduler(db)
+	settingsService := settings.NewService(db)
 
-	// Generate initial schedules for the next 7 days
-	log.Println("Generating initial schedules...")
-	if err := sched.GenerateScheduleForNextDays(7); err != nil {
-		log.Printf("Warning: Failed to generate initial schedules: %v", err)
+	// Background jobs: run on the leader replica only (Postgres advisory lock),
+	// history is stored in job_runs
+	runner, err := jobs.NewRunner(db)
+	if err != nil {
+		log.Fatalf("Failed to initialize job runner: %v", err)
 	}
+	runner.Register(jobs.Job{
+		Name:       "generate-schedules",
+		Schedule:   jobs.MustParseSchedule("0 2 * * *"), // 02:00 in each organization's local time
+		PerOrg:     true,
+		RunOnStart: true,
+		Run: func(ctx context.Context, orgID uint) error {
+			if !settingsService.Bool(orgID, settings.KeyAutoGenerateSchedule) {
+				return jobs.ErrSkipped
+			}
+			return sched.GenerateOrgUpcomingSchedules(orgID)
+		},
+	})
+	shoppingGenerator := shopping.NewGenerator(db)
+	runner.Register(jobs.Job{
+		Name:     "generate-shopping-list",
+		Schedule: jobs.MustParseSchedule("0 3 * * *"), // after generate-schedules
+		PerOrg:   true,
+		Run: func(ctx context.Context, orgID uint) error {
+			if !settingsService.Bool(orgID, settings.KeyAutoShoppingList) {
+				return jobs.ErrSkipped
+			}
+			var org models.Organization
+			if err := db.First(&org, orgID).Error; err != nil {
+				return err
+			}
+			_, err := shoppingGenerator.Generate(orgID, org.Today(), settingsService.Int(orgID, settings.KeyShoppingDaysAhead))
+			return err
+		},
+	})
 
-	// Start daily schedule generation goroutine
-	go func() {
-		ticker := time.NewTicker(24 * time.Hour)
-		defer ticker.Stop()
+	// Stop gracefully on SIGINT/SIGTERM: finish the running job and in-flight requests
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-		for range ticker.C {
-			log.Println("Running daily schedule generation...")
-			if err := sched.GenerateScheduleForNextDays(7); err != nil {
-				log.Printf("Error in daily schedule generation: %v", err)
-			}
-		}
+	runnerDone := make(chan struct{})
+	go func() {
+		runner.Run(ctx)
+		close(runnerDone)
 	}()
 
 	// Initialize Gin router
@@ -88,6 +125,14 @@ func main() {
 	authHandler := handlers.NewAuthHandler(db)
 	inviteHandler := handlers.NewInviteHandler(db)
 	orgHandler := handlers.NewOrgHandler(db)
+	menuHandler := handlers.NewMenuHandler(db)
+	shoppingHandler := handlers.NewShoppingHandler(db)
+	pantryHandler := handlers.NewPantryHandler(db)
+	familyHandler := handlers.NewFamilyHandler(db)
+	nutritionHandler := handlers.NewNutritionHandler(db)
+	workloadHandler := handlers.NewWorkloadHandler(db)
+	recurringHandler := handlers.NewRecurringHandler(db)
+	childcareHandler := handlers.NewChildcareHandler(db)
 
 	// Auth routes
 	authMw := middleware.Auth()
@@ -109,6 +154,8 @@ func main() {
 	// Org routes (auth + org context required)
 	orgsGroup := router.Group("/orgs", authMw, orgMw)
 	{
+		orgsGroup.GET("/:orgId", orgHandler.GetOrganization)
+		orgsGroup.PUT("/:orgId", middleware.Require(middleware.CapManageSettings), orgHandler.UpdateOrganization)
 		orgsGroup.POST("/:orgId/invites", middleware.Require(middleware.CapManageTeam), inviteHandler.CreateInvite)
 		orgsGroup.GET("/:orgId/members", middleware.Require(middleware.CapManageTeam), orgHandler.GetMembers)
 
@@ -117,6 +164,15 @@ func main() {
 		orgsGroup.POST("/:orgId/task-categories", middleware.Require(middleware.CapManageSettings), orgHandler.CreateTaskCategory)
 		orgsGroup.PUT("/:orgId/task-categories/:id", middleware.Require(middleware.CapManageSettings), orgHandler.UpdateTaskCategory)
 		orgsGroup.DELETE("/:orgId/task-categories/:id", middleware.Require(middleware.CapManageSettings), orgHandler.DeleteTaskCategory)
+
+		// Background job history
+		orgsGroup.GET("/:orgId/job-runs", middleware.Require(middleware.CapManageSettings), orgHandler.GetJobRuns)
+
+		// Settings
+		orgsGroup.GET("/:orgId/settings", middleware.Require(middleware.CapManageSettings), orgHandler.GetSettings)
+		orgsGroup.GET("/:orgId/settings/:key", middleware.Require(middleware.CapManageSettings), orgHandler.GetSetting)
+		orgsGroup.PUT("/:orgId/settings/:key", middleware.Require(middleware.CapManageSettings), orgHandler.UpdateSetting)
+		orgsGroup.DELETE("/:orgId/settings/:key", middleware.Require(middleware.CapManageSettings), orgHandler.DeleteSetting)
 	}
 
 
@@ -142,11 +198,13 @@ func main() {
 		{
 			// Recipes
 			api.GET("/recipes", adminHandler.GetRecipes)
+			api.GET("/recipes/suggestions", adminHandler.GetRecipeSuggestions)
 			api.GET("/recipes/:id", adminHandler.GetRecipe)
 			api.POST("/recipes", adminHandler.CreateRecipe)
 			api.PUT("/recipes/:id", adminHandler.UpdateRecipe)
 			api.DELETE("/recipes/:id", adminHandler.DeleteRecipe)
 			api.POST("/recipes/upload-image", adminHandler.UploadRecipeImage)
+			api.POST("/recipes/import", adminHandler.ImportRecipe)
 
 			// Recipe Comments
 			api.GET("/recipes/:id/comments", adminHandler.GetRecipeComments)
@@ -159,6 +217,20 @@ func main() {
 			api.POST("/mealtimes", adminHandler.CreateMealTime)
 			api.PUT("/mealtimes/:id", adminHandler.UpdateMealTime)
 			api.DELETE("/mealtimes/:id", adminHandler.DeleteMealTime)
+			api.PUT("/mealtimes/:id/strategy", adminHandler.UpdateMealTimeStrategy)
+			api.GET("/recipe-strategies", adminHandler.GetRecipeStrategies)
+
+			// Family members and their dietary restrictions
+			registerFamilyRoutes(api, familyHandler)
+
+			// Nutrition
+			registerNutritionRoutes(api, nutritionHandler)
+
+			// Helpers' working hours and daily workload
+			api.GET("/work-profiles", workloadHandler.ListProfiles)
+			api.PUT("/work-profiles/:user_id", middleware.Require(middleware.CapManageTeam), workloadHandler.SaveProfile)
+			api.DELETE("/work-profiles/:user_id", middleware.Require(middleware.CapManageTeam), workloadHandler.DeleteProfile)
+			api.GET("/workload", workloadHandler.Days)
 
 			// Cleaning zones
 			api.GET("/zones", adminHandler.GetCleaningZones)
@@ -174,6 +246,15 @@ func main() {
 			api.PUT("/childcare/:id", adminHandler.UpdateChildcareSchedule)
 			api.DELETE("/childcare/:id", adminHandler.DeleteChildcareSchedule)
 
+			// Weekly childcare patterns and days they skip
+			api.GET("/childcare-patterns", childcareHandler.ListPatterns)
+			api.POST("/childcare-patterns", middleware.Require(middleware.CapManageSchedule), childcareHandler.CreatePattern)
+			api.PUT("/childcare-patterns/:id", middleware.Require(middleware.CapManageSchedule), childcareHandler.UpdatePattern)
+			api.DELETE("/childcare-patterns/:id", middleware.Require(middleware.CapManageSchedule), childcareHandler.DeletePattern)
+			api.GET("/childcare-exceptions", childcareHandler.ListExceptions)
+			api.POST("/childcare-exceptions", middleware.Require(middleware.CapManageSchedule), childcareHandler.CreateException)
+			api.DELETE("/childcare-exceptions/:id", middleware.Require(middleware.CapManageSchedule), childcareHandler.DeleteException)
+
 			// Task management
 			api.GET("/tasks/:id", adminHandler.GetTask)
 			api.PUT("/tasks/:id", adminHandler.UpdateTask)
@@ -186,67 +267,25 @@ func main() {
 			api.POST("/custom-tasks", adminHandler.CreateCustomTask)
 			api.DELETE("/custom-tasks/:id", adminHandler.DeleteCustomTask)
 
+			// Recurring tasks (RRULE series) and their single occurrences
+			api.GET("/recurring-tasks", recurringHandler.List)
+			api.POST("/recurring-tasks", middleware.Require(middleware.CapManageSchedule), recurringHandler.Create)
+			api.PUT("/recurring-tasks/:id", middleware.Require(middleware.CapManageSchedule), recurringHandler.Update)
+			api.DELETE("/recurring-tasks/:id", middleware.Require(middleware.CapManageSchedule), recurringHandler.Delete)
+			api.GET("/recurring-tasks/:id/occurrences", recurringHandler.Occurrences)
+			api.PUT("/recurring-tasks/:id/occurrences/:date", middleware.Require(middleware.CapManageSchedule), recurringHandler.UpdateOccurrence)
+			api.DELETE("/recurring-tasks/:id/occurrences/:date", middleware.Require(middleware.CapManageSchedule), recurringHandler.DeleteOccurrence)
+
+			// Weekly menu planner
+			api.GET("/menu/week", menuHandler.GetWeek)
+			api.POST("/menu/bulk", middleware.Require(middleware.CapManageSchedule), menuHandler.BulkEdit)
+
 			// Schedule management
-			api.POST("/regenerate-schedule", func(c *gin.Context) {
-				days := 7
-
-				// Normalize to start of day so today's schedule is always included
-				now := time.Now()
-				today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-				endDate := today.AddDate(0, 0, days)
-
-				log.Println("Clearing schedules from", today.Format("2006-01-02"), "to", endDate.Format("2006-01-02"))
-
-				// Delete in correct order, but KEEP custom tasks (task_type = 'custom')
-				// 1. Delete meal_recipes for non-custom tasks
-				if err := db.Exec(`DELETE FROM meal_recipes WHERE schedule_task_id IN (
-					SELECT st.id FROM schedule_tasks st
-					JOIN daily_schedules ds ON ds.id = st.schedule_id
-					WHERE ds.date >= ? AND ds.date < ? AND st.task_type != 'custom'
-				)`, today, endDate).Error; err != nil {
-					log.Printf("Warning: Failed to delete meal_recipes: %v", err)
-				}
-
-				// 2. Delete task_zones for non-custom tasks
-				if err := db.Exec(`DELETE FROM task_zones WHERE schedule_task_id IN (
-					SELECT st.id FROM schedule_tasks st
-					JOIN daily_schedules ds ON ds.id = st.schedule_id
-					WHERE ds.date >= ? AND ds.date < ? AND st.task_type != 'custom'
-				)`, today, endDate).Error; err != nil {
-					log.Printf("Warning: Failed to delete task_zones: %v", err)
-				}
-
-				// 3. Delete non-custom schedule_tasks
-				if err := db.Exec(`DELETE FROM schedule_tasks
-					WHERE task_type != 'custom'
-					AND schedule_id IN (SELECT id FROM daily_schedules WHERE date >= ? AND date < ?)`,
-					today, endDate).Error; err != nil {
-					log.Printf("Warning: Failed to delete schedule tasks: %v", err)
-				}
-
-				// 4. Delete daily_schedules that have no tasks left
-				if err := db.Exec(`DELETE FROM daily_schedules
-					WHERE date >= ? AND date < ?
-					AND id NOT IN (SELECT DISTINCT schedule_id FROM schedule_tasks)`,
-					today, endDate).Error; err != nil {
-					log.Printf("Warning: Failed to delete empty daily schedules: %v", err)
-				}
-
-				// 5. Reset generated=false on schedules that still exist (have custom tasks only)
-				if err := db.Exec(`UPDATE daily_schedules SET generated = false
-					WHERE date >= ? AND date < ?`, today, endDate).Error; err != nil {
-					log.Printf("Warning: Failed to reset generated flag: %v", err)
-				}
-
-				log.Println("Old schedules cleared, generating new schedules...")
-
-				// Generate new schedules
-				if err := sched.GenerateScheduleForNextDays(days); err != nil {
-					c.JSON(500, gin.H{"error": err.Error()})
-					return
-				}
-				c.JSON(200, gin.H{"message": "Schedule regenerated successfully"})
-			})
+			api.POST("/regenerate-schedule", middleware.Require(middleware.CapManageSchedule), adminHandler.RegenerateSchedule)
+			api.POST("/schedule-previews", middleware.Require(middleware.CapManageSchedule), adminHandler.CreateSchedulePreview)
+			api.GET("/schedule-previews/:id", middleware.Require(middleware.CapManageSchedule), adminHandler.GetSchedulePreview)
+			api.POST("/schedule-previews/:id/accept", middleware.Require(middleware.CapManageSchedule), adminHandler.AcceptSchedulePreview)
+			api.POST("/schedule-previews/:id/reject", middleware.Require(middleware.CapManageSchedule), adminHandler.RejectSchedulePreview)
 		}
 	}
 
@@ -275,6 +314,13 @@ func main() {
 			api.POST("/shopping", helperHandler.AddShoppingListItem)
 			api.POST("/shopping/:id/purchased", helperHandler.MarkItemPurchased)
 			api.DELETE("/shopping/:id", helperHandler.DeleteShoppingListItem)
+			api.POST("/shopping/generate", shoppingHandler.Generate)
+
+			// Pantry
+			api.GET("/pantry", pantryHandler.List)
+			api.POST("/pantry", pantryHandler.Add)
+			api.PUT("/pantry/:id", pantryHandler.Update)
+			api.DELETE("/pantry/:id", pantryHandler.Delete)
 
 			// Recipe details
 			api.GET("/recipes/:id", helperHandler.GetRecipeDetails)
@@ -302,7 +348,21 @@ func main() {
 	log.Printf("Admin interface: http://localhost:%s/admin", port)
 	log.Printf("Helper interface: http://localhost:%s/helper", port)
 
-	if err := router.Run(":" + port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	srv := &http.Server{Addr: ":" + port, Handler: router}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutting down...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Warning: HTTP server shutdown: %v", err)
 	}
+	<-runnerDone
+	log.Println("Server stopped")
 }
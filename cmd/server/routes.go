@@ -0,0 +1,32 @@
+package main
+
+import (
+	"podlevskikh/awesomeProject/internal/handlers"
+	"podlevskikh/awesomeProject/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registerFamilyRoutes adds family members and their food introductions to the admin API.
+// Anyone in the organization can read them; changes need the recipe management capability
+// because the meal generator filters recipes by them
+func registerFamilyRoutes(api gin.IRoutes, family *handlers.FamilyHandler) {
+	manage := middleware.Require(middleware.CapManageRecipes)
+	api.GET("/family-members", family.List)
+	api.POST("/family-members", manage, family.Create)
+	api.PUT("/family-members/:id", manage, family.Update)
+	api.DELETE("/family-members/:id", manage, family.Delete)
+	api.GET("/family-members/:id/introductions", family.ListIntroductions)
+	api.POST("/family-members/:id/introductions", manage, family.CreateIntroduction)
+	api.PUT("/introductions/:id", manage, family.UpdateIntroduction)
+	api.DELETE("/introductions/:id", manage, family.DeleteIntroduction)
+}
+
+// registerNutritionRoutes adds the ingredient nutrition table and the nutrition summary to the
+// admin API. Editing the table needs the recipe management capability
+func registerNutritionRoutes(api gin.IRoutes, nutrition *handlers.NutritionHandler) {
+	api.GET("/nutrition/facts", nutrition.ListFacts)
+	api.PUT("/nutrition/facts", middleware.Require(middleware.CapManageRecipes), nutrition.SaveFact)
+	api.DELETE("/nutrition/facts/:id", middleware.Require(middleware.CapManageRecipes), nutrition.DeleteFact)
+	api.GET("/nutrition/summary", nutrition.Summary)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"podlevskikh/awesomeProject/internal/handlers"
+	"podlevskikh/awesomeProject/internal/middleware"
+	"podlevskikh/awesomeProject/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// routesAs builds a router whose requests come from a member with the given role. Handlers
+// have no database, so a request that passes RBAC fails later with 400 or 500, never 403.
+func routesAs(role models.Role, register func(api gin.IRoutes)) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(gin.Recovery())
+	api := router.Group("/admin/api", func(c *gin.Context) {
+		c.Set(middleware.ContextKeyMembership, &models.Membership{OrganizationID: 1, Role: role})
+		c.Set(middleware.ContextKeyOrganization, &models.Organization{ID: 1})
+	})
+	register(api)
+	return router
+}
+
+type route struct{ method, path string }
+
+func TestFamilyRoutesRBAC(t *testing.T) {
+	testWritesNeedRecipes(t, func(api gin.IRoutes) { registerFamilyRoutes(api, &handlers.FamilyHandler{}) }, []route{
+		{http.MethodPost, "/admin/api/family-members"},
+		{http.MethodPut, "/admin/api/family-members/1"},
+		{http.MethodDelete, "/admin/api/family-members/1"},
+		{http.MethodPost, "/admin/api/family-members/1/introductions"},
+		{http.MethodPut, "/admin/api/introductions/1"},
+		{http.MethodDelete, "/admin/api/introductions/1"},
+	})
+}
+
+func TestNutritionRoutesRBAC(t *testing.T) {
+	testWritesNeedRecipes(t, func(api gin.IRoutes) { registerNutritionRoutes(api, &handlers.NutritionHandler{}) }, []route{
+		{http.MethodPut, "/admin/api/nutrition/facts"},
+		{http.MethodDelete, "/admin/api/nutrition/facts/1"},
+	})
+}
+
+// testWritesNeedRecipes checks that every role without CapManageRecipes gets 403 on writes,
+// and every role with it gets past RBAC.
+func testWritesNeedRecipes(t *testing.T, register func(api gin.IRoutes), writes []route) {
+	t.Helper()
+	for _, role := range []models.Role{models.RoleOwner, models.RoleAdmin, models.RoleManager, models.RoleHelper} {
+		router := routesAs(role, register)
+		wantForbidden := !middleware.Can(&models.Membership{Role: role}, middleware.CapManageRecipes)
+		for _, w := range writes {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(w.method, w.path, strings.NewReader("{")))
+			if forbidden := rec.Code == http.StatusForbidden; forbidden != wantForbidden {
+				t.Errorf("%s %s as %s: status %d, want forbidden=%v", w.method, w.path, role, rec.Code, wantForbidden)
+			}
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Package childcare — еженедельные шаблоны присмотра за детьми: разбор дней недели, проверка
+// шаблонов и исключений и выбор блоков, которые приходятся на дату.
+package childcare
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"podlevskikh/awesomeProject/internal/models"
+	"podlevskikh/awesomeProject/internal/timeline"
+)
+
+// Дни недели в порядке с понедельника, коды как в iCalendar.
+var weekdayCodes = []string{"MO", "TU", "WE", "TH", "FR", "SA", "SU"}
+
+// weekdayIndex — номер дня с понедельника (0) по коду.
+func weekdayIndex(code string) (int, bool) {
+	for i, c := range weekdayCodes {
+		if c == code {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// ParseWeekdays разбирает дни недели шаблона: коды через запятую ("MO,WE,FR") и диапазоны
+// ("MO-FR", "SA-SU").
+func ParseWeekdays(s string) (map[time.Weekday]bool, error) {
+	days := make(map[time.Weekday]bool)
+	for _, part := range strings.Split(strings.ToUpper(s), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		from, to, isRange := strings.Cut(part, "-")
+		first, ok := weekdayIndex(strings.TrimSpace(from))
+		last := first
+		if ok && isRange {
+			last, ok = weekdayIndex(strings.TrimSpace(to))
+		}
+		if !ok || last < first {
+			return nil, fmt.Errorf("invalid weekdays %q: use MO, TU, WE, TH, FR, SA, SU or a range like MO-FR", part)
+		}
+		for i := first; i <= last; i++ {
+			days[time.Weekday((i+1)%7)] = true
+		}
+	}
+	if len(days) == 0 {
+		return nil, fmt.Errorf("weekdays are required")
+	}
+	return days, nil
+}
+
+// ValidateBlock проверяет время блока присмотра: "HH:MM", конец позже начала.
+func ValidateBlock(start, end string) error {
+	_, err := timeline.ParseSpan(start + "-" + end)
+	return err
+}
+
+// Validate проверяет шаблон: дни недели, время и период действия.
+func Validate(p models.ChildcarePattern) error {
+	if _, err := ParseWeekdays(p.Weekdays); err != nil {
+		return err
+	}
+	if err := ValidateBlock(p.StartTime, p.EndTime); err != nil {
+		return err
+	}
+	if p.ValidFrom != nil && p.ValidUntil != nil && p.ValidUntil.Before(*p.ValidFrom) {
+		return fmt.Errorf("valid_until is before valid_from")
+	}
+	return nil
+}
+
+// ValidateException проверяет исключение: конец не раньше начала и не дальше года.
+func ValidateException(e models.ChildcareException) error {
+	if e.StartDate.IsZero() || e.EndDate.IsZero() {
+		return fmt.Errorf("start_date and end_date are required")
+	}
+	if e.EndDate.Before(e.StartDate) {
+		return fmt.Errorf("end_date is before start_date")
+	}
+	if e.EndDate.DaysSince(e.StartDate) > 366 {
+		return fmt.Errorf("an exception can span at most a year")
+	}
+	return nil
+}
+
+// Applies сообщает, приходится ли блок шаблона на date. Если нет, reason объясняет почему
+// (вне периода действия, исключение); для неактивного шаблона и другого дня недели он пуст.
+func Applies(p models.ChildcarePattern, exceptions []models.ChildcareException, date models.Date) (ok bool, reason string) {
+	days, err := ParseWeekdays(p.Weekdays)
+	if !p.Active || err != nil || !days[date.Weekday()] {
+		return false, ""
+	}
+	if p.ValidFrom != nil && date.Before(*p.ValidFrom) {
+		return false, "pattern starts on " + p.ValidFrom.String()
+	}
+	if p.ValidUntil != nil && date.After(*p.ValidUntil) {
+		return false, "pattern ended on " + p.ValidUntil.String()
+	}
+	for _, e := range exceptions {
+		if e.PatternID != nil && *e.PatternID != p.ID {
+			continue
+		}
+		if !date.Before(e.StartDate) && !date.After(e.EndDate) {
+			reason := "exception"
+			if e.Reason != "" {
+				reason += ": " + e.Reason
+			}
+			return false, reason
+		}
+	}
+	return true, ""
+}
+
+// Origin — дата, на которую шаблон развернул блок. У перенесённого блока она остаётся
+// прежней; у блоков, созданных до появления OriginDate, совпадает с Date.
+func Origin(b models.ChildcareSchedule) models.Date {
+	if b.OriginDate != nil {
+		return *b.OriginDate
+	}
+	return b.Date
+}
+
+// Expanded — шаблоны, уже развёрнутые на date. Блок считается по исходной дате: перенесённый
+// на другой день шаблона, он не мешает развернуть шаблон в этот день.
+func Expanded(blocks []models.ChildcareSchedule, date models.Date) map[uint]bool {
+	expanded := make(map[uint]bool)
+	for _, b := range blocks {
+		if b.PatternID != nil && Origin(b).Equal(date) {
+			expanded[*b.PatternID] = true
+		}
+	}
+	return expanded
+}
+
+// Title — название задачи присмотра: "Childcare" и имена детей, если они указаны.
+func Title(children []models.FamilyMember) string {
+	if len(children) == 0 {
+		return "Childcare"
+	}
+	names := make([]string, len(children))
+	for i, c := range children {
+		names[i] = c.Name
+	}
+	sort.Strings(names)
+	return "Childcare: " + strings.Join(names, ", ")
+}
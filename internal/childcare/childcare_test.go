@@ -0,0 +1,79 @@
+package childcare
+
+import (
+	"testing"
+	"time"
+
+	"podlevskikh/awesomeProject/internal/models"
+)
+
+func TestParseWeekdays(t *testing.T) {
+	days, err := ParseWeekdays("mo-fr")
+	if err != nil || len(days) != 5 || days[time.Saturday] || !days[time.Monday] || !days[time.Friday] {
+		t.Errorf("MO-FR = %v, %v", days, err)
+	}
+	days, err = ParseWeekdays("WE, SA-SU")
+	if err != nil || len(days) != 3 || !days[time.Sunday] || !days[time.Wednesday] {
+		t.Errorf("WE, SA-SU = %v, %v", days, err)
+	}
+	for _, s := range []string{"", "FR-MO", "XX"} {
+		if _, err := ParseWeekdays(s); err == nil {
+			t.Errorf("ParseWeekdays(%q) = nil error", s)
+		}
+	}
+}
+
+func TestApplies(t *testing.T) {
+	until := models.NewDate(2025, time.June, 30)
+	weekdays := models.ChildcarePattern{ID: 1, Weekdays: "MO-FR", StartTime: "08:00", EndTime: "13:00", Active: true, ValidUntil: &until}
+	evening := models.ChildcarePattern{ID: 2, Weekdays: "WE", StartTime: "18:00", EndTime: "21:00", Active: true}
+	patternID := uint(1)
+	exceptions := []models.ChildcareException{
+		{PatternID: &patternID, StartDate: models.NewDate(2025, time.June, 9), EndDate: models.NewDate(2025, time.June, 13), Reason: "parental leave"},
+		{StartDate: models.NewDate(2025, time.June, 25), EndDate: models.NewDate(2025, time.June, 25)},
+	}
+
+	cases := []struct {
+		pattern models.ChildcarePattern
+		date    models.Date
+		ok      bool
+		reason  string
+	}{
+		{weekdays, models.NewDate(2025, time.June, 4), true, ""},
+		{evening, models.NewDate(2025, time.June, 4), true, ""},
+		{evening, models.NewDate(2025, time.June, 5), false, ""},
+		{weekdays, models.NewDate(2025, time.June, 11), false, "exception: parental leave"},
+		{evening, models.NewDate(2025, time.June, 11), true, ""},
+		{evening, models.NewDate(2025, time.June, 25), false, "exception"},
+		{weekdays, models.NewDate(2025, time.July, 1), false, "pattern ended on 2025-06-30"},
+	}
+	for _, c := range cases {
+		ok, reason := Applies(c.pattern, exceptions, c.date)
+		if ok != c.ok || reason != c.reason {
+			t.Errorf("pattern %d on %s = %v %q, want %v %q", c.pattern.ID, c.date, ok, reason, c.ok, c.reason)
+		}
+	}
+}
+
+// Блок шаблона MO-FR перенесли с понедельника на вторник: во вторник шаблон всё равно
+// разворачивается, а в понедельник — нет.
+func TestExpandedMovedBlock(t *testing.T) {
+	patternID := uint(1)
+	monday, tuesday := models.NewDate(2025, time.June, 2), models.NewDate(2025, time.June, 3)
+	blocks := []models.ChildcareSchedule{
+		{ID: 10, PatternID: &patternID, OriginDate: &monday, Date: tuesday},
+		{ID: 11, Date: tuesday}, // добавлен вручную
+	}
+	if Expanded(blocks, tuesday)[patternID] {
+		t.Errorf("block moved onto Tuesday counts as Tuesday's own expansion")
+	}
+	if !Expanded(blocks, monday)[patternID] {
+		t.Errorf("block moved off Monday does not count as Monday's expansion")
+	}
+
+	// Блок, развёрнутый до появления OriginDate, считается по своей дате
+	legacy := []models.ChildcareSchedule{{ID: 12, PatternID: &patternID, Date: tuesday}}
+	if !Expanded(legacy, tuesday)[patternID] {
+		t.Errorf("block without origin date does not count on its own date")
+	}
+}
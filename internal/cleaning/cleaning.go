@@ -0,0 +1,352 @@
+// Package cleaning — планирование уборки по фактическим датам: зона попадает в расписание,
+// когда с последней уборки прошёл её интервал, а зона с частотой в неделю — пока за последние
+// 7 дней не набрала своей нормы уборок. Пропущенная уборка (выходной, не сделали)
+// не теряется — зона остаётся просроченной и переходит на следующий рабочий день, а
+// рабочие дни получают ровную нагрузку.
+package cleaning
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"podlevskikh/awesomeProject/internal/models"
+	"podlevskikh/awesomeProject/internal/timeline"
+
+	"gorm.io/gorm"
+)
+
+// workingDaysPerWeek — рабочих дней в неделе: воскресенье выходной, поэтому ежедневная уборка —
+// это 6 уборок в неделю.
+const workingDaysPerWeek = 6
+
+// Interval — интервал между уборками зоны в днях: IntervalDays, а если он не задан —
+// наименьший промежуток для FrequencyPerWeek, 7 / частота с округлением вниз (сколько раз
+// убирать, решает недельная норма, см. Quota). 0 — зона не планируется.
+func Interval(zone models.CleaningZone) int {
+	if zone.IntervalDays > 0 {
+		return zone.IntervalDays
+	}
+	if freq := Quota(zone); freq > 0 {
+		return 7 / freq
+	}
+	return 0
+}
+
+// Quota — недельная норма зоны: сколько уборок допускается в любые 7 дней подряд.
+// FrequencyPerWeek, но не больше рабочих дней недели; 0 — у зоны интервал в днях или она
+// не планируется.
+func Quota(zone models.CleaningZone) int {
+	if zone.IntervalDays > 0 || zone.FrequencyPerWeek <= 0 {
+		return 0
+	}
+	return min(zone.FrequencyPerWeek, workingDaysPerWeek)
+}
+
+// perWeek — уборок зоны в неделю.
+func perWeek(zone models.CleaningZone) float64 {
+	if quota := Quota(zone); quota > 0 {
+		return float64(quota)
+	}
+	if interval := Interval(zone); interval > 0 {
+		return 7 / float64(interval)
+	}
+	return 0
+}
+
+// DefaultDuration — длительность уборки зоны, у которой она не задана, в минутах.
+const DefaultDuration = 30
+
+// Duration — сколько минут занимает уборка зоны.
+func Duration(zone models.CleaningZone) int {
+	if zone.DurationMinutes > 0 {
+		return zone.DurationMinutes
+	}
+	return DefaultDuration
+}
+
+// Validate проверяет настройки расписания зоны.
+func Validate(zone models.CleaningZone) error {
+	if zone.FrequencyPerWeek < 0 || zone.FrequencyPerWeek > 7 {
+		return fmt.Errorf("frequency_per_week must be between 0 and 7")
+	}
+	if zone.IntervalDays < 0 || zone.IntervalDays > 365 {
+		return fmt.Errorf("interval_days must be between 0 and 365")
+	}
+	if zone.DurationMinutes < 0 || zone.DurationMinutes > 600 {
+		return fmt.Errorf("duration_minutes must be between 0 and 600")
+	}
+	if _, err := timeline.ParsePreference(zone.PreferredTime); err != nil {
+		return err
+	}
+	return nil
+}
+
+// State — зона, дата её последней уборки (nil — не убиралась) и даты уборок за 6 дней
+// до date по возрастанию: вместе с date они составляют неделю, в которую укладывается Quota.
+type State struct {
+	Zone   models.CleaningZone
+	Last   *models.Date
+	Recent []models.Date
+}
+
+// Due — дата, с которой зона нуждается в уборке: прошёл интервал с последней уборки, а если
+// недельная норма выбрана — из недели выпала самая ранняя уборка, освобождая место; для
+// неубиравшейся — date.
+func (s State) Due(date models.Date) models.Date {
+	if s.Last == nil {
+		return date
+	}
+	due := s.Last.AddDays(Interval(s.Zone))
+	if quota := Quota(s.Zone); quota > 0 && len(s.Recent) >= quota {
+		if free := s.Recent[len(s.Recent)-quota].AddDays(7); free.After(due) {
+			due = free
+		}
+	}
+	return due
+}
+
+// quotaLeft сообщает, можно ли убрать зону на date, не превысив недельную нормы.
+func (s State) quotaLeft() bool {
+	quota := Quota(s.Zone)
+	return quota == 0 || len(s.Recent) < quota
+}
+
+// urgency — доля интервала, прошедшая с последней уборки на date; у неубиравшейся — +Inf.
+func (s State) urgency(date models.Date) float64 {
+	if s.Last == nil {
+		return math.Inf(1)
+	}
+	return float64(date.DaysSince(*s.Last)) / float64(Interval(s.Zone))
+}
+
+// earlyDays — на сколько дней раньше срока зону можно убрать, чтобы выровнять нагрузку:
+// четверть интервала (зону раз в две недели — на три дня раньше, ежедневную — никогда).
+// Зону с недельной нормой раньше убирают, только если норма не выбрана.
+func earlyDays(interval int) int {
+	return interval / 4
+}
+
+// DailyLimit — сколько зон убирать в рабочий день: уборок в неделю по всем зонам, делённое
+// на рабочие дни недели с округлением вверх, но не больше maxPerDay.
+func DailyLimit(zones []models.CleaningZone, workingDays, maxPerDay int) int {
+	weekly := 0.0
+	for _, z := range zones {
+		weekly += perWeek(z)
+	}
+	if workingDays <= 0 {
+		workingDays = 1
+	}
+	limit := int(math.Ceil(weekly/float64(workingDays) - 1e-9))
+	return min(max(limit, 1), maxPerDay)
+}
+
+// Pick — решение по зоне на день.
+type Pick struct {
+	State
+	Due          models.Date
+	Early        bool   // убирается раньше срока, чтобы выровнять нагрузку
+	OverCapacity bool   // отложена: не помещается в рабочее время помощников
+	Reason       string // почему зона выбрана или отложена
+}
+
+// Plan выбирает зоны на date: сначала просроченные и те, у которых срок сегодня (самые
+// просроченные относительно интервала — первыми, при равенстве — по приоритету), не больше
+// limit и не дольше minutes в сумме (minutes < 0 — без ограничения); оставшиеся откладываются
+// на следующий рабочий день. Если до limit не хватает, день добирается зонами, срок которых
+// наступит в ближайшие earlyDays дней, если они помещаются.
+func Plan(states []State, date models.Date, limit, minutes int) (picked, postponed []Pick) {
+	var due, upcoming []Pick
+	for _, s := range states {
+		interval := Interval(s.Zone)
+		if interval == 0 {
+			continue
+		}
+		p := Pick{State: s, Due: s.Due(date)}
+		switch {
+		case !p.Due.After(date):
+			due = append(due, p)
+		case s.quotaLeft() && p.Due.DaysSince(date) <= earlyDays(interval):
+			upcoming = append(upcoming, p)
+		}
+	}
+
+	sort.SliceStable(due, func(i, j int) bool {
+		ui, uj := due[i].urgency(date), due[j].urgency(date)
+		if ui != uj {
+			return ui > uj
+		}
+		return priorityRank(due[i].Zone.Priority) < priorityRank(due[j].Zone.Priority)
+	})
+	fits := func(p Pick) bool { return minutes < 0 || Duration(p.Zone) <= minutes }
+	take := func(p Pick) {
+		picked = append(picked, p)
+		if minutes >= 0 {
+			minutes -= Duration(p.Zone)
+		}
+	}
+	for _, p := range due {
+		switch {
+		case len(picked) >= limit:
+			p.Reason = fmt.Sprintf("%s, postponed: daily load of %d zones reached", dueReason(p, date), limit)
+			postponed = append(postponed, p)
+		case !fits(p):
+			p.OverCapacity = true
+			p.Reason = fmt.Sprintf("%s, postponed: takes %d min, %d min of working time left",
+				dueReason(p, date), Duration(p.Zone), minutes)
+			postponed = append(postponed, p)
+		default:
+			p.Reason = dueReason(p, date)
+			take(p)
+		}
+	}
+
+	sort.SliceStable(upcoming, func(i, j int) bool {
+		if !upcoming[i].Due.Equal(upcoming[j].Due) {
+			return upcoming[i].Due.Before(upcoming[j].Due)
+		}
+		return priorityRank(upcoming[i].Zone.Priority) < priorityRank(upcoming[j].Zone.Priority)
+	})
+	for _, p := range upcoming {
+		if len(picked) >= limit {
+			break
+		}
+		if !fits(p) {
+			continue
+		}
+		p.Early = true
+		p.Reason = fmt.Sprintf("last cleaned %s, %s: due %s, cleaned early to even the load",
+			p.Last, schedule(p.State), p.Due)
+		take(p)
+	}
+	return picked, postponed
+}
+
+func dueReason(p Pick, date models.Date) string {
+	if p.Last == nil {
+		return "never cleaned"
+	}
+	reason := fmt.Sprintf("last cleaned %s, %s", p.Last, schedule(p.State))
+	if overdue := date.DaysSince(p.Due); overdue > 0 {
+		return fmt.Sprintf("%s: due %s, %d days overdue", reason, p.Due, overdue)
+	}
+	return reason + ": due today"
+}
+
+// schedule описывает, как часто убирается зона.
+func schedule(s State) string {
+	if quota := Quota(s.Zone); quota > 0 {
+		return fmt.Sprintf("%d times a week, %d in the last 6 days", quota, len(s.Recent))
+	}
+	return fmt.Sprintf("every %d days", Interval(s.Zone))
+}
+
+// priorityRank — порядок приоритетов: high, medium, low, остальные.
+func priorityRank(priority string) int {
+	switch priority {
+	case "high":
+		return 0
+	case "medium":
+		return 1
+	case "low":
+		return 2
+	}
+	return 3
+}
+
+// States собирает состояние зон на date. Уборки — это LastCleanedOn зоны, выполненные
+// cleaning-задачи до date и невыполненные задачи, стоящие в расписании с today до date
+// (см. zoneDates); planned добавляет запланированное вне БД, а задачи из hidden (их заменяет
+// предпросмотр) не учитываются. Last — самая поздняя из них, Recent — попавшие в 6 дней до date.
+func States(db *gorm.DB, orgID uint, zones []models.CleaningZone, today, date models.Date, planned map[uint][]models.Date, hidden []uint) ([]State, error) {
+	week := date.AddDays(-6)
+	last, err := zoneDates(db, orgID, true, models.Date{}, date, true, hidden)
+	if err != nil {
+		return nil, err
+	}
+	done, err := zoneDates(db, orgID, true, week, date, false, hidden)
+	if err != nil {
+		return nil, err
+	}
+	ahead, err := zoneDates(db, orgID, false, today, date, false, hidden)
+	if err != nil {
+		return nil, err
+	}
+	states := make([]State, len(zones))
+	for i, z := range zones {
+		var cleaned []models.Date
+		if z.LastCleanedOn != nil {
+			cleaned = append(cleaned, *z.LastCleanedOn)
+		}
+		for _, dates := range []map[uint][]models.Date{last, done, ahead, planned} {
+			cleaned = append(cleaned, dates[z.ID]...)
+		}
+		sort.Slice(cleaned, func(i, j int) bool { return cleaned[i].Before(cleaned[j]) })
+
+		state := State{Zone: z}
+		for _, d := range cleaned {
+			if !d.Before(date) || (state.Last != nil && d.Equal(*state.Last)) {
+				continue
+			}
+			if !d.Before(week) {
+				state.Recent = append(state.Recent, d)
+			}
+			state.Last = &d
+		}
+		states[i] = state
+	}
+	return states, nil
+}
+
+// zoneDate — зона и дата cleaning-задачи.
+type zoneDate struct {
+	ZoneID uint
+	Date   models.Date
+}
+
+// zoneDates возвращает по зонам даты из [from, before) с выполненной (completed) или
+// невыполненной cleaning-задачей (latest — только последнюю) — и по ZoneID, и по связям
+// task_zones. Невыполненные задачи берутся только с сегодняшнего дня: в будущем это
+// запланированная уборка (иначе генерация на несколько дней вперёд ставила бы зону каждый
+// день), а в прошлом — пропуск, и зона остаётся просроченной. Задачи из hidden пропускаются.
+func zoneDates(db *gorm.DB, orgID uint, completed bool, from, before models.Date, latest bool, hidden []uint) (map[uint][]models.Date, error) {
+	query := func(zoneColumn, join string) ([]zoneDate, error) {
+		var rows []zoneDate
+		q := db.Table("schedule_tasks").
+			Joins("JOIN daily_schedules ON daily_schedules.id = schedule_tasks.schedule_id")
+		if latest {
+			q = q.Select(zoneColumn + " AS zone_id, MAX(daily_schedules.date) AS date").Group(zoneColumn)
+		} else {
+			q = q.Select("DISTINCT " + zoneColumn + " AS zone_id, daily_schedules.date")
+		}
+		if join != "" {
+			q = q.Joins(join)
+		}
+		q = q.Where("daily_schedules.organization_id = ?", orgID).
+			Where("schedule_tasks.task_type = 'cleaning'").
+			Where("schedule_tasks.completed = ?", completed).
+			Where("daily_schedules.date < ?", before)
+		if !from.IsZero() {
+			q = q.Where("daily_schedules.date >= ?", from)
+		}
+		if len(hidden) > 0 {
+			q = q.Where("schedule_tasks.id NOT IN ?", hidden)
+		}
+		err := q.Where(zoneColumn + " IS NOT NULL").Scan(&rows).Error
+		return rows, err
+	}
+
+	direct, err := query("schedule_tasks.zone_id", "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cleaning history: %w", err)
+	}
+	linked, err := query("task_zones.cleaning_zone_id", "JOIN task_zones ON task_zones.schedule_task_id = schedule_tasks.id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cleaning history: %w", err)
+	}
+	out := make(map[uint][]models.Date)
+	for _, r := range append(direct, linked...) {
+		out[r.ZoneID] = append(out[r.ZoneID], r.Date)
+	}
+	return out, nil
+}
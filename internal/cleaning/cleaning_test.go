@@ -0,0 +1,168 @@
+package cleaning
+
+import (
+	"testing"
+	"time"
+
+	"podlevskikh/awesomeProject/internal/models"
+)
+
+func TestInterval(t *testing.T) {
+	cases := []struct {
+		zone        models.CleaningZone
+		want, quota int
+	}{
+		{models.CleaningZone{FrequencyPerWeek: 1}, 7, 1},
+		{models.CleaningZone{FrequencyPerWeek: 2}, 3, 2},
+		{models.CleaningZone{FrequencyPerWeek: 3}, 2, 3},
+		{models.CleaningZone{FrequencyPerWeek: 4}, 1, 4},
+		{models.CleaningZone{FrequencyPerWeek: 5}, 1, 5}, // сколько раз — решает норма, а не интервал
+		{models.CleaningZone{FrequencyPerWeek: 6}, 1, 6},
+		{models.CleaningZone{FrequencyPerWeek: 7}, 1, 6}, // воскресенье выходной
+		{models.CleaningZone{FrequencyPerWeek: 1, IntervalDays: 14}, 14, 0},
+		{models.CleaningZone{}, 0, 0},
+	}
+	for _, c := range cases {
+		if got := Interval(c.zone); got != c.want {
+			t.Errorf("Interval(freq %d, interval %d) = %d, want %d", c.zone.FrequencyPerWeek, c.zone.IntervalDays, got, c.want)
+		}
+		if got := Quota(c.zone); got != c.quota {
+			t.Errorf("Quota(freq %d, interval %d) = %d, want %d", c.zone.FrequencyPerWeek, c.zone.IntervalDays, got, c.quota)
+		}
+	}
+}
+
+// stateOn — состояние зоны на date по датам её уборок, как его собирает States.
+func stateOn(zone models.CleaningZone, cleaned []models.Date, date models.Date) State {
+	s := State{Zone: zone}
+	for _, d := range cleaned {
+		if !d.Before(date.AddDays(-6)) {
+			s.Recent = append(s.Recent, d)
+		}
+		s.Last = &d
+	}
+	return s
+}
+
+// Две недели по дням: воскресенья пропускаются, выбранные зоны считаются убранными.
+func TestPlanMakesUpMissedDays(t *testing.T) {
+	zones := []models.CleaningZone{
+		{ID: 1, Name: "Kitchen", FrequencyPerWeek: 7, Priority: "high"},
+		{ID: 2, Name: "Bathroom", FrequencyPerWeek: 2, Priority: "high"},
+		{ID: 3, Name: "Bedroom", FrequencyPerWeek: 1, Priority: "medium"},
+		{ID: 4, Name: "Hall", FrequencyPerWeek: 1, Priority: "low"},
+		{ID: 5, Name: "Balcony", FrequencyPerWeek: 1, Priority: "low"},
+	}
+	// Кухню последний раз убирали в субботу: её срок — воскресенье
+	saturday := models.NewDate(2025, time.June, 7)
+	history := map[uint][]models.Date{1: {saturday}}
+	for id, daysAgo := range map[uint]int{2: 1, 3: 4, 4: 3, 5: 2} {
+		history[id] = []models.Date{saturday.AddDays(-daysAgo)}
+	}
+
+	limit := DailyLimit(zones, 6, 3)
+	if limit != 2 { // 6 + 2 + 1 + 1 + 1 = 11 уборок на 6 рабочих дней
+		t.Fatalf("DailyLimit = %d, want 2", limit)
+	}
+
+	cleaned := make(map[uint][]models.Date)
+	for date := saturday.AddDays(2); date.Before(saturday.AddDays(16)); date = date.AddDays(1) {
+		if date.Weekday() == time.Sunday {
+			continue
+		}
+		states := make([]State, len(zones))
+		for i, z := range zones {
+			states[i] = stateOn(z, history[z.ID], date)
+		}
+		picked, _ := Plan(states, date, limit, -1)
+		if len(picked) > limit {
+			t.Errorf("%s: %d zones over the limit of %d", date, len(picked), limit)
+		}
+		for _, p := range picked {
+			history[p.Zone.ID] = append(history[p.Zone.ID], date)
+			cleaned[p.Zone.ID] = append(cleaned[p.Zone.ID], date)
+		}
+	}
+
+	// Кухня, пропущенная в воскресенье, убирается в понедельник первой
+	if first := cleaned[1]; len(first) == 0 || !first[0].Equal(saturday.AddDays(2)) {
+		t.Errorf("kitchen: %v, want Monday first", first)
+	}
+	// Каждая зона убрана, ни одна не ждала дольше интервала больше чем на неделю
+	for _, z := range zones {
+		if len(cleaned[z.ID]) == 0 {
+			t.Errorf("%s never cleaned", z.Name)
+		}
+		for i := 1; i < len(cleaned[z.ID]); i++ {
+			if gap := cleaned[z.ID][i].DaysSince(cleaned[z.ID][i-1]); gap > Interval(z)+7 {
+				t.Errorf("%s: %d days between cleanings", z.Name, gap)
+			}
+		}
+	}
+}
+
+// Зона с частотой от 1 до 7 раз в неделю за четыре недели с понедельника: каждую неделю
+// убирается ровно по норме (7 раз — это 6, воскресенье выходной).
+func TestPlanWeeklyQuota(t *testing.T) {
+	monday := models.NewDate(2025, time.June, 2)
+	for freq := 1; freq <= 7; freq++ {
+		zone := models.CleaningZone{ID: 1, Name: "Kitchen", FrequencyPerWeek: freq}
+		var cleaned []models.Date
+		perWeek := make([]int, 4)
+		for date := monday; date.Before(monday.AddDays(28)); date = date.AddDays(1) {
+			if date.Weekday() == time.Sunday {
+				continue
+			}
+			picked, _ := Plan([]State{stateOn(zone, cleaned, date)}, date, 3, -1)
+			if len(picked) > 0 {
+				cleaned = append(cleaned, date)
+				perWeek[date.DaysSince(monday)/7]++
+			}
+		}
+		for week, n := range perWeek {
+			if want := min(freq, 6); n != want {
+				t.Errorf("%d times a week, week %d: cleaned %d times, want %d", freq, week+1, n, want)
+			}
+		}
+	}
+}
+
+// Зона 2 раза в неделю, убранная в понедельник и среду, ждёт следующего понедельника.
+func TestPlanQuotaCountsExtraCleaning(t *testing.T) {
+	zone := models.CleaningZone{ID: 1, Name: "Kitchen", FrequencyPerWeek: 2}
+	monday := models.NewDate(2025, time.June, 2)
+	friday := monday.AddDays(4)
+	cleaned := []models.Date{monday, monday.AddDays(2)}
+	if picked, _ := Plan([]State{stateOn(zone, cleaned, friday)}, friday, 3, -1); len(picked) != 0 {
+		t.Errorf("picked %+v on Friday after two cleanings this week", picked)
+	}
+	if s := stateOn(zone, cleaned, friday); !s.Due(friday).Equal(monday.AddDays(7)) {
+		t.Errorf("due %s, want next Monday", s.Due(friday))
+	}
+}
+
+func TestPlanPostponesOverLimit(t *testing.T) {
+	date := models.NewDate(2025, time.June, 10)
+	long, short := date.AddDays(-20), date.AddDays(-8)
+	states := []State{
+		{Zone: models.CleaningZone{ID: 1, Name: "A", FrequencyPerWeek: 1, Priority: "low"}, Last: &short},
+		{Zone: models.CleaningZone{ID: 2, Name: "B", FrequencyPerWeek: 1, Priority: "low"}, Last: &long},
+		{Zone: models.CleaningZone{ID: 3, Name: "C", FrequencyPerWeek: 1, Priority: "high"}, Last: &short},
+	}
+	picked, postponed := Plan(states, date, 2, -1)
+	if len(picked) != 2 || picked[0].Zone.ID != 2 || picked[1].Zone.ID != 3 {
+		t.Fatalf("picked %+v, want the most overdue zone, then the high priority one", picked)
+	}
+	if len(postponed) != 1 || postponed[0].Zone.ID != 1 {
+		t.Errorf("postponed %+v, want zone A", postponed)
+	}
+
+	// В 40 минут помещается одна зона, остальные откладываются из-за рабочего времени
+	picked, postponed = Plan(states, date, 3, 40)
+	if len(picked) != 1 || picked[0].Zone.ID != 2 {
+		t.Fatalf("picked %+v, want only zone B", picked)
+	}
+	if len(postponed) != 2 || !postponed[0].OverCapacity {
+		t.Errorf("postponed %+v, want two zones over capacity", postponed)
+	}
+}
@@ -9,22 +9,22 @@ import (
 // InitializeCyprusHolidays adds Cyprus national holidays to the database
 func InitializeCyprusHolidays(db *gorm.DB) error {
 	holidays := []models.Holiday{
-		{Name: "New Year's Day", Date: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), IsRecurring: true, Country: "Cyprus"},
-		{Name: "Epiphany", Date: time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC), IsRecurring: true, Country: "Cyprus"},
-		{Name: "Green Monday", Date: time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC), IsRecurring: false, Country: "Cyprus"}, // Changes yearly
-		{Name: "Greek Independence Day", Date: time.Date(2025, 3, 25, 0, 0, 0, 0, time.UTC), IsRecurring: true, Country: "Cyprus"},
-		{Name: "Cyprus National Day", Date: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), IsRecurring: true, Country: "Cyprus"},
-		{Name: "Good Friday", Date: time.Date(2025, 4, 18, 0, 0, 0, 0, time.UTC), IsRecurring: false, Country: "Cyprus"}, // Changes yearly
-		{Name: "Easter Saturday", Date: time.Date(2025, 4, 19, 0, 0, 0, 0, time.UTC), IsRecurring: false, Country: "Cyprus"}, // Changes yearly
-		{Name: "Easter Sunday", Date: time.Date(2025, 4, 20, 0, 0, 0, 0, time.UTC), IsRecurring: false, Country: "Cyprus"}, // Changes yearly
-		{Name: "Easter Monday", Date: time.Date(2025, 4, 21, 0, 0, 0, 0, time.UTC), IsRecurring: false, Country: "Cyprus"}, // Changes yearly
-		{Name: "Labour Day", Date: time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC), IsRecurring: true, Country: "Cyprus"},
-		{Name: "Orthodox Pentecost Monday", Date: time.Date(2025, 6, 9, 0, 0, 0, 0, time.UTC), IsRecurring: false, Country: "Cyprus"}, // Changes yearly
-		{Name: "Assumption of Mary", Date: time.Date(2025, 8, 15, 0, 0, 0, 0, time.UTC), IsRecurring: true, Country: "Cyprus"},
-		{Name: "Cyprus Independence Day", Date: time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC), IsRecurring: true, Country: "Cyprus"},
-		{Name: "Greek National Day (Ochi Day)", Date: time.Date(2025, 10, 28, 0, 0, 0, 0, time.UTC), IsRecurring: true, Country: "Cyprus"},
-		{Name: "Christmas Day", Date: time.Date(2025, 12, 25, 0, 0, 0, 0, time.UTC), IsRecurring: true, Country: "Cyprus"},
-		{Name: "Boxing Day", Date: time.Date(2025, 12, 26, 0, 0, 0, 0, time.UTC), IsRecurring: true, Country: "Cyprus"},
+		{Name: "New Year's Day", Date: models.NewDate(2025, 1, 1), IsRecurring: true, Country: "Cyprus"},
+		{Name: "Epiphany", Date: models.NewDate(2025, 1, 6), IsRecurring: true, Country: "Cyprus"},
+		{Name: "Green Monday", Date: models.NewDate(2025, 3, 3), IsRecurring: false, Country: "Cyprus"}, // Changes yearly
+		{Name: "Greek Independence Day", Date: models.NewDate(2025, 3, 25), IsRecurring: true, Country: "Cyprus"},
+		{Name: "Cyprus National Day", Date: models.NewDate(2025, 4, 1), IsRecurring: true, Country: "Cyprus"},
+		{Name: "Good Friday", Date: models.NewDate(2025, 4, 18), IsRecurring: false, Country: "Cyprus"}, // Changes yearly
+		{Name: "Easter Saturday", Date: models.NewDate(2025, 4, 19), IsRecurring: false, Country: "Cyprus"}, // Changes yearly
+		{Name: "Easter Sunday", Date: models.NewDate(2025, 4, 20), IsRecurring: false, Country: "Cyprus"}, // Changes yearly
+		{Name: "Easter Monday", Date: models.NewDate(2025, 4, 21), IsRecurring: false, Country: "Cyprus"}, // Changes yearly
+		{Name: "Labour Day", Date: models.NewDate(2025, 5, 1), IsRecurring: true, Country: "Cyprus"},
+		{Name: "Orthodox Pentecost Monday", Date: models.NewDate(2025, 6, 9), IsRecurring: false, Country: "Cyprus"}, // Changes yearly
+		{Name: "Assumption of Mary", Date: models.NewDate(2025, 8, 15), IsRecurring: true, Country: "Cyprus"},
+		{Name: "Cyprus Independence Day", Date: models.NewDate(2025, 10, 1), IsRecurring: true, Country: "Cyprus"},
+		{Name: "Greek National Day (Ochi Day)", Date: models.NewDate(2025, 10, 28), IsRecurring: true, Country: "Cyprus"},
+		{Name: "Christmas Day", Date: models.NewDate(2025, 12, 25), IsRecurring: true, Country: "Cyprus"},
+		{Name: "Boxing Day", Date: models.NewDate(2025, 12, 26), IsRecurring: true, Country: "Cyprus"},
 	}
 
 	for _, holiday := range holidays {
@@ -44,20 +44,26 @@ func InitializeCyprusHolidays(db *gorm.DB) error {
 }
 
 // IsHoliday checks if a given date is a holiday or Sunday
-func IsHoliday(db *gorm.DB, date time.Time) bool {
+func IsHoliday(db *gorm.DB, date models.Date) bool {
+	return HolidayReason(db, date) != ""
+}
+
+// HolidayReason returns why the date is a day off ("Sunday" or the holiday name),
+// or an empty string for a working day
+func HolidayReason(db *gorm.DB, date models.Date) string {
 	// Check if it's Sunday
 	if date.Weekday() == time.Sunday {
-		return true
+		return "Sunday"
 	}
 
 	// Check if it's a public holiday — exact date match only.
 	// Recurring logic is disabled: holidays stored with a past year date
 	// would otherwise block the same month/day in every future year.
-	var count int64
-	db.Model(&models.Holiday{}).
-		Where("date = ?", date.Format("2006-01-02")).
-		Count(&count)
+	var holiday models.Holiday
+	if err := db.Where("date = ?", date).Limit(1).Find(&holiday).Error; err != nil || holiday.ID == 0 {
+		return ""
+	}
 
-	return count > 0
+	return holiday.Name
 }
 
@@ -6,6 +6,7 @@ import (
 	"os"
 
 	"podlevskikh/awesomeProject/internal/data"
+	"podlevskikh/awesomeProject/internal/ingredients"
 	"podlevskikh/awesomeProject/internal/models"
 
 	"golang.org/x/crypto/bcrypt"
@@ -57,16 +58,31 @@ func Initialize(databaseURL string) error {
 		&models.RefreshToken{},
 		// Домен
 		&models.Recipe{},
+		&models.Ingredient{},
+		&models.RecipeIngredient{},
+		&models.FamilyMember{}, // до MealTime (meal_time_members)
+		&models.AllergenIntroduction{},
+		&models.NutritionFact{},
+		&models.WorkProfile{},
 		&models.MealTime{},
 		&models.CleaningZone{},
+		&models.ChildcarePattern{},
+		&models.ChildcareException{},
 		&models.ChildcareSchedule{},
 		&models.DailySchedule{},
 		&models.TaskCategory{}, // M2: до ScheduleTask (FK)
 		&models.ScheduleTask{},
+		&models.RecurringTask{},
 		&models.ShoppingListItem{},
+		&models.ShoppingItemSource{},
+		&models.PantryItem{},
+		&models.PantryDeduction{},
 		&models.Settings{},
 		&models.Holiday{},
 		&models.RecipeComment{},
+		&models.SchedulePreview{},
+		// Фоновые задачи
+		&models.JobRun{},
 	)
 
 	if err != nil {
@@ -78,8 +94,8 @@ func Initialize(databaseURL string) error {
 	// Run data migrations
 	runDataMigrations()
 
-	// Initialize default settings
-	initializeDefaultSettings()
+	// Default settings are not seeded: settings.Definitions provides defaults,
+	// rows in the settings table are per-organization overrides
 
 	// M2: seed дефолтных категорий задач для всех организаций
 	seedDefaultCategories()
@@ -102,8 +118,24 @@ func runDataMigrations() {
 	// Settings: drop old single-column unique index (replaced by composite org+key)
 	DB.Exec("DROP INDEX IF EXISTS uni_settings_key")
 
+	// Childcare blocks expanded from patterns before origin_date existed were never moved
+	DB.Exec("UPDATE childcare_schedules SET origin_date = date WHERE pattern_id IS NOT NULL AND origin_date IS NULL")
+
+	// Recurring occurrences created before occurrence_date existed stand for their schedule's date
+	DB.Exec(`UPDATE schedule_tasks SET occurrence_date = daily_schedules.date FROM daily_schedules
+		WHERE daily_schedules.id = schedule_tasks.schedule_id
+		AND schedule_tasks.recurring_task_id IS NOT NULL AND schedule_tasks.occurrence_date IS NULL`)
+
 	// M1: seed organisation + owner user if none exist, then backfill organization_id
 	seedOrgAndOwner()
+
+	// Структурированный состав из свободного текста Recipe.Ingredients
+	migrated, flagged, err := ingredients.MigrateFreeText(DB)
+	if err != nil {
+		log.Printf("Warning: failed to migrate recipe ingredients: %v", err)
+	} else if migrated > 0 {
+		log.Printf("Recipe ingredients: %d recipes migrated, %d lines need review", migrated, flagged)
+	}
 }
 
 // seedOrgAndOwner создаёт seed-организацию + владельца и заполняет organization_id
@@ -128,9 +160,13 @@ func seedOrgAndOwner() {
 	if ownerPassword == "" {
 		ownerPassword = "changeme123"
 	}
+	orgTimezone := os.Getenv("SEED_ORG_TIMEZONE")
+	if orgTimezone == "" {
+		orgTimezone = "UTC"
+	}
 
 	// 1. Создаём организацию
-	org := models.Organization{Name: orgName}
+	org := models.Organization{Name: orgName, Timezone: orgTimezone}
 	if err := DB.Create(&org).Error; err != nil {
 		log.Printf("Seed: не удалось создать организацию: %v", err)
 		return
@@ -246,24 +282,6 @@ func seedDefaultCategories() {
 	}
 }
 
-// initializeDefaultSettings создаёт дефолтные настройки для seed-организации (id=1)
-func initializeDefaultSettings() {
-	const seedOrgID = 1
-	defaultSettings := []models.Settings{
-		{OrganizationID: seedOrgID, Key: "schedule_days_ahead", Value: "7", Description: "Number of days to generate schedule ahead"},
-		{OrganizationID: seedOrgID, Key: "auto_generate_schedule", Value: "true", Description: "Automatically generate schedule daily"},
-	}
-
-	for _, setting := range defaultSettings {
-		var existing models.Settings
-		result := DB.Where("organization_id = ? AND key = ?", seedOrgID, setting.Key).First(&existing)
-		if result.Error == gorm.ErrRecordNotFound {
-			DB.Create(&setting)
-			log.Printf("Created default setting: %s = %s", setting.Key, setting.Value)
-		}
-	}
-}
-
 // GetDB returns the database instance
 func GetDB() *gorm.DB {
 	return DB
@@ -0,0 +1,274 @@
+package diet
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"podlevskikh/awesomeProject/internal/ingredients"
+	"podlevskikh/awesomeProject/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Виды конфликтов прикорма.
+const (
+	KindAge          = "age"          // рецепт не по возрасту
+	KindReaction     = "reaction"     // на аллерген была сильная реакция
+	KindIntroduction = "introduction" // правила введения нового аллергена
+)
+
+// Правила введения аллергенов в прикорм.
+const (
+	// MorningEnds — новый аллерген дают только утром (время приёма пищи раньше), в будни.
+	MorningEnds = "12:00"
+	// IntroductionGapDays — два разных новых аллергена не попадают в одни и те же три дня:
+	// между ними не меньше IntroductionGapDays+1 дней.
+	IntroductionGapDays = 2
+)
+
+// IntroductionAllergens — аллергены, с которыми ребёнка знакомят по одному.
+var IntroductionAllergens = []string{"milk", "eggs", "peanuts", "nuts", "fish", "shellfish", "soy", "sesame", "gluten"}
+
+// Allergens возвращает аллергены рецепта: указанные в Recipe.Allergens (группы, их синонимы
+// или любые названия) и найденные в составе среди IntroductionAllergens. Без повторов, по алфавиту.
+func Allergens(recipe models.Recipe) []string {
+	seen := make(map[string]bool)
+	for _, a := range List(recipe.Allergens) {
+		seen[AllergenKey(a)] = true
+	}
+	lines := recipe.RecipeIngredients
+	if len(lines) == 0 && recipe.Ingredients != "" {
+		lines = ingredients.FromText(recipe.Ingredients)
+	}
+	for _, line := range lines {
+		name := lineName(line)
+		for _, a := range IntroductionAllergens {
+			if groups[a].matches(name) {
+				seen[a] = true
+			}
+		}
+	}
+	out := make([]string, 0, len(seen))
+	for a := range seen {
+		out = append(out, a)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// AllergenKey приводит название аллергена к группе ("яйца" → "eggs"); неизвестные
+// названия остаются как есть (в нижнем регистре).
+func AllergenKey(name string) string {
+	key := normalize(name)
+	if alias, ok := allergenAliases[key]; ok {
+		return alias
+	}
+	return key
+}
+
+// AgeMonths — полных месяцев на дату date; -1, если дата рождения неизвестна.
+func AgeMonths(m models.FamilyMember, date models.Date) int {
+	if m.BirthDate == nil {
+		return -1
+	}
+	b := *m.BirthDate
+	months := (date.Year()-b.Year())*12 + int(date.Month()) - int(b.Month())
+	if date.Day() < b.Day() {
+		months--
+	}
+	return months
+}
+
+// IsBaby — ведётся ли для члена семьи журнал знакомства с аллергенами: ребёнок младше BabyMaxAge.
+func IsBaby(m models.FamilyMember, date models.Date) bool {
+	age := Age(m, date)
+	return age >= 0 && age < BabyMaxAge
+}
+
+// CheckAge возвращает конфликты рецепта с возрастом членов семьи (Recipe.MinAgeMonths).
+func CheckAge(recipe models.Recipe, members []models.FamilyMember, date models.Date) []Conflict {
+	if recipe.MinAgeMonths <= 0 {
+		return nil
+	}
+	var conflicts []Conflict
+	for _, m := range members {
+		if months := AgeMonths(m, date); months >= 0 && months < recipe.MinAgeMonths {
+			conflicts = append(conflicts, Conflict{
+				MemberID: m.ID, Member: m.Name, Kind: KindAge,
+				Restriction: fmt.Sprintf("from %d months", recipe.MinAgeMonths),
+				Ingredient:  fmt.Sprintf("%d months old", months),
+			})
+		}
+	}
+	return conflicts
+}
+
+// IntroductionPlan — что известно о знакомстве ребёнка с аллергенами вокруг даты приёма пищи.
+type IntroductionPlan struct {
+	Member     models.FamilyMember
+	Introduced map[string]models.AllergenIntroduction // журнал по аллергену
+	// Nearby — новые аллергены, введённые или запланированные не дальше IntroductionGapDays от даты
+	Nearby map[string]bool
+}
+
+// New возвращает аллергены из списка, с которыми ребёнок ещё не знаком.
+func (p IntroductionPlan) New(allergens []string) []string {
+	var out []string
+	for _, a := range allergens {
+		if _, ok := p.Introduced[a]; !ok {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// Check проверяет рецепт с аллергенами allergens в приёме пищи date в clock (HH:MM):
+// аллергены с сильной реакцией не даются никогда, новый аллерген — только один,
+// в будний день утром и не рядом с другим новым аллергеном.
+func (p IntroductionPlan) Check(allergens []string, date models.Date, clock string) []Conflict {
+	conflict := func(kind, restriction, ingredient string) Conflict {
+		return Conflict{MemberID: p.Member.ID, Member: p.Member.Name, Kind: kind, Restriction: restriction, Ingredient: ingredient}
+	}
+	var conflicts []Conflict
+	for _, a := range allergens {
+		if intro, ok := p.Introduced[a]; ok && intro.Reaction == models.ReactionSevere {
+			conflicts = append(conflicts, conflict(KindReaction, a, "severe reaction on "+intro.IntroducedOn.String()))
+		}
+	}
+
+	fresh := p.New(allergens)
+	switch {
+	case len(fresh) == 0:
+	case len(fresh) > 1:
+		conflicts = append(conflicts, conflict(KindIntroduction, "one new allergen at a time", strings.Join(fresh, ", ")))
+	case !weekdayMorning(date, clock):
+		conflicts = append(conflicts, conflict(KindIntroduction, "new allergens only on weekday mornings", fresh[0]))
+	default:
+		var other []string
+		for a := range p.Nearby {
+			if a != fresh[0] {
+				other = append(other, a)
+			}
+		}
+		if len(other) > 0 {
+			sort.Strings(other)
+			restriction := fmt.Sprintf("no two new allergens within %d days", IntroductionGapDays+1)
+			conflicts = append(conflicts, conflict(KindIntroduction, restriction, fresh[0]+" next to "+strings.Join(other, ", ")))
+		}
+	}
+	return conflicts
+}
+
+func weekdayMorning(date models.Date, clock string) bool {
+	wd := date.Weekday()
+	return wd != time.Saturday && wd != time.Sunday && clock != "" && clock < MorningEnds
+}
+
+// Introductions возвращает журнал знакомства члена семьи с аллергенами по аллергену.
+func Introductions(db *gorm.DB, memberID uint) (map[string]models.AllergenIntroduction, error) {
+	var list []models.AllergenIntroduction
+	if err := db.Where("family_member_id = ?", memberID).Find(&list).Error; err != nil {
+		return nil, fmt.Errorf("failed to load allergen introductions: %w", err)
+	}
+	out := make(map[string]models.AllergenIntroduction, len(list))
+	for _, intro := range list {
+		out[intro.Allergen] = intro
+	}
+	return out, nil
+}
+
+// LogMeal записывает в журнал аллергены выполненной meal-задачи, с которыми дети, для которых
+// этот приём пищи, познакомились впервые (реакция пока не указана). db может быть транзакцией.
+func LogMeal(db *gorm.DB, task *models.ScheduleTask) ([]models.AllergenIntroduction, error) {
+	if task.TaskType != "meal" {
+		return nil, nil
+	}
+	var full models.ScheduleTask
+	err := db.Preload("Recipes.RecipeIngredients.Ingredient").Preload("Recipe.RecipeIngredients.Ingredient").First(&full, task.ID).Error
+	if err != nil {
+		return nil, err
+	}
+	recipes := full.Recipes
+	if len(recipes) == 0 && full.Recipe != nil {
+		recipes = []models.Recipe{*full.Recipe}
+	}
+	if len(recipes) == 0 {
+		return nil, nil
+	}
+	var schedule models.DailySchedule
+	if err := db.First(&schedule, task.ScheduleID).Error; err != nil {
+		return nil, err
+	}
+	mealTime, err := mealTimeOf(db, task)
+	if err != nil || mealTime == nil {
+		return nil, err // без приёма пищи задача не от настроенного слота
+	}
+	members, err := MembersFor(db, *mealTime, schedule.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	var logged []models.AllergenIntroduction
+	for _, m := range members {
+		if !IsBaby(m, schedule.Date) {
+			continue
+		}
+		introduced, err := Introductions(db, m.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, recipe := range recipes {
+			for _, a := range Allergens(recipe) {
+				if _, ok := introduced[a]; ok {
+					continue
+				}
+				intro := models.AllergenIntroduction{
+					OrganizationID: task.OrganizationID,
+					FamilyMemberID: m.ID,
+					Allergen:       a,
+					IntroducedOn:   schedule.Date,
+					RecipeID:       &recipe.ID,
+					ScheduleTaskID: &task.ID,
+				}
+				if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&intro).Error; err != nil {
+					return nil, err
+				}
+				introduced[a] = intro
+				logged = append(logged, intro)
+			}
+		}
+	}
+	return logged, nil
+}
+
+// mealTimeOf находит приём пищи meal-задачи: по MealTimeID, а у задач без него — по названию.
+// nil — задача не от настроенного приёма пищи.
+func mealTimeOf(db *gorm.DB, task *models.ScheduleTask) (*models.MealTime, error) {
+	var mealTimes []models.MealTime
+	if err := db.Where("organization_id = ?", task.OrganizationID).Order("id").Find(&mealTimes).Error; err != nil {
+		return nil, err
+	}
+	for i, mt := range mealTimes {
+		if task.MealTimeID != nil && *task.MealTimeID == mt.ID || task.MealTimeID == nil && mt.TaskTitle() == task.Title {
+			return &mealTimes[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// UnlogMeal отменяет LogMeal для задачи, с которой сняли отметку о выполнении: удаляет записи
+// о знакомстве с аллергенами, сделанные при её выполнении, пока реакция не указана.
+// db может быть транзакцией.
+func UnlogMeal(db *gorm.DB, task *models.ScheduleTask) error {
+	if task.TaskType != "meal" {
+		return nil
+	}
+	if err := db.Where("schedule_task_id = ? AND (reaction = '' OR reaction IS NULL)", task.ID).
+		Delete(&models.AllergenIntroduction{}).Error; err != nil {
+		return fmt.Errorf("failed to delete allergen introductions: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,343 @@
+// Package diet — ограничения питания членов семьи (аллергии, диеты, нелюбимые продукты)
+// и проверка рецептов на конфликты с ними по составу.
+package diet
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"podlevskikh/awesomeProject/internal/ingredients"
+	"podlevskikh/awesomeProject/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Виды конфликтов.
+const (
+	KindAllergy = "allergy"
+	KindDiet    = "diet"
+	KindDislike = "dislike"
+)
+
+// Conflict — строка состава рецепта, которую члену семьи нельзя или не хочется есть.
+type Conflict struct {
+	MemberID    uint   `json:"member_id"`
+	Member      string `json:"member"`
+	Kind        string `json:"kind"`        // allergy, diet, dislike
+	Restriction string `json:"restriction"` // аллерген, тег диеты или нелюбимый продукт
+	Ingredient  string `json:"ingredient"`  // строка состава, нарушающая ограничение
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s: %s %q (%s)", c.Member, c.Kind, c.Restriction, c.Ingredient)
+}
+
+// group — группа продуктов. Слово названия продукта входит в группу, если начинается
+// с одного из words, но не с одного из except, и рядом с ним нет слова, начинающегося
+// с одного из qualifiers («кокосовое молоко», «молоко кокосовое» — не молочное).
+type group struct {
+	words      []string
+	except     []string
+	qualifiers []string
+}
+
+// Группы аллергенов и продуктов, запрещённых диетами. Аллергию можно указать
+// названием группы или её синонимом (allergenAliases); всё остальное ищется
+// в составе как название продукта.
+var groups = map[string]group{
+	"nuts": {
+		words:      []string{"орех", "миндал", "фундук", "кешью", "фисташ", "пекан", "макадами", "пралине", "nuts", "walnut", "hazelnut", "almond", "cashew", "pistachio", "pecan", "macadamia", "praline"},
+		qualifiers: []string{"мускатн"},
+	},
+	"peanuts": {words: []string{"арахис", "peanut"}},
+	"milk": {
+		words:      []string{"молок", "молоч", "сливк", "сливоч", "сыр", "творог", "творож", "кефир", "йогурт", "сметан", "ряженк", "сгущ", "моцарел", "пармезан", "брынз", "milk", "cream", "butter", "cheese", "yogurt", "yoghurt", "mozzarella", "parmesan", "ricotta"},
+		except:     []string{"сырой", "сырое", "сырая", "сырые", "сырых", "buttern"},
+		qualifiers: []string{"кокос", "миндальн", "соев", "овсян", "рисов", "растительн", "coconut", "almond", "soy", "oat", "rice", "peanut", "cocoa"},
+	},
+	"eggs": {
+		words:  []string{"яйц", "яйко", "яичн", "желтк", "майонез", "egg", "yolk", "mayonnaise"},
+		except: []string{"eggplant"},
+	},
+	"gluten": {
+		words:      []string{"пшени", "мука", "муки", "муку", "хлеб", "батон", "макарон", "спагетти", "лапш", "вермишел", "манн", "манк", "ячм", "ржан", "булгур", "кускус", "панировоч", "сухар", "wheat", "flour", "bread", "pasta", "spaghetti", "noodle", "barley", "rye", "couscous", "bulgur", "semolina", "breadcrumb"},
+		qualifiers: []string{"рисов", "кукурузн", "гречнев", "миндальн", "кокос", "безглютен", "rice", "corn", "buckwheat", "almond", "coconut", "free"},
+	},
+	"fish":      {words: []string{"рыб", "лосос", "семг", "треск", "тунец", "тунц", "форел", "скумбри", "сельд", "минтай", "хек", "судак", "горбуш", "анчоус", "fish", "salmon", "cod", "tuna", "trout", "mackerel", "herring", "anchov"}},
+	"shellfish": {words: []string{"кревет", "краб", "мидии", "мидий", "кальмар", "устриц", "лобстер", "омар", "shrimp", "prawn", "crab", "mussel", "squid", "oyster", "lobster"}},
+	"soy":       {words: []string{"соя", "сои", "соев", "тофу", "эдамаме", "soy", "tofu", "edamame"}},
+	"sesame":    {words: []string{"кунжут", "тахин", "sesame", "tahini"}},
+	"meat": {
+		words:      []string{"мяс", "говя", "свин", "баран", "телят", "куриц", "курин", "индейк", "индюш", "утк", "утин", "кролик", "фарш", "бекон", "ветчин", "колбас", "сосис", "сардел", "грудинк", "chicken", "beef", "pork", "lamb", "veal", "turkey", "duck", "bacon", "ham", "sausage", "meat", "mince"},
+		qualifiers: []string{"яйц", "яйко", "яичн", "egg"}, // куриное яйцо
+	},
+	"honey": {words: []string{"мед", "honey"}},
+	"sugar": {
+		words:      []string{"сахар", "сироп", "мед", "сгущ", "варень", "джем", "повидл", "шоколад", "конфитюр", "sugar", "syrup", "honey", "jam", "condensed", "chocolate"},
+		except:     []string{"сахарозамен"},
+		qualifiers: []string{"без", "free"},
+	},
+}
+
+// allergenAliases — другие названия групп аллергенов.
+var allergenAliases = map[string]string{
+	"орехи":        "nuts",
+	"tree nuts":    "nuts",
+	"арахис":       "peanuts",
+	"peanut":       "peanuts",
+	"nut":          "nuts",
+	"молоко":       "milk",
+	"dairy":        "milk",
+	"лактоза":      "milk",
+	"lactose":      "milk",
+	"яйца":         "eggs",
+	"egg":          "eggs",
+	"глютен":       "gluten",
+	"wheat":        "gluten",
+	"рыба":         "fish",
+	"морепродукты": "shellfish",
+	"seafood":      "shellfish",
+	"соя":          "soy",
+	"кунжут":       "sesame",
+	"мед":          "honey",
+}
+
+// Diets — известные теги диет и группы продуктов, которые они исключают.
+var Diets = map[string][]string{
+	"vegetarian":     {"meat", "fish", "shellfish"},
+	"pescatarian":    {"meat"},
+	"vegan":          {"meat", "fish", "shellfish", "milk", "eggs", "honey"},
+	"no-nuts":        {"nuts", "peanuts"},
+	"no-added-sugar": {"sugar"},
+	"gluten-free":    {"gluten"},
+	"dairy-free":     {"milk"},
+}
+
+// List разбирает список через запятую: без пустых элементов и повторов, в нижнем регистре.
+func List(s string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ",") {
+		v := ingredients.NormalizeName(part)
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		out = append(out, v)
+	}
+	return out
+}
+
+// Validate проверяет ограничения члена семьи перед сохранением.
+func Validate(m models.FamilyMember, today models.Date) error {
+	if strings.TrimSpace(m.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if m.BirthDate != nil && m.BirthDate.After(today) {
+		return fmt.Errorf("birth date is in the future")
+	}
+	for _, tag := range List(m.DietaryTags) {
+		if _, ok := Diets[tag]; !ok {
+			return fmt.Errorf("unknown dietary tag %q (known: %s)", tag, strings.Join(DietNames(), ", "))
+		}
+	}
+	return nil
+}
+
+// DietNames возвращает известные теги диет по алфавиту.
+func DietNames() []string {
+	names := make([]string, 0, len(Diets))
+	for name := range Diets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Возрастные группы для MealTime.FamilyMember.
+const (
+	BabyMaxAge  = 3 // baby — младше 3 лет
+	AdultMinAge = 18
+)
+
+// Age — полных лет на дату date; -1, если дата рождения неизвестна.
+func Age(m models.FamilyMember, date models.Date) int {
+	if m.BirthDate == nil {
+		return -1
+	}
+	b := *m.BirthDate
+	age := date.Year() - b.Year()
+	if date.Month() < b.Month() || (date.Month() == b.Month() && date.Day() < b.Day()) {
+		age--
+	}
+	return age
+}
+
+// inGroup — входит ли член семьи в возрастную группу на дату date. Без даты рождения
+// считается взрослым.
+func inGroup(m models.FamilyMember, group string, date models.Date) (member, known bool) {
+	age := Age(m, date)
+	switch group {
+	case "all":
+		return true, true
+	case "adult", "adults":
+		return age < 0 || age >= AdultMinAge, true
+	case "child", "children", "kids":
+		return age >= 0 && age < AdultMinAge, true
+	case "baby", "babies":
+		return age >= 0 && age < BabyMaxAge, true
+	}
+	return false, false
+}
+
+// MembersFor возвращает членов семьи, для которых готовится приём пищи: привязанных к нему
+// (MealTime.Members), а если таких нет — по строке MealTime.FamilyMember: "all" или пусто — все,
+// "adult", "child", "baby" — по возрасту на дату date, иначе имена через запятую.
+func MembersFor(db *gorm.DB, mealTime models.MealTime, date models.Date) ([]models.FamilyMember, error) {
+	var linked []models.FamilyMember
+	if err := db.Model(&mealTime).Association("Members").Find(&linked); err != nil {
+		return nil, fmt.Errorf("failed to load meal time members: %w", err)
+	}
+	if len(linked) > 0 {
+		return linked, nil
+	}
+
+	var all []models.FamilyMember
+	if err := db.Where("organization_id = ?", mealTime.OrganizationID).Order("id").Find(&all).Error; err != nil {
+		return nil, fmt.Errorf("failed to load family members: %w", err)
+	}
+	who := List(mealTime.FamilyMember)
+	if len(who) == 0 {
+		return all, nil
+	}
+	var out []models.FamilyMember
+	for _, m := range all {
+		for _, w := range who {
+			member, isGroup := inGroup(m, w, date)
+			if member || (!isGroup && ingredients.NormalizeName(m.Name) == w) {
+				out = append(out, m)
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+// Check возвращает конфликты состава рецепта с ограничениями членов семьи.
+// Рецепты без структурированного состава проверяются по свободному тексту Ingredients.
+func Check(recipe models.Recipe, members []models.FamilyMember) []Conflict {
+	lines := recipe.RecipeIngredients
+	if len(lines) == 0 && recipe.Ingredients != "" {
+		lines = ingredients.FromText(recipe.Ingredients)
+	}
+
+	var conflicts []Conflict
+	for _, m := range members {
+		add := func(kind, restriction string, match func(name string) bool) {
+			for _, line := range lines {
+				if match(lineName(line)) {
+					conflicts = append(conflicts, Conflict{
+						MemberID: m.ID, Member: m.Name, Kind: kind, Restriction: restriction, Ingredient: line.Name,
+					})
+					return // одного конфликта на ограничение достаточно
+				}
+			}
+		}
+		for _, allergy := range List(m.Allergies) {
+			if g, ok := allergenGroup(allergy); ok {
+				add(KindAllergy, allergy, g.matches)
+			} else {
+				add(KindAllergy, allergy, func(name string) bool { return containsWord(name, normalize(allergy)) })
+			}
+		}
+		for _, tag := range List(m.DietaryTags) {
+			tagGroups := Diets[tag]
+			add(KindDiet, tag, func(name string) bool {
+				for _, g := range tagGroups {
+					if groups[g].matches(name) {
+						return true
+					}
+				}
+				return false
+			})
+		}
+		for _, disliked := range List(m.DislikedIngredients) {
+			add(KindDislike, disliked, func(name string) bool { return containsWord(name, normalize(disliked)) })
+		}
+	}
+	return conflicts
+}
+
+// lineName — название продукта строки состава для поиска: как в рецепте и по справочнику.
+func lineName(line models.RecipeIngredient) string {
+	name := line.Name
+	if line.Ingredient != nil && !strings.EqualFold(line.Ingredient.Name, line.Name) {
+		name += " " + line.Ingredient.Name
+	}
+	return normalize(name)
+}
+
+func allergenGroup(allergy string) (group, bool) {
+	key := normalize(allergy)
+	if alias, ok := allergenAliases[key]; ok {
+		key = alias
+	}
+	g, ok := groups[key]
+	return g, ok
+}
+
+func (g group) matches(name string) bool {
+	words := strings.FieldsFunc(name, func(r rune) bool { return !unicode.IsLetter(r) })
+	for i, w := range words {
+		if !hasPrefix(w, g.words) || hasPrefix(w, g.except) {
+			continue
+		}
+		if i > 0 && hasPrefix(words[i-1], g.qualifiers) || i+1 < len(words) && hasPrefix(words[i+1], g.qualifiers) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+func hasPrefix(word string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(word, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize приводит название к нижнему регистру, «ё» — к «е».
+func normalize(s string) string {
+	return strings.ReplaceAll(ingredients.NormalizeName(s), "ё", "е")
+}
+
+// containsWord — есть ли в name слово, начинающееся с prefix (prefix может быть из нескольких слов).
+func containsWord(name, prefix string) bool {
+	if prefix == "" {
+		return false
+	}
+	for i := 0; i+len(prefix) <= len(name); {
+		j := strings.Index(name[i:], prefix)
+		if j < 0 {
+			return false
+		}
+		at := i + j
+		if at == 0 || !isLetter(name[:at]) {
+			return true
+		}
+		i = at + len(prefix)
+	}
+	return false
+}
+
+// isLetter — заканчивается ли s буквой.
+func isLetter(s string) bool {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return unicode.IsLetter(r)
+}
@@ -0,0 +1,136 @@
+package diet
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"podlevskikh/awesomeProject/internal/models"
+)
+
+func recipeOf(lines ...string) models.Recipe {
+	var r models.Recipe
+	for _, name := range lines {
+		r.RecipeIngredients = append(r.RecipeIngredients, models.RecipeIngredient{Name: name})
+	}
+	return r
+}
+
+func TestCheck(t *testing.T) {
+	member := models.FamilyMember{
+		Name:                "Маша",
+		Allergies:           "nuts, молоко, клубника",
+		DietaryTags:         "vegetarian, no-added-sugar",
+		DislikedIngredients: "Кинза",
+	}
+	cases := []struct {
+		lines []string
+		want  []string // restrictions in conflict
+	}{
+		{[]string{"Мука", "Яйца"}, nil},
+		{[]string{"Грецкие орехи"}, []string{"nuts"}},
+		{[]string{"Мускатный орех"}, nil},
+		{[]string{"Молоко кокосовое"}, nil},
+		{[]string{"Сливки 20%"}, []string{"молоко"}},
+		{[]string{"Клубника свежая"}, []string{"клубника"}},
+		{[]string{"Куриное филе", "Мёд"}, []string{"vegetarian", "no-added-sugar"}},
+		{[]string{"Яйцо куриное"}, nil},
+		{[]string{"Баклажан", "кинза"}, []string{"кинза"}},
+	}
+	for _, c := range cases {
+		conflicts := Check(recipeOf(c.lines...), []models.FamilyMember{member})
+		var got []string
+		for _, conflict := range conflicts {
+			got = append(got, conflict.Restriction)
+		}
+		if len(got) != len(c.want) {
+			t.Errorf("Check(%v) = %v; want %v", c.lines, got, c.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != c.want[i] {
+				t.Errorf("Check(%v) = %v; want %v", c.lines, got, c.want)
+				break
+			}
+		}
+	}
+}
+
+func TestAgeGroups(t *testing.T) {
+	date := models.NewDate(2025, time.June, 1)
+	birth := func(y int, m time.Month, d int) *models.Date {
+		b := models.NewDate(y, m, d)
+		return &b
+	}
+	baby := models.FamilyMember{Name: "Baby", BirthDate: birth(2023, time.June, 2)} // 1 год 11 месяцев
+	kid := models.FamilyMember{Name: "Kid", BirthDate: birth(2015, time.January, 1)}
+	adult := models.FamilyMember{Name: "Mom"}
+
+	for _, c := range []struct {
+		m     models.FamilyMember
+		group string
+		want  bool
+	}{
+		{baby, "baby", true}, {baby, "child", true}, {baby, "adult", false},
+		{kid, "baby", false}, {kid, "child", true},
+		{adult, "adult", true}, {adult, "baby", false}, {adult, "all", true},
+	} {
+		if got, _ := inGroup(c.m, c.group, date); got != c.want {
+			t.Errorf("inGroup(%s, %s) = %v; want %v", c.m.Name, c.group, got, c.want)
+		}
+	}
+	if _, known := inGroup(adult, "маша", date); known {
+		t.Errorf("a name must not be an age group")
+	}
+}
+
+func TestIntroductionRules(t *testing.T) {
+	monday, saturday := models.NewDate(2025, time.June, 2), models.NewDate(2025, time.June, 7)
+	plan := IntroductionPlan{
+		Member: models.FamilyMember{Name: "Baby"},
+		Introduced: map[string]models.AllergenIntroduction{
+			"milk": {Allergen: "milk", Reaction: models.ReactionNone},
+			"fish": {Allergen: "fish", Reaction: models.ReactionSevere},
+		},
+		Nearby: map[string]bool{},
+	}
+	kinds := func(conflicts []Conflict) string {
+		var s []string
+		for _, c := range conflicts {
+			s = append(s, c.Kind)
+		}
+		return strings.Join(s, ",")
+	}
+
+	cases := []struct {
+		allergens []string
+		date      models.Date
+		clock     string
+		nearby    string
+		want      string
+	}{
+		{[]string{"milk"}, saturday, "18:00", "", ""},
+		{[]string{"eggs"}, monday, "09:00", "", ""},
+		{[]string{"eggs"}, monday, "09:00", "eggs", ""},
+		{[]string{"eggs"}, monday, "13:00", "", KindIntroduction},
+		{[]string{"eggs"}, saturday, "09:00", "", KindIntroduction},
+		{[]string{"eggs", "gluten"}, monday, "09:00", "", KindIntroduction},
+		{[]string{"eggs"}, monday, "09:00", "peanuts", KindIntroduction},
+		{[]string{"fish"}, monday, "09:00", "", KindReaction},
+	}
+	for _, c := range cases {
+		plan.Nearby = map[string]bool{}
+		if c.nearby != "" {
+			plan.Nearby[c.nearby] = true
+		}
+		if got := kinds(plan.Check(c.allergens, c.date, c.clock)); got != c.want {
+			t.Errorf("Check(%v, %s %s, nearby %q) = %q; want %q", c.allergens, c.date.Weekday(), c.clock, c.nearby, got, c.want)
+		}
+	}
+
+	r := recipeOf("Яйцо куриное", "Мука пшеничная")
+	r.Allergens = "молоко"
+	if got := strings.Join(Allergens(r), ","); got != "eggs,gluten,milk" {
+		t.Errorf("Allergens = %q; want eggs,gluten,milk", got)
+	}
+}
@@ -1,28 +1,40 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
+	"podlevskikh/awesomeProject/internal/childcare"
+	"podlevskikh/awesomeProject/internal/cleaning"
+	"podlevskikh/awesomeProject/internal/importer"
+	"podlevskikh/awesomeProject/internal/ingredients"
 	"podlevskikh/awesomeProject/internal/middleware"
 	"podlevskikh/awesomeProject/internal/models"
+	"podlevskikh/awesomeProject/internal/scheduler"
+	"podlevskikh/awesomeProject/internal/settings"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type AdminHandler struct {
-	db *gorm.DB
+	db        *gorm.DB
+	scheduler *scheduler.Scheduler
+	settings  *settings.Service
 }
 
 func NewAdminHandler(db *gorm.DB) *AdminHandler {
-	return &AdminHandler{db: db}
+	return &AdminHandler{db: db, scheduler: scheduler.NewScheduler(db), settings: settings.NewService(db)}
 }
 
 // orgDB возвращает DB-сессию, скоупленную по organization_id из контекста.
@@ -38,22 +50,63 @@ func (h *AdminHandler) orgID(c *gin.Context) uint {
 
 // Recipe handlers
 
+// withIngredients preloads the structured ingredient lines in recipe order
+func withIngredients(db *gorm.DB) *gorm.DB {
+	return db.Preload("RecipeIngredients", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	}).Preload("RecipeIngredients.Ingredient")
+}
+
+// saveRecipeIngredients stores the structured ingredients when the client sent them,
+// otherwise reparses the free-text Ingredients if it changed (or was set on create)
+func saveRecipeIngredients(db *gorm.DB, recipe *models.Recipe, items []models.RecipeIngredient, textChanged bool) error {
+	switch {
+	case items != nil:
+		return ingredients.Replace(db, recipe, items)
+	case textChanged:
+		return ingredients.ReplaceFromText(db, recipe)
+	}
+	return nil
+}
+
+// recipeResponse is a saved recipe with dietary conflicts of the meal times it is linked to
+type recipeResponse struct {
+	models.Recipe
+	Warnings []dietWarning `json:"warnings,omitempty"`
+}
+
+// withDietWarnings checks the recipe against the people its meal times are for
+func (h *AdminHandler) withDietWarnings(c *gin.Context, recipe models.Recipe) recipeResponse {
+	warnings, err := dietWarnings(h.db, []models.Recipe{recipe}, recipe.MealTimes, middleware.MustOrganization(c).Today())
+	if err != nil {
+		log.Printf("Warning: failed to check dietary conflicts of recipe %d: %v", recipe.ID, err)
+	}
+	fillNutrition(h.db, recipe.OrganizationID, &recipe)
+	return recipeResponse{Recipe: recipe, Warnings: warnings}
+}
+
 func (h *AdminHandler) GetRecipes(c *gin.Context) {
 	var recipes []models.Recipe
-	if err := h.orgDB(c).Preload("MealTimes").Order("created_at DESC").Find(&recipes).Error; err != nil {
+	if err := withIngredients(h.orgDB(c)).Preload("MealTimes").Order("created_at DESC").Find(&recipes).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	ptrs := make([]*models.Recipe, len(recipes))
+	for i := range recipes {
+		ptrs[i] = &recipes[i]
+	}
+	fillNutrition(h.db, h.orgID(c), ptrs...)
 	c.JSON(http.StatusOK, recipes)
 }
 
 func (h *AdminHandler) GetRecipe(c *gin.Context) {
 	id := c.Param("id")
 	var recipe models.Recipe
-	if err := h.orgDB(c).Preload("MealTimes").First(&recipe, id).Error; err != nil {
+	if err := withIngredients(h.orgDB(c)).Preload("MealTimes").First(&recipe, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
 		return
 	}
+	fillNutrition(h.db, h.orgID(c), &recipe)
 	c.JSON(http.StatusOK, recipe)
 }
 
@@ -70,9 +123,25 @@ func (h *AdminHandler) CreateRecipe(c *gin.Context) {
 
 	recipe := input.Recipe
 	recipe.OrganizationID = h.orgID(c)
+	items := recipe.RecipeIngredients
+	recipe.RecipeIngredients = nil
+	if err := ingredients.Validate(items); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if recipe.MinAgeMonths < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "min_age_months must not be negative"})
+		return
+	}
 
-	// Create the recipe first
-	if err := h.db.Create(&recipe).Error; err != nil {
+	// Create the recipe first, then its ingredient lines
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&recipe).Error; err != nil {
+			return err
+		}
+		return saveRecipeIngredients(tx, &recipe, items, recipe.Ingredients != "")
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -92,9 +161,9 @@ func (h *AdminHandler) CreateRecipe(c *gin.Context) {
 	}
 
 	// Reload recipe with associations
-	h.db.Preload("MealTimes").First(&recipe, recipe.ID)
+	withIngredients(h.db).Preload("MealTimes").First(&recipe, recipe.ID)
 
-	c.JSON(http.StatusCreated, recipe)
+	c.JSON(http.StatusCreated, h.withDietWarnings(c, recipe))
 }
 
 func (h *AdminHandler) UpdateRecipe(c *gin.Context) {
@@ -116,6 +185,16 @@ func (h *AdminHandler) UpdateRecipe(c *gin.Context) {
 		return
 	}
 
+	if err := ingredients.Validate(input.RecipeIngredients); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.MinAgeMonths < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "min_age_months must not be negative"})
+		return
+	}
+	textChanged := input.Ingredients != recipe.Ingredients
+
 	// Update recipe fields
 	recipe.Name = input.Name
 	recipe.Description = input.Description
@@ -123,6 +202,8 @@ func (h *AdminHandler) UpdateRecipe(c *gin.Context) {
 	recipe.Instructions = input.Instructions
 	recipe.FamilyMember = input.FamilyMember
 	recipe.Tags = input.Tags
+	recipe.MinAgeMonths = input.MinAgeMonths
+	recipe.Allergens = input.Allergens
 	recipe.ImageURL = input.ImageURL
 	recipe.VideoURL = input.VideoURL
 	recipe.Rating = input.Rating
@@ -131,7 +212,13 @@ func (h *AdminHandler) UpdateRecipe(c *gin.Context) {
 	recipe.Servings = input.Servings
 	recipe.IsActive = input.IsActive
 
-	if err := h.db.Save(&recipe).Error; err != nil {
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&recipe).Error; err != nil {
+			return err
+		}
+		return saveRecipeIngredients(tx, &recipe, input.RecipeIngredients, textChanged)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -153,9 +240,109 @@ func (h *AdminHandler) UpdateRecipe(c *gin.Context) {
 	}
 
 	// Reload recipe with associations
-	h.db.Preload("MealTimes").First(&recipe, recipe.ID)
+	withIngredients(h.db).Preload("MealTimes").First(&recipe, recipe.ID)
 
-	c.JSON(http.StatusOK, recipe)
+	c.JSON(http.StatusOK, h.withDietWarnings(c, recipe))
+}
+
+// maxImportSize limits the pages and files accepted by ImportRecipe
+const maxImportSize = 5 << 20
+
+// ImportRecipe parses a recipe page (schema.org/Recipe JSON-LD) or plain text into a draft
+// that is not saved: the admin reviews it and accepts it with a regular POST /recipes.
+// Input: a multipart "file" (a saved web page or a .txt file) or JSON {"content": "..."}
+// with pasted HTML or text.
+func (h *AdminHandler) ImportRecipe(c *gin.Context) {
+	var data []byte
+	if file, err := c.FormFile("file"); err == nil {
+		if file.Size > maxImportSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "File is too large (max 5 MB)"})
+			return
+		}
+		src, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open uploaded file"})
+			return
+		}
+		defer src.Close()
+		if data, err = io.ReadAll(src); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read uploaded file"})
+			return
+		}
+	} else {
+		var input struct {
+			Content string `json:"content"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil || strings.TrimSpace(input.Content) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Upload a file or send the page or recipe text as content"})
+			return
+		}
+		if len(input.Content) > maxImportSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Content is too large (max 5 MB)"})
+			return
+		}
+		data = []byte(input.Content)
+	}
+
+	draft, err := importer.Parse(data)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	var existing int64
+	if err := h.orgDB(c).Model(&models.Recipe{}).Where("LOWER(name) = LOWER(?)", draft.Recipe.Name).Count(&existing).Error; err != nil {
+		log.Printf("Warning: failed to check for duplicate recipe %q: %v", draft.Recipe.Name, err)
+	} else if existing > 0 {
+		draft.Warnings = append(draft.Warnings, fmt.Sprintf("a recipe named %q already exists", draft.Recipe.Name))
+	}
+	fillNutrition(h.db, h.orgID(c), &draft.Recipe)
+
+	c.JSON(http.StatusOK, draft)
+}
+
+// GetRecipeSuggestions ranks active recipes by the pantry items about to expire they would use up.
+// Query: within_days (default: setting expiring_within_days), meal_time_id (only recipes
+// linked to that meal time), date (default: today)
+func (h *AdminHandler) GetRecipeSuggestions(c *gin.Context) {
+	orgID := h.orgID(c)
+
+	date := middleware.MustOrganization(c).Today()
+	if s := c.Query("date"); s != "" {
+		parsed, err := models.ParseDate(s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		date = parsed
+	}
+
+	withinDays := h.settings.Int(orgID, settings.KeyExpiringWithinDays)
+	if s := c.Query("within_days"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 || n > 60 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "within_days must be between 0 and 60"})
+			return
+		}
+		withinDays = n
+	}
+
+	var mealTimeID uint
+	if s := c.Query("meal_time_id"); s != "" {
+		var mealTime models.MealTime
+		if err := h.orgDB(c).First(&mealTime, s).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Meal time not found"})
+			return
+		}
+		mealTimeID = mealTime.ID
+	}
+
+	suggestions, err := h.scheduler.SuggestRecipes(orgID, mealTimeID, date, withinDays)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, suggestions)
 }
 
 func (h *AdminHandler) DeleteRecipe(c *gin.Context) {
@@ -195,6 +382,12 @@ func (h *AdminHandler) DeleteRecipe(c *gin.Context) {
 		return
 	}
 
+	// 5. Delete the structured ingredient lines
+	if err := h.db.Where("recipe_id = ?", id).Delete(&models.RecipeIngredient{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete recipe ingredients"})
+		return
+	}
+
 	// Now delete the recipe itself
 	if err := h.db.Delete(&recipe).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -260,7 +453,7 @@ func (h *AdminHandler) UploadRecipeImage(c *gin.Context) {
 
 func (h *AdminHandler) GetMealTimes(c *gin.Context) {
 	var mealTimes []models.MealTime
-	if err := h.orgDB(c).Preload("Recipes").Order("default_time").Find(&mealTimes).Error; err != nil {
+	if err := h.orgDB(c).Preload("Recipes").Preload("Members").Order("default_time").Find(&mealTimes).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -271,7 +464,7 @@ func (h *AdminHandler) GetMealTime(c *gin.Context) {
 	id := c.Param("id")
 	var mealTime models.MealTime
 
-	if err := h.db.Preload("Recipes").First(&mealTime, id).Error; err != nil {
+	if err := h.db.Preload("Recipes").Preload("Members").First(&mealTime, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Meal time not found"})
 		return
 	}
@@ -279,14 +472,54 @@ func (h *AdminHandler) GetMealTime(c *gin.Context) {
 	c.JSON(http.StatusOK, mealTime)
 }
 
+// mealTimeResponse is a saved meal time with dietary conflicts of its recipes
+type mealTimeResponse struct {
+	models.MealTime
+	Warnings []dietWarning `json:"warnings,omitempty"`
+}
+
+// familyMembers loads the organization's family members by ID; ok is false if any is missing
+func (h *AdminHandler) familyMembers(c *gin.Context, ids []uint) (members []models.FamilyMember, ok bool, err error) {
+	if len(ids) == 0 {
+		return nil, true, nil
+	}
+	if err := h.orgDB(c).Find(&members, ids).Error; err != nil {
+		return nil, false, err
+	}
+	return members, len(members) == len(ids), nil
+}
+
 func (h *AdminHandler) CreateMealTime(c *gin.Context) {
-	var mealTime models.MealTime
-	if err := c.ShouldBindJSON(&mealTime); err != nil {
+	var input struct {
+		models.MealTime
+		MemberIDs []uint `json:"member_ids"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	mealTime := input.MealTime
+	mealTime.OrganizationID = h.orgID(c)
+	members, ok, err := h.familyMembers(c, input.MemberIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Family member not found"})
+		return
+	}
+	mealTime.Members = members
+	if err := scheduler.ValidateStrategy(mealTime.Strategy, mealTime.StrategyParams); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if mealTime.Headcount < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "headcount must not be negative"})
+		return
+	}
 	
-	if err := h.db.Create(&mealTime).Error; err != nil {
+	if err := h.db.Omit("Recipes", "Members.*").Create(&mealTime).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -306,14 +539,33 @@ func (h *AdminHandler) UpdateMealTime(c *gin.Context) {
 	var input struct {
 		models.MealTime
 		RecipeIDs []uint `json:"recipe_ids"`
+		MemberIDs []uint `json:"member_ids"` // nil keeps the linked family members
 	}
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	if input.Headcount < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "headcount must not be negative"})
+		return
+	}
+
+	members, ok, err := h.familyMembers(c, input.MemberIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Family member not found"})
+		return
+	}
+
 	input.MealTime.ID = mealTime.ID
-	if err := h.db.Save(&input.MealTime).Error; err != nil {
+	input.MealTime.OrganizationID = mealTime.OrganizationID
+	input.MealTime.Recipes, input.MealTime.Members = nil, nil
+	// The strategy is changed only through UpdateMealTimeStrategy
+	if err := h.db.Omit("strategy", "strategy_params").Save(&input.MealTime).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -330,13 +582,96 @@ func (h *AdminHandler) UpdateMealTime(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update recipes"})
 		return
 	}
+	if input.MemberIDs != nil {
+		if err := h.db.Model(&input.MealTime).Association("Members").Replace(members); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update family members"})
+			return
+		}
+	}
+
+	h.db.Preload("Recipes").Preload("Members").First(&input.MealTime, input.MealTime.ID)
+	warnings, err := dietWarnings(h.db, input.MealTime.Recipes, []models.MealTime{input.MealTime}, middleware.MustOrganization(c).Today())
+	if err != nil {
+		log.Printf("Warning: failed to check dietary conflicts of meal time %d: %v", input.MealTime.ID, err)
+	}
+	c.JSON(http.StatusOK, mealTimeResponse{MealTime: input.MealTime, Warnings: warnings})
+}
 
-	h.db.Preload("Recipes").First(&input.MealTime, input.MealTime.ID)
-	c.JSON(http.StatusOK, input.MealTime)
+// GetRecipeStrategies lists the recipe selection strategies a meal time can use
+func (h *AdminHandler) GetRecipeStrategies(c *gin.Context) {
+	c.JSON(http.StatusOK, scheduler.Strategies())
+}
+
+// UpdateMealTimeStrategy sets the recipe selection strategy of a meal time and its JSON parameters.
+// Body: {"strategy": "fixed_weekday", "params": {...}}; an empty strategy means the default.
+func (h *AdminHandler) UpdateMealTimeStrategy(c *gin.Context) {
+	var mealTime models.MealTime
+	if err := h.orgDB(c).First(&mealTime, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Meal time not found"})
+		return
+	}
+
+	var input struct {
+		Strategy string          `json:"strategy"`
+		Params   json.RawMessage `json:"params"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	params := strings.TrimSpace(string(input.Params))
+	if params == "null" {
+		params = ""
+	}
+	if err := scheduler.ValidateStrategy(input.Strategy, params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if ids := scheduler.FixedRecipeIDs(input.Strategy, params); len(ids) > 0 {
+		var found []uint
+		if err := h.db.Model(&models.Recipe{}).Where("organization_id = ? AND id IN ?", mealTime.OrganizationID, ids).
+			Pluck("id", &found).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if missing := missingIDs(ids, found); len(missing) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("recipes not found: %v", missing)})
+			return
+		}
+	}
+
+	if err := h.db.Model(&mealTime).Updates(map[string]interface{}{
+		"strategy":        input.Strategy,
+		"strategy_params": params,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	mealTime.Strategy, mealTime.StrategyParams = input.Strategy, params
+	c.JSON(http.StatusOK, mealTime)
+}
+
+// missingIDs returns the IDs from want that are not in found
+func missingIDs(want, found []uint) []uint {
+	have := make(map[uint]bool, len(found))
+	for _, id := range found {
+		have[id] = true
+	}
+	var missing []uint
+	for _, id := range want {
+		if !have[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing
 }
 
 func (h *AdminHandler) DeleteMealTime(c *gin.Context) {
 	id := c.Param("id")
+	if err := h.db.Exec("DELETE FROM meal_time_members WHERE meal_time_id = ?", id).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear family members association"})
+		return
+	}
 	if err := h.db.Delete(&models.MealTime{}, id).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -373,6 +708,10 @@ func (h *AdminHandler) CreateCleaningZone(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := cleaning.Validate(zone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	
 	if err := h.db.Create(&zone).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -395,6 +734,10 @@ func (h *AdminHandler) UpdateCleaningZone(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := cleaning.Validate(zone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	
 	if err := h.db.Save(&zone).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -428,12 +771,11 @@ func (h *AdminHandler) DeleteCleaningZone(c *gin.Context) {
 func (h *AdminHandler) GetChildcareSchedules(c *gin.Context) {
 	var schedules []models.ChildcareSchedule
 
-	// Normalize to UTC midnight so today's entries are always included
-	now := time.Now().UTC()
-	startDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
-	endDate := startDate.AddDate(0, 0, 60)
+	// Start from today in the organization's timezone so today's entries are always included
+	startDate := middleware.MustOrganization(c).Today()
+	endDate := startDate.AddDays(60)
 
-	if err := h.orgDB(c).Where("date >= ? AND date < ?", startDate, endDate).
+	if err := h.orgDB(c).Preload("Children").Where("date >= ? AND date < ?", startDate, endDate).
 		Order("date, start_time").Find(&schedules).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -445,7 +787,7 @@ func (h *AdminHandler) GetChildcareSchedule(c *gin.Context) {
 	id := c.Param("id")
 	var schedule models.ChildcareSchedule
 
-	if err := h.db.First(&schedule, id).Error; err != nil {
+	if err := h.orgDB(c).Preload("Children").First(&schedule, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Childcare schedule not found"})
 		return
 	}
@@ -453,14 +795,40 @@ func (h *AdminHandler) GetChildcareSchedule(c *gin.Context) {
 	c.JSON(http.StatusOK, schedule)
 }
 
+// childcareInput is a childcare block with the IDs of the children it covers
+type childcareInput struct {
+	models.ChildcareSchedule
+	MemberIDs []uint `json:"member_ids"` // nil keeps the children of an updated block
+}
+
+// CreateChildcareSchedule adds a childcare block; a day can have several
 func (h *AdminHandler) CreateChildcareSchedule(c *gin.Context) {
-	var schedule models.ChildcareSchedule
-	if err := c.ShouldBindJSON(&schedule); err != nil {
+	var input childcareInput
+	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	
-	if err := h.db.Create(&schedule).Error; err != nil {
+	if err := childcare.ValidateBlock(input.StartTime, input.EndTime); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	children, ok, err := h.familyMembers(c, input.MemberIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Family member not found"})
+		return
+	}
+
+	schedule := input.ChildcareSchedule
+	schedule.ID = 0
+	schedule.OrganizationID = h.orgID(c)
+	schedule.PatternID = nil
+	schedule.Edited = false
+	schedule.Children = children
+	if err := h.db.Omit("Children.*").Create(&schedule).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -468,31 +836,92 @@ func (h *AdminHandler) CreateChildcareSchedule(c *gin.Context) {
 	c.JSON(http.StatusCreated, schedule)
 }
 
+// UpdateChildcareSchedule changes a childcare block. A block expanded from a weekly pattern
+// is marked edited and kept when the pattern changes.
 func (h *AdminHandler) UpdateChildcareSchedule(c *gin.Context) {
 	id := c.Param("id")
 	var schedule models.ChildcareSchedule
 	
-	if err := h.db.First(&schedule, id).Error; err != nil {
+	if err := h.orgDB(c).First(&schedule, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Childcare schedule not found"})
 		return
 	}
 	
-	if err := c.ShouldBindJSON(&schedule); err != nil {
+	var input childcareInput
+	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	
-	if err := h.db.Save(&schedule).Error; err != nil {
+	if err := childcare.ValidateBlock(input.StartTime, input.EndTime); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	children, ok, err := h.familyMembers(c, input.MemberIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Family member not found"})
+		return
+	}
+
+	// A pattern block moved off the date it was expanded for leaves an exception behind there
+	origin := childcare.Origin(schedule)
+	moved := schedule.PatternID != nil && !input.Date.IsZero() && !input.Date.Equal(schedule.Date) && schedule.Date.Equal(origin)
+	if schedule.PatternID != nil {
+		schedule.OriginDate = &origin
+	}
+	if !input.Date.IsZero() {
+		schedule.Date = input.Date
+	}
+	schedule.StartTime = input.StartTime
+	schedule.EndTime = input.EndTime
+	schedule.Notes = input.Notes
+	schedule.Edited = schedule.PatternID != nil
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if moved {
+			exception := models.ChildcareException{
+				OrganizationID: schedule.OrganizationID,
+				PatternID:      schedule.PatternID,
+				StartDate:      origin,
+				EndDate:        origin,
+				Reason:         "block moved to " + schedule.Date.String(),
+			}
+			if err := tx.Create(&exception).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Omit("Children").Save(&schedule).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if input.MemberIDs != nil {
+		if err := h.db.Model(&schedule).Association("Children").Replace(children); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update children"})
+			return
+		}
+	}
 	
+	h.db.Preload("Children").First(&schedule, schedule.ID)
 	c.JSON(http.StatusOK, schedule)
 }
 
+// DeleteChildcareSchedule removes a childcare block. A block expanded from a weekly pattern
+// becomes an exception of the pattern for its date, so it is not expanded again.
 func (h *AdminHandler) DeleteChildcareSchedule(c *gin.Context) {
 	id := c.Param("id")
-	if err := h.db.Delete(&models.ChildcareSchedule{}, id).Error; err != nil {
+	var schedule models.ChildcareSchedule
+	if err := h.orgDB(c).First(&schedule, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Childcare schedule not found"})
+		return
+	}
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		return deleteChildcareBlock(tx, schedule)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -501,15 +930,165 @@ func (h *AdminHandler) DeleteChildcareSchedule(c *gin.Context) {
 
 // Schedule management
 
+// RegenerateSchedule replaces the generated tasks of the caller's organization in one transaction.
+// Query: from=YYYY-MM-DD (default today), days=N (default schedule_days_ahead, at most 60),
+// types=meal,cleaning,childcare (default all), reshuffle=true (new random picks instead of
+// reproducing the previous ones). Custom, edited and completed tasks are kept.
 func (h *AdminHandler) RegenerateSchedule(c *gin.Context) {
-	daysStr := c.DefaultQuery("days", "7")
-	days, err := strconv.Atoi(daysStr)
+	opts, ok := h.regenerateOptions(c)
+	if !ok {
+		return
+	}
+
+	diff, err := h.scheduler.RegenerateSchedules(h.orgID(c), opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Schedule regenerated successfully", "diff": diff})
+}
+
+// CreateSchedulePreview runs a regeneration as a dry run and stores the result for review.
+// Takes the same query parameters as RegenerateSchedule.
+func (h *AdminHandler) CreateSchedulePreview(c *gin.Context) {
+	opts, ok := h.regenerateOptions(c)
+	if !ok {
+		return
+	}
+
+	preview, err := h.scheduler.CreatePreview(h.orgID(c), c.GetUint(middleware.ContextKeyUserID), opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, preview)
+}
+
+// GetSchedulePreview returns a stored preview with its proposed schedules and decisions
+func (h *AdminHandler) GetSchedulePreview(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid preview ID"})
+		return
+	}
+
+	preview, err := h.scheduler.GetPreview(h.orgID(c), uint(id))
+	if err != nil {
+		previewError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, preview)
+}
+
+// AcceptSchedulePreview applies a pending preview exactly as it was shown
+func (h *AdminHandler) AcceptSchedulePreview(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid preview ID"})
+		return
+	}
+
+	diff, err := h.scheduler.AcceptPreview(h.orgID(c), uint(id), c.GetUint(middleware.ContextKeyUserID))
 	if err != nil {
-		days = 7
+		previewError(c, err)
+		return
 	}
+	c.JSON(http.StatusOK, gin.H{"message": "Preview accepted", "diff": diff})
+}
 
-	// This will be called from the main server with scheduler instance
-	c.JSON(http.StatusOK, gin.H{"message": "Schedule regeneration triggered", "days": days})
+// RejectSchedulePreview discards a pending preview
+func (h *AdminHandler) RejectSchedulePreview(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid preview ID"})
+		return
+	}
+
+	if err := h.scheduler.RejectPreview(h.orgID(c), uint(id), c.GetUint(middleware.ContextKeyUserID)); err != nil {
+		previewError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Preview rejected"})
+}
+
+// regenerateOptions parses from/days/types/reshuffle query parameters; on error it writes the response
+func (h *AdminHandler) regenerateOptions(c *gin.Context) (scheduler.RegenerateOptions, bool) {
+	orgID := h.orgID(c)
+
+	from := middleware.MustOrganization(c).Today()
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsed, err := models.ParseDate(fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return scheduler.RegenerateOptions{}, false
+		}
+		from = parsed
+	}
+	// Past days are history: their uncompleted generated tasks are not rewritten
+	if today := middleware.MustOrganization(c).Today(); from.Before(today) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("from must not be earlier than today (%s)", today)})
+		return scheduler.RegenerateOptions{}, false
+	}
+
+	days := h.settings.Int(orgID, settings.KeyScheduleDaysAhead)
+	if daysStr := c.Query("days"); daysStr != "" {
+		// Same bound as the schedule_days_ahead setting: the whole range runs in one transaction
+		maxDays := 60
+		if def, ok := settings.Lookup(settings.KeyScheduleDaysAhead); ok {
+			maxDays = def.Max
+		}
+		d, err := strconv.Atoi(daysStr)
+		if err != nil || d <= 0 || d > maxDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("days must be between 1 and %d", maxDays)})
+			return scheduler.RegenerateOptions{}, false
+		}
+		days = d
+	}
+
+	types, err := taskTypes(c.Query("types"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return scheduler.RegenerateOptions{}, false
+	}
+
+	reshuffle, _ := strconv.ParseBool(c.Query("reshuffle"))
+
+	return scheduler.RegenerateOptions{
+		From:      from,
+		To:        from.AddDays(days),
+		TaskTypes: types,
+		Reshuffle: reshuffle,
+	}, true
+}
+
+// taskTypes parses a comma-separated list of generated task types; empty means all of them
+func taskTypes(s string) ([]string, error) {
+	var types []string
+	for _, t := range strings.Split(s, ",") {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+		if !slices.Contains(scheduler.GeneratedTaskTypes, t) {
+			return nil, fmt.Errorf("unknown task type %q, use %s", t, strings.Join(scheduler.GeneratedTaskTypes, ", "))
+		}
+		types = append(types, t)
+	}
+	return types, nil
+}
+
+// previewError maps preview errors to HTTP statuses
+func previewError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Preview not found"})
+	case errors.Is(err, scheduler.ErrPreviewExpired):
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	case errors.Is(err, scheduler.ErrPreviewClosed), errors.Is(err, scheduler.ErrPreviewStale):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
 }
 
 // Recipe Comments handlers
@@ -592,6 +1171,7 @@ func (h *AdminHandler) UpdateTask(c *gin.Context) {
 	task.Description = input.Description
 	task.TaskCategoryID = input.TaskCategoryID
 	task.AssignedToUserID = input.AssignedToUserID
+	task.Edited = true
 
 	if err := h.db.Save(&task).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -674,6 +1254,7 @@ func (h *AdminHandler) AddRecipeToTask(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	h.db.Model(&task).Update("edited", true)
 
 	// Reload task with recipes
 	if err := h.db.Preload("Recipes").First(&task, taskID).Error; err != nil {
@@ -708,6 +1289,7 @@ func (h *AdminHandler) RemoveRecipeFromTask(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	h.db.Model(&task).Update("edited", true)
 
 	// Reload task with recipes
 	if err := h.db.Preload("Recipes").First(&task, taskID).Error; err != nil {
@@ -756,6 +1338,7 @@ func (h *AdminHandler) AddZoneToTask(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	h.db.Model(&task).Update("edited", true)
 
 	// Reload task with zones
 	if err := h.db.Preload("Zones").First(&task, taskID).Error; err != nil {
@@ -790,6 +1373,7 @@ func (h *AdminHandler) RemoveZoneFromTask(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	h.db.Model(&task).Update("edited", true)
 
 	// Reload task with zones
 	if err := h.db.Preload("Zones").First(&task, taskID).Error; err != nil {
@@ -822,12 +1406,11 @@ func (h *AdminHandler) CreateCustomTask(c *gin.Context) {
 		return
 	}
 
-	date, err := time.Parse("2006-01-02", input.Date)
+	date, err := models.ParseDate(input.Date)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date format, use YYYY-MM-DD"})
 		return
 	}
-	date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
 
 	// Find or create daily_schedule for this date
 	var schedule models.DailySchedule
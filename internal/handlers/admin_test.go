@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTaskTypes(t *testing.T) {
+	cases := []struct {
+		query string
+		want  string // types joined with "," or "error"
+	}{
+		{"", ""},
+		{"meal, cleaning", "meal,cleaning"},
+		{" childcare ,,recurring", "childcare,recurring"},
+		{"meal,custom", "error"},
+		{"Meal", "error"},
+	}
+	for _, c := range cases {
+		types, err := taskTypes(c.query)
+		got := strings.Join(types, ",")
+		if err != nil {
+			got = "error"
+		}
+		if got != c.want {
+			t.Errorf("taskTypes(%q) = %q, want %q", c.query, got, c.want)
+		}
+	}
+}
@@ -29,6 +29,7 @@ type registerRequest struct {
 	Email    string `json:"email"    binding:"required,email"`
 	Password string `json:"password" binding:"required,min=8"`
 	OrgName  string `json:"org_name" binding:"required"`
+	Timezone string `json:"timezone"` // опционально, IANA-зона; по умолчанию UTC
 }
 
 type loginRequest struct {
@@ -105,7 +106,7 @@ func (h *AuthHandler) loadMemberships(userID uint) ([]membershipView, error) {
 
 // Register godoc
 // POST /auth/register
-// Body: {email, password, org_name}
+// Body: {email, password, org_name, timezone?}
 // Создаёт User + Organization + owner Membership. Возвращает токены.
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req registerRequest
@@ -129,7 +130,14 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	}
 
 	user := models.User{Email: req.Email, PasswordHash: hash, Name: req.Email, Locale: "ru"}
-	org := models.Organization{Name: strings.TrimSpace(req.OrgName)}
+	org := models.Organization{Name: strings.TrimSpace(req.OrgName), Timezone: "UTC"}
+	if req.Timezone != "" {
+		if _, err := time.LoadLocation(req.Timezone); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown timezone"})
+			return
+		}
+		org.Timezone = req.Timezone
+	}
 
 	// Транзакция: user + org + membership
 	err = h.db.Transaction(func(tx *gorm.DB) error {
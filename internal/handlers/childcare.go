@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"podlevskikh/awesomeProject/internal/childcare"
+	"podlevskikh/awesomeProject/internal/middleware"
+	"podlevskikh/awesomeProject/internal/models"
+	"podlevskikh/awesomeProject/internal/scheduler"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ChildcareHandler — еженедельные шаблоны присмотра за детьми и исключения из них.
+type ChildcareHandler struct {
+	db        *gorm.DB
+	scheduler *scheduler.Scheduler
+}
+
+func NewChildcareHandler(db *gorm.DB) *ChildcareHandler {
+	return &ChildcareHandler{db: db, scheduler: scheduler.NewScheduler(db)}
+}
+
+// childcarePatternInput — шаблон из запроса; member_ids — дети, за которыми присмотр.
+type childcarePatternInput struct {
+	models.ChildcarePattern
+	Active    *bool  `json:"active"`
+	MemberIDs []uint `json:"member_ids"`
+}
+
+// ListPatterns возвращает шаблоны присмотра организации с детьми.
+// GET /admin/api/childcare-patterns
+func (h *ChildcareHandler) ListPatterns(c *gin.Context) {
+	var patterns []models.ChildcarePattern
+	if err := h.db.Preload("Children").Where("organization_id = ?", middleware.MustMembership(c).OrganizationID).
+		Order("start_time, id").Find(&patterns).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, patterns)
+}
+
+// CreatePattern создаёт шаблон и разворачивает его в уже сгенерированные расписания.
+// POST /admin/api/childcare-patterns
+func (h *ChildcareHandler) CreatePattern(c *gin.Context) {
+	var input childcarePatternInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	pattern := models.ChildcarePattern{OrganizationID: middleware.MustMembership(c).OrganizationID, Active: true}
+	children, ok := h.apply(c, &pattern, input)
+	if !ok {
+		return
+	}
+	pattern.Children = children
+	if err := h.db.Omit("Children.*").Create(&pattern).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.refresh(c) {
+		return
+	}
+	c.JSON(http.StatusCreated, pattern)
+}
+
+// UpdatePattern меняет шаблон. Блоки, развёрнутые из него начиная с сегодняшнего дня,
+// пересоздаются; блоки, изменённые вручную, остаются.
+// PUT /admin/api/childcare-patterns/:id
+func (h *ChildcareHandler) UpdatePattern(c *gin.Context) {
+	pattern, ok := h.findPattern(c)
+	if !ok {
+		return
+	}
+	var input childcarePatternInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	children, ok := h.apply(c, &pattern, input)
+	if !ok {
+		return
+	}
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Children").Save(&pattern).Error; err != nil {
+			return err
+		}
+		if input.MemberIDs == nil {
+			return nil
+		}
+		return tx.Model(&pattern).Association("Children").Replace(children)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.refresh(c) {
+		return
+	}
+	h.db.Preload("Children").First(&pattern, pattern.ID)
+	c.JSON(http.StatusOK, pattern)
+}
+
+// DeletePattern удаляет шаблон и его исключения. Блоки с сегодняшнего дня удаляются вместе
+// с задачами присмотра; прошедшие блоки остаются без привязки к шаблону.
+// DELETE /admin/api/childcare-patterns/:id
+func (h *ChildcareHandler) DeletePattern(c *gin.Context) {
+	pattern, ok := h.findPattern(c)
+	if !ok {
+		return
+	}
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ChildcareSchedule{}).Where("pattern_id = ? AND date < ?", pattern.ID, middleware.MustOrganization(c).Today()).
+			Update("pattern_id", nil).Error; err != nil {
+			return err
+		}
+		// Блоки с сегодняшнего дня удаляются при пересоздании, в том числе изменённые вручную
+		if err := tx.Model(&models.ChildcareSchedule{}).Where("pattern_id = ?", pattern.ID).
+			Update("edited", false).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("pattern_id = ?", pattern.ID).Delete(&models.ChildcareException{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&pattern).Association("Children").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(&pattern).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.refresh(c) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Childcare pattern deleted"})
+}
+
+// ListExceptions возвращает исключения организации, которые ещё не закончились.
+// GET /admin/api/childcare-exceptions
+func (h *ChildcareHandler) ListExceptions(c *gin.Context) {
+	var exceptions []models.ChildcareException
+	if err := h.db.Where("organization_id = ? AND end_date >= ?",
+		middleware.MustMembership(c).OrganizationID, middleware.MustOrganization(c).Today()).
+		Order("start_date, id").Find(&exceptions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, exceptions)
+}
+
+// CreateException добавляет дни без присмотра по шаблонам: отпуск родителя по уходу, поездку.
+// Без pattern_id исключение действует на все шаблоны.
+// POST /admin/api/childcare-exceptions
+func (h *ChildcareHandler) CreateException(c *gin.Context) {
+	orgID := middleware.MustMembership(c).OrganizationID
+	var input models.ChildcareException
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := childcare.ValidateException(input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.PatternID != nil {
+		var count int64
+		if err := h.db.Model(&models.ChildcarePattern{}).Where("organization_id = ? AND id = ?", orgID, *input.PatternID).
+			Count(&count).Error; err != nil || count == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Childcare pattern not found"})
+			return
+		}
+	}
+	exception := models.ChildcareException{
+		OrganizationID: orgID,
+		PatternID:      input.PatternID,
+		StartDate:      input.StartDate,
+		EndDate:        input.EndDate,
+		Reason:         strings.TrimSpace(input.Reason),
+	}
+	if err := h.db.Create(&exception).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.refresh(c) {
+		return
+	}
+	c.JSON(http.StatusCreated, exception)
+}
+
+// DeleteException удаляет исключение: шаблоны снова действуют в эти дни.
+// DELETE /admin/api/childcare-exceptions/:id
+func (h *ChildcareHandler) DeleteException(c *gin.Context) {
+	result := h.db.Where("organization_id = ?", middleware.MustMembership(c).OrganizationID).
+		Delete(&models.ChildcareException{}, c.Param("id"))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Childcare exception not found"})
+		return
+	}
+	if !h.refresh(c) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Childcare exception deleted"})
+}
+
+// findPattern загружает шаблон организации по :id.
+func (h *ChildcareHandler) findPattern(c *gin.Context) (models.ChildcarePattern, bool) {
+	var pattern models.ChildcarePattern
+	err := h.db.Where("organization_id = ?", middleware.MustMembership(c).OrganizationID).First(&pattern, c.Param("id")).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Childcare pattern not found"})
+		return pattern, false
+	}
+	return pattern, true
+}
+
+// apply переносит поля шаблона из запроса в pattern, проверяет его и загружает детей
+// по member_ids; при ошибке отвечает 400.
+func (h *ChildcareHandler) apply(c *gin.Context, pattern *models.ChildcarePattern, input childcarePatternInput) ([]models.FamilyMember, bool) {
+	pattern.Name = strings.TrimSpace(input.Name)
+	pattern.Weekdays = strings.ToUpper(strings.TrimSpace(input.Weekdays))
+	pattern.StartTime = strings.TrimSpace(input.StartTime)
+	pattern.EndTime = strings.TrimSpace(input.EndTime)
+	pattern.Notes = input.Notes
+	pattern.ValidFrom = input.ValidFrom
+	pattern.ValidUntil = input.ValidUntil
+	if input.Active != nil {
+		pattern.Active = *input.Active
+	}
+	if err := childcare.Validate(*pattern); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	children, err := childrenByID(h.db, pattern.OrganizationID, input.MemberIDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return children, true
+}
+
+// refresh пересоздаёт блоки шаблонов и задачи присмотра начиная с сегодняшнего дня.
+func (h *ChildcareHandler) refresh(c *gin.Context) bool {
+	_, err := h.scheduler.RefreshChildcare(middleware.MustMembership(c).OrganizationID, middleware.MustOrganization(c).Today())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "saved, but failed to update schedules: " + err.Error()})
+		return false
+	}
+	return true
+}
+
+// childrenByID загружает членов семьи организации по ID; ошибка, если кого-то нет.
+func childrenByID(db *gorm.DB, orgID uint, ids []uint) ([]models.FamilyMember, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var members []models.FamilyMember
+	if err := db.Where("organization_id = ?", orgID).Find(&members, ids).Error; err != nil {
+		return nil, err
+	}
+	if len(members) != len(ids) {
+		return nil, errors.New("family member not found")
+	}
+	return members, nil
+}
+
+// deleteChildcareBlock удаляет блок присмотра. Блок, развёрнутый из шаблона, становится
+// исключением шаблона на свою дату, чтобы не развернуться снова; у перенесённого блока
+// исключение на исходную дату уже есть.
+func deleteChildcareBlock(tx *gorm.DB, block models.ChildcareSchedule) error {
+	if block.PatternID != nil && childcare.Origin(block).Equal(block.Date) {
+		exception := models.ChildcareException{
+			OrganizationID: block.OrganizationID,
+			PatternID:      block.PatternID,
+			StartDate:      block.Date,
+			EndDate:        block.Date,
+			Reason:         "block removed",
+		}
+		if err := tx.Create(&exception).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Model(&block).Association("Children").Clear(); err != nil {
+		return err
+	}
+	return tx.Delete(&block).Error
+}
@@ -0,0 +1,304 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"podlevskikh/awesomeProject/internal/diet"
+	"podlevskikh/awesomeProject/internal/middleware"
+	"podlevskikh/awesomeProject/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// FamilyHandler — члены семьи и их ограничения питания.
+type FamilyHandler struct {
+	db *gorm.DB
+}
+
+func NewFamilyHandler(db *gorm.DB) *FamilyHandler {
+	return &FamilyHandler{db: db}
+}
+
+// List возвращает членов семьи организации и известные теги диет.
+// GET /admin/api/family-members
+func (h *FamilyHandler) List(c *gin.Context) {
+	orgID := middleware.MustMembership(c).OrganizationID
+	var members []models.FamilyMember
+	if err := h.db.Where("organization_id = ?", orgID).Order("name").Find(&members).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"members": members, "dietary_tags": diet.DietNames()})
+}
+
+// Create добавляет члена семьи.
+// POST /admin/api/family-members
+func (h *FamilyHandler) Create(c *gin.Context) {
+	var member models.FamilyMember
+	if err := c.ShouldBindJSON(&member); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := diet.Validate(member, middleware.MustOrganization(c).Today()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	member.ID = 0
+	member.OrganizationID = middleware.MustMembership(c).OrganizationID
+	member.Name = strings.TrimSpace(member.Name)
+
+	if err := h.db.Create(&member).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, member)
+}
+
+// Update меняет имя, дату рождения и ограничения члена семьи.
+// PUT /admin/api/family-members/:id
+func (h *FamilyHandler) Update(c *gin.Context) {
+	orgID := middleware.MustMembership(c).OrganizationID
+	var member models.FamilyMember
+	if err := h.db.Where("organization_id = ?", orgID).First(&member, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Family member not found"})
+		return
+	}
+
+	var input models.FamilyMember
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := diet.Validate(input, middleware.MustOrganization(c).Today()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member.Name = strings.TrimSpace(input.Name)
+	member.BirthDate = input.BirthDate
+	member.Allergies = input.Allergies
+	member.DietaryTags = input.DietaryTags
+	member.DislikedIngredients = input.DislikedIngredients
+	if err := h.db.Save(&member).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, member)
+}
+
+// Delete удаляет члена семьи, его привязки к приёмам пищи и блокам присмотра и журнал
+// знакомства с аллергенами.
+// DELETE /admin/api/family-members/:id
+func (h *FamilyHandler) Delete(c *gin.Context) {
+	orgID := middleware.MustMembership(c).OrganizationID
+	var member models.FamilyMember
+	if err := h.db.Where("organization_id = ?", orgID).First(&member, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Family member not found"})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM meal_time_members WHERE family_member_id = ?", member.ID).Error; err != nil {
+			return err
+		}
+		for _, table := range []string{"childcare_pattern_children", "childcare_schedule_children"} {
+			if err := tx.Exec("DELETE FROM "+table+" WHERE family_member_id = ?", member.ID).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("family_member_id = ?", member.ID).Delete(&models.AllergenIntroduction{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&member).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Family member deleted"})
+}
+
+// introductionInput — тело POST/PUT записи журнала знакомства с аллергеном.
+type introductionInput struct {
+	Allergen     string       `json:"allergen"`
+	IntroducedOn *models.Date `json:"introduced_on"`
+	RecipeID     *uint        `json:"recipe_id"`
+	Reaction     string       `json:"reaction"`
+	Notes        string       `json:"notes"`
+}
+
+func validReaction(r string) bool {
+	switch r {
+	case "", models.ReactionNone, models.ReactionMild, models.ReactionSevere:
+		return true
+	}
+	return false
+}
+
+// ListIntroductions возвращает журнал знакомства ребёнка с аллергенами по дате.
+// GET /admin/api/family-members/:id/introductions
+func (h *FamilyHandler) ListIntroductions(c *gin.Context) {
+	orgID := middleware.MustMembership(c).OrganizationID
+	var member models.FamilyMember
+	if err := h.db.Where("organization_id = ?", orgID).First(&member, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Family member not found"})
+		return
+	}
+	var list []models.AllergenIntroduction
+	if err := h.db.Where("family_member_id = ?", member.ID).Order("introduced_on, allergen").Find(&list).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// CreateIntroduction записывает первое знакомство ребёнка с аллергеном.
+// Выполнение meal-задачи записывает новые аллергены само; здесь — то, что было вне расписания.
+// POST /admin/api/family-members/:id/introductions
+func (h *FamilyHandler) CreateIntroduction(c *gin.Context) {
+	orgID := middleware.MustMembership(c).OrganizationID
+	var member models.FamilyMember
+	if err := h.db.Where("organization_id = ?", orgID).First(&member, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Family member not found"})
+		return
+	}
+	var input introductionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	allergen := diet.AllergenKey(input.Allergen)
+	if allergen == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "allergen is required"})
+		return
+	}
+	if !validReaction(input.Reaction) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reaction must be none, mild or severe"})
+		return
+	}
+
+	var existing models.AllergenIntroduction
+	if err := h.db.Where("family_member_id = ? AND allergen = ?", member.ID, allergen).First(&existing).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Allergen already introduced on " + existing.IntroducedOn.String(), "introduction": existing})
+		return
+	}
+
+	intro := models.AllergenIntroduction{
+		OrganizationID: orgID,
+		FamilyMemberID: member.ID,
+		Allergen:       allergen,
+		IntroducedOn:   middleware.MustOrganization(c).Today(),
+		RecipeID:       input.RecipeID,
+		Reaction:       input.Reaction,
+		Notes:          input.Notes,
+	}
+	if input.IntroducedOn != nil {
+		intro.IntroducedOn = *input.IntroducedOn
+	}
+	if err := h.db.Create(&intro).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, intro)
+}
+
+// UpdateIntroduction записывает реакцию, заметки или исправляет дату знакомства.
+// Сильная реакция (severe) исключает аллерген из расписания.
+// PUT /admin/api/introductions/:id
+func (h *FamilyHandler) UpdateIntroduction(c *gin.Context) {
+	orgID := middleware.MustMembership(c).OrganizationID
+	var intro models.AllergenIntroduction
+	if err := h.db.Where("organization_id = ?", orgID).First(&intro, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Introduction not found"})
+		return
+	}
+	var input introductionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !validReaction(input.Reaction) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reaction must be none, mild or severe"})
+		return
+	}
+
+	intro.Reaction = input.Reaction
+	intro.Notes = input.Notes
+	if input.IntroducedOn != nil {
+		intro.IntroducedOn = *input.IntroducedOn
+	}
+	if err := h.db.Save(&intro).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, intro)
+}
+
+// DeleteIntroduction удаляет запись журнала: аллерген снова считается новым.
+// DELETE /admin/api/introductions/:id
+func (h *FamilyHandler) DeleteIntroduction(c *gin.Context) {
+	orgID := middleware.MustMembership(c).OrganizationID
+	res := h.db.Where("organization_id = ?", orgID).Delete(&models.AllergenIntroduction{}, c.Param("id"))
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": res.Error.Error()})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Introduction not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Introduction deleted"})
+}
+
+// dietWarning — конфликт рецепта с ограничениями того, для кого готовится приём пищи.
+type dietWarning struct {
+	MealTimeID uint   `json:"meal_time_id"`
+	MealTime   string `json:"meal_time"`
+	RecipeID   uint   `json:"recipe_id"`
+	Recipe     string `json:"recipe"`
+	diet.Conflict
+}
+
+// dietWarnings проверяет рецепты, привязанные к приёмам пищи, на конфликты с аллергиями,
+// диетами, нелюбимыми продуктами и возрастом тех, для кого эти приёмы пищи. Планировщик
+// такие рецепты не назначает, поэтому админ узнаёт о конфликте сразу при привязке.
+func dietWarnings(db *gorm.DB, recipes []models.Recipe, mealTimes []models.MealTime, date models.Date) ([]dietWarning, error) {
+	if len(recipes) == 0 || len(mealTimes) == 0 {
+		return nil, nil
+	}
+	ids := make([]uint, len(recipes))
+	for i, r := range recipes {
+		ids[i] = r.ID
+	}
+	var lines []models.RecipeIngredient
+	if err := db.Preload("Ingredient").Where("recipe_id IN ?", ids).Order("recipe_id, position").Find(&lines).Error; err != nil {
+		return nil, err
+	}
+	byRecipe := make(map[uint][]models.RecipeIngredient)
+	for _, line := range lines {
+		byRecipe[line.RecipeID] = append(byRecipe[line.RecipeID], line)
+	}
+
+	var warnings []dietWarning
+	for _, mt := range mealTimes {
+		members, err := diet.MembersFor(db, mt, date)
+		if err != nil {
+			return nil, err
+		}
+		if len(members) == 0 {
+			continue
+		}
+		for _, r := range recipes {
+			r.RecipeIngredients = byRecipe[r.ID]
+			conflicts := append(diet.Check(r, members), diet.CheckAge(r, members, date)...)
+			for _, conflict := range conflicts {
+				warnings = append(warnings, dietWarning{
+					MealTimeID: mt.ID, MealTime: mt.Name, RecipeID: r.ID, Recipe: r.Name, Conflict: conflict,
+				})
+			}
+		}
+	}
+	return warnings, nil
+}
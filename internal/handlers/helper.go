@@ -5,8 +5,13 @@ import (
 	"strconv"
 	"time"
 
+	"podlevskikh/awesomeProject/internal/childcare"
+	"podlevskikh/awesomeProject/internal/diet"
+	"podlevskikh/awesomeProject/internal/ingredients"
 	"podlevskikh/awesomeProject/internal/middleware"
 	"podlevskikh/awesomeProject/internal/models"
+	"podlevskikh/awesomeProject/internal/pantry"
+	"podlevskikh/awesomeProject/internal/units"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -31,6 +36,11 @@ func (h *HelperHandler) orgID(c *gin.Context) uint {
 	return middleware.MustMembership(c).OrganizationID
 }
 
+// today возвращает сегодняшнюю дату в часовом поясе организации.
+func (h *HelperHandler) today(c *gin.Context) models.Date {
+	return middleware.MustOrganization(c).Today()
+}
+
 // taskPreload возвращает функцию Preload для задач с фильтром по assigned_to_user_id.
 // Хелпер видит только свои задачи + неназначенные; owner/admin/manager — все.
 func (h *HelperHandler) taskPreload(c *gin.Context) func(*gorm.DB) *gorm.DB {
@@ -47,39 +57,39 @@ func (h *HelperHandler) taskPreload(c *gin.Context) func(*gorm.DB) *gorm.DB {
 	}
 }
 
-// mergeChildcareTasks ensures all ChildcareSchedule entries for `date` have a
+// mergeChildcareTasks ensures all ChildcareSchedule entries for the schedule's date have a
 // corresponding ScheduleTask in the given schedule. Missing tasks are created in
-// the DB and appended to schedule.Tasks.  `date` is the local calendar date.
-func (h *HelperHandler) mergeChildcareTasks(schedule *models.DailySchedule, date time.Time) {
-	utcDate := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
-	nextDay := utcDate.AddDate(0, 0, 1)
-
+// the DB and appended to schedule.Tasks.
+func (h *HelperHandler) mergeChildcareTasks(schedule *models.DailySchedule) {
 	var ccList []models.ChildcareSchedule
-	if err := h.db.Where("date >= ? AND date < ?", utcDate, nextDay).Find(&ccList).Error; err != nil {
+	if err := h.db.Preload("Children").Where("organization_id = ? AND date = ?", schedule.OrganizationID, schedule.Date).
+		Find(&ccList).Error; err != nil {
 		return
 	}
 
-	// Build set of existing childcare task start times so we don't duplicate.
+	// Build set of existing childcare tasks (start time and title) so we don't duplicate.
 	existing := make(map[string]bool)
 	for _, t := range schedule.Tasks {
 		if t.TaskType == "childcare" {
-			existing[t.Time] = true
+			existing[t.Time+" "+t.Title] = true
 		}
 	}
 
 	for _, cc := range ccList {
-		if existing[cc.StartTime] {
+		title := childcare.Title(cc.Children)
+		if existing[cc.StartTime+" "+title] {
 			continue
 		}
 		task := models.ScheduleTask{
-			ScheduleID:  schedule.ID,
-			TaskType:    "childcare",
-			Time:        cc.StartTime,
-			EndTime:     cc.EndTime,
-			Duration:    childcareDuration(cc.StartTime, cc.EndTime),
-			Title:       "Childcare",
-			Description: cc.Notes,
-			Completed:   false,
+			OrganizationID: schedule.OrganizationID,
+			ScheduleID:     schedule.ID,
+			TaskType:       "childcare",
+			Time:           cc.StartTime,
+			EndTime:        cc.EndTime,
+			Duration:       childcareDuration(cc.StartTime, cc.EndTime),
+			Title:          title,
+			Description:    cc.Notes,
+			Completed:      false,
 		}
 		if err := h.db.Create(&task).Error; err == nil {
 			schedule.Tasks = append(schedule.Tasks, task)
@@ -96,25 +106,23 @@ func childcareDuration(startTime, endTime string) int {
 
 // GetTodaySchedule returns today's schedule with all tasks
 func (h *HelperHandler) GetTodaySchedule(c *gin.Context) {
-	today := time.Now()
-	todayStart := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
+	today := h.today(c)
 
 	var schedule models.DailySchedule
 	err := h.orgDB(c).Preload("Tasks", h.taskPreload(c)).
-		Where("date = ?", todayStart).First(&schedule).Error
+		Where("date = ?", today).First(&schedule).Error
 
 	if err == gorm.ErrRecordNotFound {
 		// No generated schedule yet — create a minimal one if childcare entries exist.
-		utcDate := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
 		var ccCount int64
 		h.orgDB(c).Model(&models.ChildcareSchedule{}).
-			Where("date >= ? AND date < ?", utcDate, utcDate.AddDate(0, 0, 1)).
+			Where("date = ?", today).
 			Count(&ccCount)
 		if ccCount == 0 {
 			c.JSON(http.StatusOK, gin.H{"message": "No schedule for today", "tasks": []models.ScheduleTask{}})
 			return
 		}
-		schedule = models.DailySchedule{Date: todayStart, Generated: false, OrganizationID: h.orgID(c)}
+		schedule = models.DailySchedule{Date: today, Generated: false, OrganizationID: h.orgID(c)}
 		if createErr := h.db.Create(&schedule).Error; createErr != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": createErr.Error()})
 			return
@@ -125,14 +133,14 @@ func (h *HelperHandler) GetTodaySchedule(c *gin.Context) {
 		return
 	}
 
-	h.mergeChildcareTasks(&schedule, today)
+	h.mergeChildcareTasks(&schedule)
 	c.JSON(http.StatusOK, schedule)
 }
 
 // GetScheduleByDate returns schedule for a specific date
 func (h *HelperHandler) GetScheduleByDate(c *gin.Context) {
 	dateStr := c.Param("date") // format: YYYY-MM-DD
-	date, err := time.Parse("2006-01-02", dateStr)
+	date, err := models.ParseDate(dateStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
 		return
@@ -144,10 +152,9 @@ func (h *HelperHandler) GetScheduleByDate(c *gin.Context) {
 
 	if loadErr == gorm.ErrRecordNotFound {
 		// No generated schedule yet — create a minimal one if childcare entries exist.
-		utcDate := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
 		var ccCount int64
 		h.orgDB(c).Model(&models.ChildcareSchedule{}).
-			Where("date >= ? AND date < ?", utcDate, utcDate.AddDate(0, 0, 1)).
+			Where("date = ?", date).
 			Count(&ccCount)
 		if ccCount == 0 {
 			c.JSON(http.StatusOK, gin.H{"message": "No schedule for this date", "tasks": []models.ScheduleTask{}})
@@ -164,7 +171,7 @@ func (h *HelperHandler) GetScheduleByDate(c *gin.Context) {
 		return
 	}
 
-	h.mergeChildcareTasks(&schedule, date)
+	h.mergeChildcareTasks(&schedule)
 	c.JSON(http.StatusOK, schedule)
 }
 
@@ -178,20 +185,14 @@ func (h *HelperHandler) GetUpcomingSchedules(c *gin.Context) {
 	}
 
 	// Support custom start date
-	var startDate time.Time
+	startDate := h.today(c)
 	if startDateParam := c.Query("start_date"); startDateParam != "" {
-		if parsed, err := time.Parse("2006-01-02", startDateParam); err == nil {
-			startDate = time.Date(parsed.Year(), parsed.Month(), parsed.Day(), 0, 0, 0, 0, time.UTC)
-		} else {
-			today := time.Now()
-			startDate = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+		if parsed, err := models.ParseDate(startDateParam); err == nil {
+			startDate = parsed
 		}
-	} else {
-		today := time.Now()
-		startDate = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
 	}
 
-	endDate := startDate.AddDate(0, 0, days)
+	endDate := startDate.AddDays(days)
 
 	var schedules []models.DailySchedule
 	if err := h.orgDB(c).Preload("Tasks", h.taskPreload(c)).
@@ -203,13 +204,15 @@ func (h *HelperHandler) GetUpcomingSchedules(c *gin.Context) {
 
 	// Merge childcare entries into existing schedules.
 	for i := range schedules {
-		h.mergeChildcareTasks(&schedules[i], schedules[i].Date)
+		h.mergeChildcareTasks(&schedules[i])
 	}
 
 	c.JSON(http.StatusOK, schedules)
 }
 
-// CompleteTask marks a task as completed
+// CompleteTask marks a task as completed. For a meal task the recipe ingredients,
+// scaled to the task's servings, are deducted from the pantry (once per task);
+// what the pantry lacked is returned in pantry_shortages.
 func (h *HelperHandler) CompleteTask(c *gin.Context) {
 	taskID := c.Param("id")
 
@@ -218,17 +221,39 @@ func (h *HelperHandler) CompleteTask(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
 		return
 	}
-	
-	task.Completed = true
-	if err := h.db.Save(&task).Error; err != nil {
+
+	var shortages []pantry.Shortage
+	var introduced []models.AllergenIntroduction
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		task.Completed = true
+		if err := tx.Save(&task).Error; err != nil {
+			return err
+		}
+		var err error
+		if shortages, err = pantry.DeductMeal(tx, &task); err != nil {
+			return err
+		}
+		introduced, err = diet.LogMeal(tx, &task)
+		return err
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	
-	c.JSON(http.StatusOK, task)
+
+	c.JSON(http.StatusOK, completedTask{ScheduleTask: task, PantryShortages: shortages, NewAllergens: introduced})
+}
+
+// completedTask is the CompleteTask response: the task, the ingredients the pantry lacked and
+// the allergens a baby met for the first time (their reaction is recorded later)
+type completedTask struct {
+	models.ScheduleTask
+	PantryShortages []pantry.Shortage             `json:"pantry_shortages,omitempty"`
+	NewAllergens    []models.AllergenIntroduction `json:"new_allergens,omitempty"`
 }
 
-// UncompleteTask marks a task as not completed
+// UncompleteTask marks a task as not completed, puts the meal ingredients taken on completion
+// back into the pantry and drops the allergen introductions logged on completion
 func (h *HelperHandler) UncompleteTask(c *gin.Context) {
 	taskID := c.Param("id")
 
@@ -238,8 +263,17 @@ func (h *HelperHandler) UncompleteTask(c *gin.Context) {
 		return
 	}
 	
-	task.Completed = false
-	if err := h.db.Save(&task).Error; err != nil {
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := pantry.RestoreMeal(tx, &task); err != nil {
+			return err
+		}
+		if err := diet.UnlogMeal(tx, &task); err != nil {
+			return err
+		}
+		task.Completed = false
+		return tx.Save(&task).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -251,7 +285,7 @@ func (h *HelperHandler) UncompleteTask(c *gin.Context) {
 
 func (h *HelperHandler) GetShoppingList(c *gin.Context) {
 	var items []models.ShoppingListItem
-	if err := h.orgDB(c).Where("purchased = ?", false).Order("category, item").Find(&items).Error; err != nil {
+	if err := h.orgDB(c).Where("purchased = ?", false).Preload("Sources").Order("category, item").Find(&items).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -267,6 +301,10 @@ func (h *HelperHandler) AddShoppingListItem(c *gin.Context) {
 	
 	item.AddedBy = "helper"
 	item.OrganizationID = h.orgID(c)
+	// "0.5 kg" → amount/unit, so the shopping list generator can add to it
+	if q, ok := units.Parse(item.Quantity); ok {
+		item.Amount, item.Unit = &q.Amount, q.Unit
+	}
 
 	if err := h.db.Create(&item).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -285,8 +323,20 @@ func (h *HelperHandler) MarkItemPurchased(c *gin.Context) {
 		return
 	}
 	
+	// The bought quantity goes to the pantry (?location=fridge); only on the first purchase
+	wasPurchased := item.Purchased
 	item.Purchased = true
-	if err := h.db.Save(&item).Error; err != nil {
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Sources").Save(&item).Error; err != nil {
+			return err
+		}
+		if wasPurchased {
+			return nil
+		}
+		_, err := pantry.AddPurchase(tx, &item, c.Query("location"))
+		return err
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -308,24 +358,50 @@ func (h *HelperHandler) GetRecipeDetails(c *gin.Context) {
 	recipeID := c.Param("id")
 
 	var recipe models.Recipe
-	if err := h.orgDB(c).First(&recipe, recipeID).Error; err != nil {
+	if err := withIngredients(h.orgDB(c)).First(&recipe, recipeID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
 		return
 	}
 
+	fillNutrition(h.db, h.orgID(c), &recipe)
+
+	// ?servings=N returns the ingredient list scaled from recipe.Servings to N portions
+	if s := c.Query("servings"); s != "" {
+		servings, err := strconv.Atoi(s)
+		if err != nil || servings < 1 || servings > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "servings must be between 1 and 100"})
+			return
+		}
+		if recipe.Servings <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Recipe servings are not set, cannot scale"})
+			return
+		}
+		c.JSON(http.StatusOK, scaledRecipe{
+			Recipe:            recipe,
+			ScaledServings:    servings,
+			ScaledIngredients: ingredients.Scale(recipe.RecipeIngredients, recipe.Servings, servings),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, recipe)
 }
 
+// scaledRecipe is a recipe with its ingredients recalculated for another number of servings
+type scaledRecipe struct {
+	models.Recipe
+	ScaledServings    int                       `json:"scaled_servings"`
+	ScaledIngredients []models.RecipeIngredient `json:"scaled_ingredients"`
+}
+
 // Childcare handlers
 
-// GetTodayChildcare returns childcare schedule for today
+// GetTodayChildcare returns today's childcare blocks
 func (h *HelperHandler) GetTodayChildcare(c *gin.Context) {
-	today := time.Now()
-	todayStart := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
-	nextDay := todayStart.AddDate(0, 0, 1)
+	today := h.today(c)
 
 	var schedules []models.ChildcareSchedule
-	if err := h.orgDB(c).Where("date >= ? AND date < ?", todayStart, nextDay).
+	if err := h.orgDB(c).Preload("Children").Where("date = ?", today).
 		Order("start_time").Find(&schedules).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -334,70 +410,122 @@ func (h *HelperHandler) GetTodayChildcare(c *gin.Context) {
 	c.JSON(http.StatusOK, schedules)
 }
 
-// SaveTodayChildcare saves or updates childcare schedule for today
+// SaveTodayChildcare adds a childcare block for today, or updates the block given by id.
+// A request without id always adds a block. A day can have several blocks; member_ids
+// names the children a block covers.
 func (h *HelperHandler) SaveTodayChildcare(c *gin.Context) {
 	var input struct {
+		ID        uint   `json:"id"`
 		StartTime string `json:"start_time" binding:"required"`
 		EndTime   string `json:"end_time" binding:"required"`
 		Notes     string `json:"notes"`
+		MemberIDs []uint `json:"member_ids"` // nil keeps the children of an updated block
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := childcare.ValidateBlock(input.StartTime, input.EndTime); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	children, err := childrenByID(h.db, h.orgID(c), input.MemberIDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	today := time.Now()
-	todayStart := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
-	nextDay := todayStart.AddDate(0, 0, 1)
-
-	// Check if there's already a childcare schedule for today
-	var existing models.ChildcareSchedule
-	err := h.orgDB(c).Where("date >= ? AND date < ?", todayStart, nextDay).First(&existing).Error
+	today := h.today(c)
 
-	if err == gorm.ErrRecordNotFound {
-		// Create new schedule
+	if input.ID == 0 {
+		// Create new block
 		schedule := models.ChildcareSchedule{
 			OrganizationID: h.orgID(c),
-			Date:           todayStart,
+			Date:           today,
 			StartTime:      input.StartTime,
 			EndTime:        input.EndTime,
 			Notes:          input.Notes,
+			Children:       children,
 		}
 
-		if err := h.db.Create(&schedule).Error; err != nil {
+		if err := h.db.Omit("Children.*").Create(&schedule).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
 		c.JSON(http.StatusCreated, schedule)
-	} else if err != nil {
+		return
+	}
+
+	// Update existing block
+	var blocks []models.ChildcareSchedule
+	if err := h.orgDB(c).Where("date = ?", today).Find(&blocks).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
-	} else {
-		// Update existing schedule
-		existing.StartTime = input.StartTime
-		existing.EndTime = input.EndTime
-		existing.Notes = input.Notes
+	}
+	existing := editBlock(blocks, input.ID, input.StartTime, input.EndTime, input.Notes)
+	if existing == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Childcare schedule not found"})
+		return
+	}
 
-		if err := h.db.Save(&existing).Error; err != nil {
+	if err := h.db.Omit("Children").Save(existing).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if input.MemberIDs != nil {
+		if err := h.db.Model(existing).Association("Children").Replace(children); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+	}
 
-		c.JSON(http.StatusOK, existing)
+	h.db.Preload("Children").First(existing, existing.ID)
+	c.JSON(http.StatusOK, existing)
+}
+
+// editBlock applies an edit to the block with the given id among today's blocks and returns it,
+// or nil when today has no such block. A block expanded from a pattern is marked as edited so
+// that expanding the pattern again keeps it.
+func editBlock(blocks []models.ChildcareSchedule, id uint, startTime, endTime, notes string) *models.ChildcareSchedule {
+	for i := range blocks {
+		if blocks[i].ID != id {
+			continue
+		}
+		block := &blocks[i]
+		block.StartTime = startTime
+		block.EndTime = endTime
+		block.Notes = notes
+		block.Edited = block.PatternID != nil
+		return block
 	}
+	return nil
 }
 
-// DeleteTodayChildcare deletes childcare schedule for today
+// DeleteTodayChildcare deletes today's childcare blocks, or only the block given by ?id=
 func (h *HelperHandler) DeleteTodayChildcare(c *gin.Context) {
-	today := time.Now()
-	todayStart := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
-	nextDay := todayStart.AddDate(0, 0, 1)
+	today := h.today(c)
 
-	// Find and delete childcare schedule for today
-	if err := h.orgDB(c).Where("date >= ? AND date < ?", todayStart, nextDay).
-		Delete(&models.ChildcareSchedule{}).Error; err != nil {
+	query := h.orgDB(c).Where("date = ?", today)
+	if id := c.Query("id"); id != "" {
+		query = query.Where("id = ?", id)
+	}
+	var blocks []models.ChildcareSchedule
+	if err := query.Find(&blocks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		for _, block := range blocks {
+			if err := deleteChildcareBlock(tx, block); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"testing"
+
+	"podlevskikh/awesomeProject/internal/models"
+)
+
+// Editing today's childcare changes the block the helper opened and never adds another one
+func TestEditBlock(t *testing.T) {
+	patternID := uint(7)
+	today := func() []models.ChildcareSchedule {
+		return []models.ChildcareSchedule{
+			{ID: 1, StartTime: "09:00", EndTime: "12:00"},
+			{ID: 2, StartTime: "15:00", EndTime: "18:00", PatternID: &patternID},
+		}
+	}
+
+	blocks := today()
+	got := editBlock(blocks, 1, "09:30", "12:30", "park")
+	if got != &blocks[0] || got.StartTime != "09:30" || got.EndTime != "12:30" || got.Notes != "park" || got.Edited {
+		t.Errorf("edit of a manual block: %+v", got)
+	}
+	if blocks[1].StartTime != "15:00" {
+		t.Errorf("other block changed: %+v", blocks[1])
+	}
+
+	blocks = today()
+	if got := editBlock(blocks, 2, "16:00", "18:00", ""); got == nil || !got.Edited {
+		t.Errorf("edit of a pattern block: %+v, want it marked as edited", got)
+	}
+
+	if got := editBlock(today(), 3, "10:00", "11:00", ""); got != nil {
+		t.Errorf("edit of a block not scheduled today: %+v, want nil", got)
+	}
+}
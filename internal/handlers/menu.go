@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"podlevskikh/awesomeProject/internal/menu"
+	"podlevskikh/awesomeProject/internal/middleware"
+	"podlevskikh/awesomeProject/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// MenuHandler — планировщик меню на неделю.
+type MenuHandler struct {
+	db      *gorm.DB
+	planner *menu.Planner
+}
+
+func NewMenuHandler(db *gorm.DB) *MenuHandler {
+	return &MenuHandler{db: db, planner: menu.NewPlanner(db)}
+}
+
+// GetWeek возвращает сетку приёмов пищи на 7 дней.
+// GET /admin/api/menu/week?start=YYYY-MM-DD  (по умолчанию — сегодня в зоне организации)
+func (h *MenuHandler) GetWeek(c *gin.Context) {
+	start := middleware.MustOrganization(c).Today()
+	if s := c.Query("start"); s != "" {
+		parsed, err := models.ParseDate(s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		start = parsed
+	}
+
+	week, err := h.planner.Week(middleware.MustMembership(c).OrganizationID, start)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, week)
+}
+
+// BulkEdit применяет операции move/swap/set/lock/unlock в одной транзакции
+// и возвращает обновлённую сетку недели.
+// POST /admin/api/menu/bulk  {"start": "YYYY-MM-DD", "operations": [...]}
+func (h *MenuHandler) BulkEdit(c *gin.Context) {
+	var input struct {
+		Start      models.Date      `json:"start"`
+		Operations []menu.Operation `json:"operations" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	orgID := middleware.MustMembership(c).OrganizationID
+	if err := h.planner.Apply(orgID, input.Operations); err != nil {
+		var opErr *menu.OpError
+		if errors.As(err, &opErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": opErr.Error(), "operation": opErr.Index})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	start := input.Start
+	if start.IsZero() {
+		start = middleware.MustOrganization(c).Today()
+	}
+	week, err := h.planner.Week(orgID, start)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, week)
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"podlevskikh/awesomeProject/internal/middleware"
+	"podlevskikh/awesomeProject/internal/models"
+	"podlevskikh/awesomeProject/internal/nutrition"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// NutritionHandler — таблица пищевой ценности продуктов и сводка по меню.
+type NutritionHandler struct {
+	db *gorm.DB
+}
+
+func NewNutritionHandler(db *gorm.DB) *NutritionHandler {
+	return &NutritionHandler{db: db}
+}
+
+// fillNutrition заполняет оценку пищевой ценности рецептов. Ошибка таблицы не мешает
+// отдать рецепты — оценка тогда просто отсутствует.
+func fillNutrition(db *gorm.DB, orgID uint, recipes ...*models.Recipe) {
+	table, err := nutrition.Load(db, orgID)
+	if err != nil {
+		log.Printf("Warning: failed to load nutrition table: %v", err)
+		return
+	}
+	table.Fill(recipes...)
+}
+
+// ListFacts возвращает таблицу продуктов организации: поставляемую с правками поверх.
+// GET /admin/api/nutrition/facts
+func (h *NutritionHandler) ListFacts(c *gin.Context) {
+	table, err := nutrition.Load(h.db, middleware.MustMembership(c).OrganizationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, table.Facts())
+}
+
+// SaveFact добавляет продукт или меняет значения существующего (по названию).
+// Правка поставляемого продукта хранится у организации и перекрывает его.
+// PUT /admin/api/nutrition/facts
+func (h *NutritionHandler) SaveFact(c *gin.Context) {
+	var input models.NutritionFact
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	n := input.Nutrition
+	if n.Kcal < 0 || n.Protein < 0 || n.Fat < 0 || n.Carbs < 0 || n.Fiber < 0 || input.PieceGrams < 0 || input.Density < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "values must not be negative"})
+		return
+	}
+	if n.Protein+n.Fat+n.Carbs > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "protein, fat and carbs per 100 g must not exceed 100 g"})
+		return
+	}
+
+	orgID := middleware.MustMembership(c).OrganizationID
+	fact := models.NutritionFact{OrganizationID: orgID, NormalizedName: nutrition.Key(input.Name)}
+	status := http.StatusOK
+	err := h.db.Where("organization_id = ? AND normalized_name = ?", orgID, fact.NormalizedName).First(&fact).Error
+	if err == gorm.ErrRecordNotFound {
+		status = http.StatusCreated
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	fact.Name = input.Name
+	fact.Aliases = strings.Join(nutrition.List(input.Aliases), ", ")
+	fact.Nutrition = input.Nutrition
+	fact.PieceGrams = input.PieceGrams
+	fact.Density = input.Density
+	if err := h.db.Save(&fact).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(status, nutrition.FromModel(fact))
+}
+
+// DeleteFact удаляет правку организации: для поставляемого продукта снова действуют
+// исходные значения.
+// DELETE /admin/api/nutrition/facts/:id
+func (h *NutritionHandler) DeleteFact(c *gin.Context) {
+	orgID := middleware.MustMembership(c).OrganizationID
+	res := h.db.Where("organization_id = ?", orgID).Delete(&models.NutritionFact{}, c.Param("id"))
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": res.Error.Error()})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Nutrition fact not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Nutrition fact deleted"})
+}
+
+// Summary возвращает пищевую ценность меню по членам семьи: по дням, итог и среднее за день.
+// Query: from (по умолчанию сегодня), days (по умолчанию 7, не больше 31).
+// GET /admin/api/nutrition/summary
+func (h *NutritionHandler) Summary(c *gin.Context) {
+	from := middleware.MustOrganization(c).Today()
+	if s := c.Query("from"); s != "" {
+		parsed, err := models.ParseDate(s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		from = parsed
+	}
+	days := 7
+	if s := c.Query("days"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > 31 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 31"})
+			return
+		}
+		days = n
+	}
+
+	summary, err := nutrition.Summarize(h.db, middleware.MustMembership(c).OrganizationID, from, days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
@@ -1,11 +1,14 @@
 package handlers
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
+	"time"
 
 	"podlevskikh/awesomeProject/internal/middleware"
 	"podlevskikh/awesomeProject/internal/models"
+	"podlevskikh/awesomeProject/internal/settings"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -13,11 +16,12 @@ import (
 
 // OrgHandler — эндпоинты уровня организации (участники, настройки и т.п.).
 type OrgHandler struct {
-	db *gorm.DB
+	db       *gorm.DB
+	settings *settings.Service
 }
 
 func NewOrgHandler(db *gorm.DB) *OrgHandler {
-	return &OrgHandler{db: db}
+	return &OrgHandler{db: db, settings: settings.NewService(db)}
 }
 
 // MemberView — то, что отдаём наружу (без лишних полей).
@@ -90,6 +94,43 @@ func (h *OrgHandler) GetMembers(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+// GetOrganization возвращает текущую организацию.
+// GET /orgs/:orgId
+func (h *OrgHandler) GetOrganization(c *gin.Context) {
+	c.JSON(http.StatusOK, middleware.MustOrganization(c))
+}
+
+// UpdateOrganization обновляет название и часовой пояс организации.
+// PUT /orgs/:orgId  (CapManageSettings)
+func (h *OrgHandler) UpdateOrganization(c *gin.Context) {
+	org := middleware.MustOrganization(c)
+
+	var input struct {
+		Name     string `json:"name"`
+		Timezone string `json:"timezone"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Name != "" {
+		org.Name = input.Name
+	}
+	if input.Timezone != "" {
+		if _, err := time.LoadLocation(input.Timezone); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown timezone, use an IANA name like Asia/Nicosia"})
+			return
+		}
+		org.Timezone = input.Timezone
+	}
+
+	if err := h.db.Save(org).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, org)
+}
+
 // ── TaskCategory CRUD ────────────────────────────────────────────────────────
 
 // GetTaskCategories возвращает все категории задач организации.
@@ -204,3 +245,98 @@ func (h *OrgHandler) DeleteTaskCategory(c *gin.Context) {
 	}
 	c.JSON(http.StatusNoContent, nil)
 }
+
+// GetJobRuns возвращает историю фоновых задач организации (последние 100 запусков).
+// GET /orgs/:orgId/job-runs  (CapManageSettings)
+func (h *OrgHandler) GetJobRuns(c *gin.Context) {
+	m := middleware.MustMembership(c)
+	var runs []models.JobRun
+	q := h.db.Where("organization_id = ?", m.OrganizationID)
+	if job := c.Query("job"); job != "" {
+		q = q.Where("job_name = ?", job)
+	}
+	if err := q.Order("started_at DESC").Limit(100).Find(&runs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, runs)
+}
+
+// ── Settings CRUD ────────────────────────────────────────────────────────────
+
+// GetSettings возвращает все настройки организации (с умолчаниями).
+// GET /orgs/:orgId/settings  (CapManageSettings)
+func (h *OrgHandler) GetSettings(c *gin.Context) {
+	m := middleware.MustMembership(c)
+	values, err := h.settings.List(m.OrganizationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, values)
+}
+
+// GetSetting возвращает одну настройку.
+// GET /orgs/:orgId/settings/:key  (CapManageSettings)
+func (h *OrgHandler) GetSetting(c *gin.Context) {
+	m := middleware.MustMembership(c)
+	value, err := h.settings.Get(m.OrganizationID, c.Param("key"))
+	if err != nil {
+		settingsError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, value)
+}
+
+// UpdateSetting задаёт значение настройки для организации.
+// PUT /orgs/:orgId/settings/:key  (CapManageSettings)
+// Body: {value} — строка или JSON-значение (число, bool, объект).
+func (h *OrgHandler) UpdateSetting(c *gin.Context) {
+	m := middleware.MustMembership(c)
+	var input struct {
+		Value json.RawMessage `json:"value" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Строки приходят в кавычках — снимаем их; остальное храним как JSON-текст
+	raw := string(input.Value)
+	var str string
+	if err := json.Unmarshal(input.Value, &str); err == nil {
+		raw = str
+	}
+
+	value, err := h.settings.Set(m.OrganizationID, c.Param("key"), raw)
+	if err != nil {
+		settingsError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, value)
+}
+
+// DeleteSetting сбрасывает настройку к значению по умолчанию.
+// DELETE /orgs/:orgId/settings/:key  (CapManageSettings)
+func (h *OrgHandler) DeleteSetting(c *gin.Context) {
+	m := middleware.MustMembership(c)
+	value, err := h.settings.Reset(m.OrganizationID, c.Param("key"))
+	if err != nil {
+		settingsError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, value)
+}
+
+// settingsError переводит ошибки сервиса настроек в HTTP-статусы.
+func settingsError(c *gin.Context, err error) {
+	var verr *settings.ValidationError
+	switch {
+	case errors.Is(err, settings.ErrUnknownKey):
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown setting"})
+	case errors.As(err, &verr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": verr.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
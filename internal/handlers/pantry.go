@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"podlevskikh/awesomeProject/internal/ingredients"
+	"podlevskikh/awesomeProject/internal/middleware"
+	"podlevskikh/awesomeProject/internal/models"
+	"podlevskikh/awesomeProject/internal/pantry"
+	"podlevskikh/awesomeProject/internal/units"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// PantryHandler — домашние запасы продуктов.
+type PantryHandler struct {
+	db *gorm.DB
+}
+
+func NewPantryHandler(db *gorm.DB) *PantryHandler {
+	return &PantryHandler{db: db}
+}
+
+// pantryInput — тело POST/PUT: количество можно передать числом с единицей
+// или строкой ("1,5 кг").
+type pantryInput struct {
+	Name      string       `json:"name" binding:"required"`
+	Quantity  *float64     `json:"quantity"`
+	Unit      string       `json:"unit"`
+	Amount    string       `json:"amount"` // альтернатива quantity/unit: "500 g"
+	Location  string       `json:"location"`
+	ExpiresOn *models.Date `json:"expires_on"`
+}
+
+// quantity возвращает количество из тела запроса; nil — не указано.
+func (in pantryInput) quantity() (*units.Quantity, bool) {
+	if in.Quantity != nil {
+		if *in.Quantity < 0 {
+			return nil, false
+		}
+		unit, _ := units.Normalize(in.Unit)
+		return &units.Quantity{Amount: *in.Quantity, Unit: unit}, true
+	}
+	if in.Amount != "" {
+		q, ok := units.Parse(in.Amount)
+		return &q, ok
+	}
+	return nil, true
+}
+
+// ingredientID находит продукт справочника организации по названию (без создания).
+func (h *PantryHandler) ingredientID(orgID uint, name string) *uint {
+	var ingredient models.Ingredient
+	err := h.db.Where("organization_id = ? AND normalized_name = ?", orgID, ingredients.NormalizeName(name)).
+		First(&ingredient).Error
+	if err != nil {
+		return nil
+	}
+	return &ingredient.ID
+}
+
+// List возвращает запасы организации: сначала с ближайшим сроком годности.
+// GET /helper/api/pantry
+func (h *PantryHandler) List(c *gin.Context) {
+	orgID := middleware.MustMembership(c).OrganizationID
+	var items []models.PantryItem
+	err := h.db.Where("organization_id = ?", orgID).
+		Order("expires_on IS NULL, expires_on, name").
+		Find(&items).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+// Add кладёт продукт в запас (добавляется к такой же строке, если она есть).
+// POST /helper/api/pantry
+func (h *PantryHandler) Add(c *gin.Context) {
+	var input pantryInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	q, ok := input.quantity()
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quantity"})
+		return
+	}
+
+	orgID := middleware.MustMembership(c).OrganizationID
+	product := pantry.Product{IngredientID: h.ingredientID(orgID, input.Name), Name: input.Name}
+	item, err := pantry.Add(h.db, orgID, product, q, strings.TrimSpace(input.Location), input.ExpiresOn)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, item)
+}
+
+// Update задаёт строке запаса количество, место и срок годности.
+// PUT /helper/api/pantry/:id
+func (h *PantryHandler) Update(c *gin.Context) {
+	orgID := middleware.MustMembership(c).OrganizationID
+	var item models.PantryItem
+	if err := h.db.Where("organization_id = ?", orgID).First(&item, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pantry item not found"})
+		return
+	}
+
+	var input pantryInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	q, ok := input.quantity()
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quantity"})
+		return
+	}
+
+	if ingredients.NormalizeName(input.Name) != ingredients.NormalizeName(item.Name) {
+		item.IngredientID = h.ingredientID(orgID, input.Name)
+	}
+	item.Name = strings.TrimSpace(input.Name)
+	item.Quantity, item.Unit = nil, ""
+	if q != nil {
+		item.Quantity, item.Unit = &q.Amount, q.Unit
+	}
+	item.Location = strings.TrimSpace(input.Location)
+	item.ExpiresOn = input.ExpiresOn
+
+	if err := h.db.Save(&item).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+// Delete убирает строку запаса (продукт закончился или выброшен).
+// DELETE /helper/api/pantry/:id
+func (h *PantryHandler) Delete(c *gin.Context) {
+	orgID := middleware.MustMembership(c).OrganizationID
+	res := h.db.Where("organization_id = ?", orgID).Delete(&models.PantryItem{}, c.Param("id"))
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": res.Error.Error()})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pantry item not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Pantry item deleted"})
+}
@@ -0,0 +1,483 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"podlevskikh/awesomeProject/internal/data"
+	"podlevskikh/awesomeProject/internal/middleware"
+	"podlevskikh/awesomeProject/internal/models"
+	"podlevskikh/awesomeProject/internal/recurrence"
+	"podlevskikh/awesomeProject/internal/scheduler"
+	"podlevskikh/awesomeProject/internal/timeline"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RecurringHandler — повторяющиеся задачи: серии с правилом RRULE и правки отдельных повторений.
+type RecurringHandler struct {
+	db        *gorm.DB
+	scheduler *scheduler.Scheduler
+}
+
+func NewRecurringHandler(db *gorm.DB) *RecurringHandler {
+	return &RecurringHandler{db: db, scheduler: scheduler.NewScheduler(db)}
+}
+
+// nextOccurrences — сколько ближайших повторений показывать в списке серий.
+const nextOccurrences = 5
+
+// recurringView — серия с ближайшими повторениями.
+type recurringView struct {
+	models.RecurringTask
+	Next []models.Date `json:"next"`
+}
+
+// List возвращает серии организации с ближайшими повторениями (в пределах года, без
+// воскресений и праздников).
+// GET /admin/api/recurring-tasks
+func (h *RecurringHandler) List(c *gin.Context) {
+	orgID := middleware.MustMembership(c).OrganizationID
+	today := middleware.MustOrganization(c).Today()
+	var series []models.RecurringTask
+	if err := h.db.Where("organization_id = ?", orgID).Order("id").Find(&series).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	views := make([]recurringView, len(series))
+	for i, rt := range series {
+		views[i] = recurringView{RecurringTask: rt, Next: []models.Date{}}
+		dates, err := recurrence.Occurrences(rt, today, today.AddDays(366))
+		if err != nil || !rt.Active {
+			continue
+		}
+		// Как и генератор, воскресенья и праздники пропускаем
+		for _, d := range dates {
+			if len(views[i].Next) == nextOccurrences {
+				break
+			}
+			if data.HolidayReason(h.db, d) == "" {
+				views[i].Next = append(views[i].Next, d)
+			}
+		}
+	}
+	c.JSON(http.StatusOK, views)
+}
+
+// Create создаёт серию и добавляет её повторения в уже сгенерированные расписания.
+// POST /admin/api/recurring-tasks
+func (h *RecurringHandler) Create(c *gin.Context) {
+	orgID := middleware.MustMembership(c).OrganizationID
+	var input models.RecurringTask
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rt := models.RecurringTask{OrganizationID: orgID, Active: true}
+	if !h.apply(c, &rt, input) {
+		return
+	}
+	if err := h.db.Create(&rt).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.refresh(c, orgID) {
+		return
+	}
+	c.JSON(http.StatusCreated, rt)
+}
+
+// Update меняет всю серию. Повторения от сегодняшнего дня пересоздаются по новому правилу;
+// выполненные и отредактированные по отдельности повторения остаются как есть.
+// PUT /admin/api/recurring-tasks/:id
+func (h *RecurringHandler) Update(c *gin.Context) {
+	orgID := middleware.MustMembership(c).OrganizationID
+	rt, ok := h.find(c)
+	if !ok {
+		return
+	}
+	var input struct {
+		models.RecurringTask
+		Active *bool `json:"active"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Active != nil {
+		rt.Active = *input.Active
+	}
+	if !h.apply(c, &rt, input.RecurringTask) {
+		return
+	}
+	if err := h.db.Save(&rt).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.refresh(c, orgID) {
+		return
+	}
+	c.JSON(http.StatusOK, rt)
+}
+
+// Delete удаляет серию и её невыполненные повторения начиная с сегодняшнего дня.
+// Прошедшие и выполненные задачи остаются в расписании без привязки к серии.
+// DELETE /admin/api/recurring-tasks/:id
+func (h *RecurringHandler) Delete(c *gin.Context) {
+	orgID := middleware.MustMembership(c).OrganizationID
+	rt, ok := h.find(c)
+	if !ok {
+		return
+	}
+	today := middleware.MustOrganization(c).Today()
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("recurring_task_id = ? AND completed = ?", rt.ID, false).
+			Where("schedule_id IN (SELECT id FROM daily_schedules WHERE organization_id = ? AND date >= ?)", orgID, today).
+			Delete(&models.ScheduleTask{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.ScheduleTask{}).Where("recurring_task_id = ?", rt.ID).
+			Update("recurring_task_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&rt).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Recurring task deleted"})
+}
+
+// occurrence — повторение серии и задача расписания, если она уже создана. Date — дата серии,
+// по ней повторение адресуется в API; перенесённое повторение стоит в расписании на MovedTo.
+type occurrence struct {
+	Date    models.Date          `json:"date"`
+	MovedTo *models.Date         `json:"moved_to,omitempty"`
+	Task    *models.ScheduleTask `json:"task,omitempty"`
+	Skipped string               `json:"skipped,omitempty"` // выходной ("Sunday" или праздник): в расписание не попадает
+}
+
+// scheduledOccurrence — задача повторения и дата расписания, в котором она стоит.
+type scheduledOccurrence struct {
+	task *models.ScheduleTask
+	date models.Date
+}
+
+// movedTo — дата расписания задачи, если повторение перенесено с date; иначе nil.
+func movedTo(date, scheduled models.Date) *models.Date {
+	if scheduled.Equal(date) {
+		return nil
+	}
+	return &scheduled
+}
+
+// Occurrences возвращает повторения серии за период вместе с их задачами в расписании,
+// включая перенесённые на другие даты. Повторения на воскресенья и праздники помечены skipped.
+// GET /admin/api/recurring-tasks/:id/occurrences?from=YYYY-MM-DD&days=30
+func (h *RecurringHandler) Occurrences(c *gin.Context) {
+	rt, ok := h.find(c)
+	if !ok {
+		return
+	}
+	from := middleware.MustOrganization(c).Today()
+	if s := c.Query("from"); s != "" {
+		parsed, err := models.ParseDate(s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		from = parsed
+	}
+	days := 30
+	if s := c.Query("days"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > 366 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 366"})
+			return
+		}
+		days = n
+	}
+	to := from.AddDays(days)
+
+	dates, err := recurrence.Occurrences(rt, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var schedules []models.DailySchedule
+	if err := h.db.Preload("Tasks", "recurring_task_id = ?", rt.ID).
+		Where("organization_id = ? AND date >= ? AND date < ?", rt.OrganizationID, from, to).
+		Find(&schedules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	// Задачи по дате серии: перенесённая задача остаётся повторением своей исходной даты
+	tasks := make(map[string]scheduledOccurrence)
+	var order []string
+	for _, sch := range schedules {
+		for i := range sch.Tasks {
+			key := recurrence.OccurrenceOf(sch.Tasks[i], sch.Date).String()
+			tasks[key] = scheduledOccurrence{task: &sch.Tasks[i], date: sch.Date}
+			order = append(order, key)
+		}
+	}
+
+	result := []occurrence{}
+	for _, d := range dates {
+		o := occurrence{Date: d, Skipped: data.HolidayReason(h.db, d)}
+		if s, ok := tasks[d.String()]; ok {
+			o.Task, o.MovedTo = s.task, movedTo(d, s.date)
+			delete(tasks, d.String())
+		}
+		result = append(result, o)
+	}
+	// Перенесённые повторения: их исходные даты исключены из серии
+	for _, key := range order {
+		if s, ok := tasks[key]; ok {
+			date := recurrence.OccurrenceOf(*s.task, s.date)
+			result = append(result, occurrence{Date: date, MovedTo: movedTo(date, s.date), Task: s.task})
+			delete(tasks, key)
+		}
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// UpdateOccurrence меняет одно повторение серии: время, длительность, описание, исполнителя
+// или дату. :date — дата серии, и у перенесённого повторения тоже. При переносе исходная дата
+// исключается из серии (EXDATE), а задача помнит её в OccurrenceDate, так что повторение дня,
+// на который её перенесли, остаётся. Отредактированное повторение не пересоздаётся при
+// генерации и правке серии.
+// PUT /admin/api/recurring-tasks/:id/occurrences/:date
+func (h *RecurringHandler) UpdateOccurrence(c *gin.Context) {
+	rt, ok := h.find(c)
+	if !ok {
+		return
+	}
+	date, task, ok := h.occurrence(c, rt)
+	if !ok {
+		return
+	}
+	var input struct {
+		Date             string  `json:"date"` // новая дата при переносе
+		Time             *string `json:"time"`
+		Duration         *int    `json:"duration"`
+		Title            *string `json:"title"`
+		Description      *string `json:"description"`
+		AssignedToUserID *uint   `json:"assigned_to_user_id"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Повторение, которого ещё нет в расписании, создаётся из серии
+	if task == nil {
+		task = &models.ScheduleTask{
+			OrganizationID:   rt.OrganizationID,
+			TaskType:         "recurring",
+			Time:             rt.Time,
+			Duration:         rt.Duration,
+			Title:            rt.Title,
+			Description:      rt.Description,
+			TaskCategoryID:   rt.TaskCategoryID,
+			AssignedToUserID: rt.AssignedToUserID,
+			RecurringTaskID:  &rt.ID,
+		}
+	}
+	if task.OccurrenceDate == nil {
+		task.OccurrenceDate = &date
+	}
+	edit := models.RecurringTask{Title: task.Title, RRule: rt.RRule, StartDate: rt.StartDate, Time: task.Time, Duration: task.Duration}
+	if input.Time != nil {
+		edit.Time = strings.TrimSpace(*input.Time)
+	}
+	if input.Duration != nil {
+		edit.Duration = *input.Duration
+	}
+	if input.Title != nil {
+		edit.Title = strings.TrimSpace(*input.Title)
+	}
+	if err := recurrence.Validate(edit); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	target := date
+	if input.Date != "" {
+		parsed, err := models.ParseDate(input.Date)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date format, use YYYY-MM-DD"})
+			return
+		}
+		target = parsed
+	}
+
+	task.Time, task.Duration, task.Title = edit.Time, edit.Duration, edit.Title
+	task.EndTime = endTime(task.Time, task.Duration)
+	if input.Description != nil {
+		task.Description = *input.Description
+	}
+	if input.AssignedToUserID != nil {
+		task.AssignedToUserID = input.AssignedToUserID
+	}
+	task.Edited = true
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if !target.Equal(date) {
+			if err := excludeDate(tx, &rt, date); err != nil {
+				return err
+			}
+		}
+		schedule, err := scheduleFor(tx, rt.OrganizationID, target)
+		if err != nil {
+			return err
+		}
+		task.ScheduleID = schedule.ID
+		return tx.Save(task).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, occurrence{Date: date, MovedTo: movedTo(date, target), Task: task})
+}
+
+// DeleteOccurrence пропускает одно повторение: дата исключается из серии (EXDATE),
+// невыполненная задача этого дня удаляется из расписания.
+// DELETE /admin/api/recurring-tasks/:id/occurrences/:date
+func (h *RecurringHandler) DeleteOccurrence(c *gin.Context) {
+	rt, ok := h.find(c)
+	if !ok {
+		return
+	}
+	date, task, ok := h.occurrence(c, rt)
+	if !ok {
+		return
+	}
+	if task != nil && task.Completed {
+		c.JSON(http.StatusConflict, gin.H{"error": "occurrence is already completed"})
+		return
+	}
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := excludeDate(tx, &rt, date); err != nil {
+			return err
+		}
+		if task == nil {
+			return nil
+		}
+		return tx.Delete(task).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Occurrence skipped", "exdates": rt.ExDates})
+}
+
+// find загружает серию организации по :id.
+func (h *RecurringHandler) find(c *gin.Context) (models.RecurringTask, bool) {
+	var rt models.RecurringTask
+	err := h.db.Where("organization_id = ?", middleware.MustMembership(c).OrganizationID).First(&rt, c.Param("id")).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recurring task not found"})
+		return rt, false
+	}
+	return rt, true
+}
+
+// occurrence разбирает :date и находит задачу повторения серии на эту дату, где бы она ни стояла
+// после переноса. Дата должна быть повторением серии или уже иметь её задачу (перенесённое
+// повторение, чья дата исключена из серии).
+func (h *RecurringHandler) occurrence(c *gin.Context, rt models.RecurringTask) (models.Date, *models.ScheduleTask, bool) {
+	date, err := models.ParseDate(c.Param("date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date format, use YYYY-MM-DD"})
+		return date, nil, false
+	}
+	var task models.ScheduleTask
+	err = h.db.Where("recurring_task_id = ?", rt.ID).
+		Where("occurrence_date = ? OR (occurrence_date IS NULL AND schedule_id IN (SELECT id FROM daily_schedules WHERE organization_id = ? AND date = ?))",
+			date, rt.OrganizationID, date).
+		First(&task).Error
+	if err == nil {
+		return date, &task, true
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return date, nil, false
+	}
+	dates, err := recurrence.Occurrences(rt, date, date.AddDays(1))
+	if err != nil || len(dates) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No occurrence on " + date.String()})
+		return date, nil, false
+	}
+	return date, nil, true
+}
+
+// apply переносит поля серии из запроса в rt и проверяет её; при ошибке отвечает 400.
+func (h *RecurringHandler) apply(c *gin.Context, rt *models.RecurringTask, input models.RecurringTask) bool {
+	rt.Title = strings.TrimSpace(input.Title)
+	rt.Description = input.Description
+	rt.RRule = strings.TrimPrefix(strings.TrimSpace(input.RRule), "RRULE:")
+	rt.StartDate = input.StartDate
+	rt.Time = strings.TrimSpace(input.Time)
+	rt.Duration = input.Duration
+	if rt.Duration == 0 {
+		rt.Duration = 30
+	}
+	rt.TaskCategoryID = input.TaskCategoryID
+	rt.AssignedToUserID = input.AssignedToUserID
+	exdates, err := recurrence.ParseDates(input.ExDates)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "exdates: " + err.Error()})
+		return false
+	}
+	rt.ExDates = recurrence.FormatDates(exdates)
+	if err := recurrence.Validate(*rt); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// refresh пересоздаёт повторения серий в расписаниях начиная с сегодняшнего дня.
+func (h *RecurringHandler) refresh(c *gin.Context, orgID uint) bool {
+	if _, err := h.scheduler.RefreshRecurring(orgID, middleware.MustOrganization(c).Today()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "saved, but failed to update schedules: " + err.Error()})
+		return false
+	}
+	return true
+}
+
+// excludeDate добавляет дату в исключения серии.
+func excludeDate(tx *gorm.DB, rt *models.RecurringTask, date models.Date) error {
+	exdates, err := recurrence.ParseDates(rt.ExDates)
+	if err != nil {
+		return err
+	}
+	rt.ExDates = recurrence.FormatDates(append(exdates, date))
+	return tx.Model(rt).Update("ex_dates", rt.ExDates).Error
+}
+
+// scheduleFor находит расписание дня или создаёт пустое (ещё не сгенерированное).
+func scheduleFor(tx *gorm.DB, orgID uint, date models.Date) (models.DailySchedule, error) {
+	var schedule models.DailySchedule
+	err := tx.Where("organization_id = ? AND date = ?", orgID, date).First(&schedule).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		schedule = models.DailySchedule{OrganizationID: orgID, Date: date}
+		err = tx.Create(&schedule).Error
+	}
+	return schedule, err
+}
+
+// endTime — конец задачи, начинающейся в start и длящейся duration минут; пусто для гибкой задачи.
+func endTime(start string, duration int) string {
+	t, err := timeline.ParseClock(start)
+	if start == "" || err != nil {
+		return ""
+	}
+	return timeline.Clock(min(t+duration, timeline.Day.End))
+}
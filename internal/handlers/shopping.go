@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"podlevskikh/awesomeProject/internal/middleware"
+	"podlevskikh/awesomeProject/internal/models"
+	"podlevskikh/awesomeProject/internal/settings"
+	"podlevskikh/awesomeProject/internal/shopping"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ShoppingHandler — генерация списка покупок из расписания приёмов пищи.
+type ShoppingHandler struct {
+	db        *gorm.DB
+	generator *shopping.Generator
+	settings  *settings.Service
+}
+
+func NewShoppingHandler(db *gorm.DB) *ShoppingHandler {
+	return &ShoppingHandler{db: db, generator: shopping.NewGenerator(db), settings: settings.NewService(db)}
+}
+
+// Generate добавляет в список покупок продукты для незавершённых приёмов пищи ближайших дней.
+// POST /helper/api/shopping/generate?from=YYYY-MM-DD&days=N
+// (по умолчанию — с сегодняшнего дня на shopping_list_days_ahead дней)
+func (h *ShoppingHandler) Generate(c *gin.Context) {
+	orgID := middleware.MustMembership(c).OrganizationID
+
+	from := middleware.MustOrganization(c).Today()
+	if s := c.Query("from"); s != "" {
+		parsed, err := models.ParseDate(s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		from = parsed
+	}
+
+	days := h.settings.Int(orgID, settings.KeyShoppingDaysAhead)
+	if s := c.Query("days"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > 60 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 60"})
+			return
+		}
+		days = n
+	}
+
+	result, err := h.generator.Generate(orgID, from, days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
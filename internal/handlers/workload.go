@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"podlevskikh/awesomeProject/internal/middleware"
+	"podlevskikh/awesomeProject/internal/models"
+	"podlevskikh/awesomeProject/internal/workload"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// WorkloadHandler — рабочие часы помощников и загрузка дней расписания.
+type WorkloadHandler struct {
+	db *gorm.DB
+}
+
+func NewWorkloadHandler(db *gorm.DB) *WorkloadHandler {
+	return &WorkloadHandler{db: db}
+}
+
+// workProfileView — профиль рабочих часов с именем помощника и минутами в день.
+type workProfileView struct {
+	models.WorkProfile
+	UserName string `json:"user_name"`
+	Minutes  int    `json:"minutes"`
+}
+
+// ListProfiles возвращает профили рабочих часов и суммарное рабочее время за день.
+// GET /admin/api/work-profiles
+func (h *WorkloadHandler) ListProfiles(c *gin.Context) {
+	orgID := middleware.MustMembership(c).OrganizationID
+	var profiles []models.WorkProfile
+	if err := h.db.Where("organization_id = ?", orgID).Order("user_id").Find(&profiles).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	userIDs := make([]uint, len(profiles))
+	for i, p := range profiles {
+		userIDs[i] = p.UserID
+	}
+	var users []models.User
+	if len(userIDs) > 0 {
+		if err := h.db.Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	names := make(map[uint]string, len(users))
+	for _, u := range users {
+		names[u.ID] = u.Name
+	}
+
+	views := make([]workProfileView, len(profiles))
+	for i, p := range profiles {
+		views[i] = workProfileView{WorkProfile: p, UserName: names[p.UserID], Minutes: workload.Minutes(p)}
+	}
+	capacity, err := workload.Load(h.db, orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"profiles": views, "capacity": capacity})
+}
+
+// SaveProfile задаёт рабочие часы участника организации (создаёт или заменяет профиль).
+// PUT /admin/api/work-profiles/:user_id
+func (h *WorkloadHandler) SaveProfile(c *gin.Context) {
+	orgID := middleware.MustMembership(c).OrganizationID
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+	var membership models.Membership
+	if err := h.db.Where("organization_id = ? AND user_id = ? AND status = ?", orgID, userID, models.MembershipActive).
+		First(&membership).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return
+	}
+
+	var input models.WorkProfile
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := workload.Validate(input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var profile models.WorkProfile
+	err = h.db.Where("organization_id = ? AND user_id = ?", orgID, userID).First(&profile).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	profile.OrganizationID = orgID
+	profile.UserID = uint(userID)
+	profile.StartTime = strings.TrimSpace(input.StartTime)
+	profile.EndTime = strings.TrimSpace(input.EndTime)
+	profile.Breaks = strings.TrimSpace(input.Breaks)
+	if err := h.db.Save(&profile).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, workProfileView{WorkProfile: profile, Minutes: workload.Minutes(profile)})
+}
+
+// DeleteProfile удаляет рабочие часы участника: его время больше не входит в объём дня.
+// DELETE /admin/api/work-profiles/:user_id
+func (h *WorkloadHandler) DeleteProfile(c *gin.Context) {
+	orgID := middleware.MustMembership(c).OrganizationID
+	result := h.db.Where("organization_id = ? AND user_id = ?", orgID, c.Param("user_id")).Delete(&models.WorkProfile{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Work profile not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Work profile deleted"})
+}
+
+// workloadDay — загрузка дня расписания на момент генерации.
+type workloadDay struct {
+	Date            models.Date `json:"date"`
+	CapacityMinutes int         `json:"capacity_minutes"`
+	PlannedMinutes  int         `json:"planned_minutes"`
+	Warnings        []string    `json:"warnings"`
+}
+
+// Days возвращает загрузку дней расписания и предупреждения о нехватке рабочего времени.
+// GET /admin/api/workload?from=YYYY-MM-DD&days=7
+func (h *WorkloadHandler) Days(c *gin.Context) {
+	from := middleware.MustOrganization(c).Today()
+	if s := c.Query("from"); s != "" {
+		parsed, err := models.ParseDate(s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		from = parsed
+	}
+	days := 7
+	if s := c.Query("days"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > 31 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 31"})
+			return
+		}
+		days = n
+	}
+
+	var schedules []models.DailySchedule
+	if err := h.db.Where("organization_id = ? AND date >= ? AND date < ?",
+		middleware.MustMembership(c).OrganizationID, from, from.AddDays(days)).
+		Order("date").Find(&schedules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	result := make([]workloadDay, len(schedules))
+	for i, sch := range schedules {
+		result[i] = workloadDay{
+			Date:            sch.Date,
+			CapacityMinutes: sch.CapacityMinutes,
+			PlannedMinutes:  sch.PlannedMinutes,
+			Warnings:        []string{},
+		}
+		if sch.Warnings != "" {
+			result[i].Warnings = strings.Split(sch.Warnings, "\n")
+		}
+	}
+	c.JSON(http.StatusOK, result)
+}
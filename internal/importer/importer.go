@@ -0,0 +1,216 @@
+// Package importer — импорт рецептов из HTML (сохранённая страница, вставленный код) по
+// разметке schema.org/Recipe в JSON-LD и из простого текста. Результат — черновик
+// models.Recipe: он не сохраняется, админ проверяет его и создаёт рецепт обычным запросом.
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"podlevskikh/awesomeProject/internal/ingredients"
+	"podlevskikh/awesomeProject/internal/models"
+)
+
+// Форматы, из которых получен черновик.
+const (
+	FormatJSONLD = "json-ld"
+	FormatText   = "text"
+)
+
+// ErrNoRecipe — во входных данных не найден рецепт.
+var ErrNoRecipe = errors.New("no schema.org/Recipe JSON-LD or ingredients section found")
+
+// Draft — рецепт, разобранный из страницы или текста, до сохранения.
+type Draft struct {
+	Recipe   models.Recipe `json:"recipe"`
+	Format   string        `json:"format"`
+	Warnings []string      `json:"warnings,omitempty"`
+}
+
+// Parse разбирает HTML или простой текст. В HTML сначала ищется JSON-LD schema.org/Recipe;
+// если его нет, текст страницы разбирается как простой формат (см. ParseText).
+func Parse(data []byte) (*Draft, error) {
+	text := decode(data)
+	if !looksLikeHTML(text) {
+		return ParseText(text)
+	}
+	draft, err := ParseHTML(text)
+	if !errors.Is(err, ErrNoRecipe) {
+		return draft, err
+	}
+	return ParseText(htmlToText(text))
+}
+
+// finish заполняет общие поля черновика и предупреждения о том, что стоит проверить.
+func finish(d *Draft) (*Draft, error) {
+	r := &d.Recipe
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return nil, fmt.Errorf("%w: recipe has no name", ErrNoRecipe)
+	}
+	r.IsActive = true
+	r.Ingredients = ingredients.Format(r.RecipeIngredients)
+
+	if len(r.RecipeIngredients) == 0 {
+		d.Warnings = append(d.Warnings, "no ingredients found")
+	}
+	review := 0
+	for _, it := range r.RecipeIngredients {
+		if it.NeedsReview {
+			review++
+		}
+	}
+	if review > 0 {
+		d.Warnings = append(d.Warnings, fmt.Sprintf("%d ingredient lines need review", review))
+	}
+	if r.Instructions == "" {
+		d.Warnings = append(d.Warnings, "no instructions found")
+	}
+	if r.Servings == 0 {
+		d.Warnings = append(d.Warnings, "servings not found")
+	}
+	return d, nil
+}
+
+// ingredientLines разбирает строки состава в том виде, в каком они записаны на странице.
+func ingredientLines(raw []string) []models.RecipeIngredient {
+	var items []models.RecipeIngredient
+	for _, s := range raw {
+		s = cleanText(s)
+		if s == "" {
+			continue
+		}
+		l := ingredients.ParseLine(s)
+		items = append(items, models.RecipeIngredient{
+			Position:    len(items),
+			Name:        l.Name,
+			Quantity:    l.Quantity,
+			Unit:        l.Unit,
+			Note:        l.Note,
+			RawText:     l.Raw,
+			NeedsReview: l.NeedsReview,
+		})
+	}
+	return items
+}
+
+// numberedSteps собирает шаги в текст инструкции: "1. ...\n2. ...". Заголовки разделов
+// (строки, оканчивающиеся на ':') идут без номера.
+func numberedSteps(steps []string) string {
+	var b strings.Builder
+	n := 0
+	for _, s := range steps {
+		s = stepNumberRe.ReplaceAllString(cleanText(s), "")
+		if s == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		if strings.HasSuffix(s, ":") {
+			b.WriteString(s)
+			continue
+		}
+		n++
+		b.WriteString(strconv.Itoa(n) + ". " + s)
+	}
+	return b.String()
+}
+
+var (
+	stepNumberRe = regexp.MustCompile(`(?i)^(?:(?:шаг|step)\s*)?\d+\s*[.):]\s*`)
+	tagRe        = regexp.MustCompile(`(?s)<[^>]*>`)
+	spaceRe      = regexp.MustCompile(`[ \t\x{00a0}]+`)
+	blockRe      = regexp.MustCompile(`(?i)<\s*(?:br|/p|/li|/div|/h\d|/tr|p|li|h\d)\b[^>]*>`)
+	scriptRe     = regexp.MustCompile(`(?is)<(script|style|noscript|template)\b[^>]*>.*?</(script|style|noscript|template)\s*>`)
+	commentRe    = regexp.MustCompile(`(?s)<!--.*?-->`)
+)
+
+// fractions — дроби одним символом, которые пишут в количествах.
+var fractions = strings.NewReplacer("½", " 1/2", "¼", " 1/4", "¾", " 3/4", "⅓", " 1/3", "⅔", " 2/3", "⅛", " 1/8")
+
+// cleanText убирает теги и сущности HTML, дроби-символы и лишние пробелы.
+func cleanText(s string) string {
+	s = html.UnescapeString(tagRe.ReplaceAllString(s, " "))
+	s = fractions.Replace(s)
+	return strings.TrimSpace(spaceRe.ReplaceAllString(s, " "))
+}
+
+// htmlToText превращает страницу в текст: блоки — отдельными строками, без скриптов и стилей.
+func htmlToText(page string) string {
+	page = commentRe.ReplaceAllString(scriptRe.ReplaceAllString(page, ""), "")
+	page = blockRe.ReplaceAllString(page, "\n")
+	var lines []string
+	for _, line := range strings.Split(page, "\n") {
+		if line = cleanText(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func looksLikeHTML(s string) bool {
+	head := strings.ToLower(s[:min(len(s), 4096)])
+	for _, marker := range []string{"<!doctype html", "<html", "<head", "<body", "<script", "<div", "<p>"} {
+		if strings.Contains(head, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// decode приводит входные данные к UTF-8. Старые русские сайты отдают windows-1251:
+// страница, которая не является валидным UTF-8, перекодируется из неё.
+func decode(data []byte) string {
+	data = []byte(strings.TrimPrefix(string(data), "\uFEFF"))
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	var b strings.Builder
+	b.Grow(len(data) * 2)
+	for _, c := range data {
+		b.WriteRune(cp1251(c))
+	}
+	return b.String()
+}
+
+func cp1251(c byte) rune {
+	switch {
+	case c < 0x80:
+		return rune(c)
+	case c >= 0xC0:
+		return rune(0x0410 + int(c) - 0xC0) // А-я
+	}
+	switch c {
+	case 0xA8:
+		return 'Ё'
+	case 0xB8:
+		return 'ё'
+	case 0xA0:
+		return ' '
+	case 0x96:
+		return '–'
+	case 0x97:
+		return '—'
+	case 0xAB:
+		return '«'
+	case 0xBB:
+		return '»'
+	case 0x85:
+		return '…'
+	case 0x93, 0x94:
+		return '"'
+	case 0x91, 0x92:
+		return '\''
+	case 0xB9:
+		return '№'
+	case 0xB0:
+		return '°'
+	}
+	return utf8.RuneError
+}
@@ -0,0 +1,134 @@
+package importer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"podlevskikh/awesomeProject/internal/models"
+)
+
+func parseFixture(t *testing.T, name string) *Draft {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	draft, err := Parse(data)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return draft
+}
+
+// lines возвращает строки состава как "имя|количество|единица|заметка".
+func lines(r models.Recipe) []string {
+	var out []string
+	for _, it := range r.RecipeIngredients {
+		q := ""
+		if it.Quantity != nil {
+			q = strconv.FormatFloat(*it.Quantity, 'f', -1, 64)
+		}
+		out = append(out, it.Name+"|"+q+"|"+it.Unit+"|"+it.Note)
+	}
+	return out
+}
+
+func TestParseJSONLDGraph(t *testing.T) {
+	d := parseFixture(t, "graph.html")
+	r := d.Recipe
+	if d.Format != FormatJSONLD || r.Name != "Сырники из творога" || r.Description != "Пышные сырники к завтраку — за 35 минут." {
+		t.Errorf("name/description: %q %q (%s)", r.Name, r.Description, d.Format)
+	}
+	if r.PrepTime != 15 || r.CookTime != 20 || r.Servings != 4 {
+		t.Errorf("times/servings: prep %d cook %d servings %d", r.PrepTime, r.CookTime, r.Servings)
+	}
+	if r.ImageURL != "https://example.ru/img/syrniki-16x9.jpg" || r.Tags != "Завтрак, Русская, творог, сырники" {
+		t.Errorf("image/tags: %q %q", r.ImageURL, r.Tags)
+	}
+	want := []string{
+		"творога|500|г|", "яйца|2||", "муки|3|ст.л.|", "сахара|2|ст.л.|", "щепотка соли|||", "Сметана|||для подачи",
+	}
+	if got := lines(r); !reflect.DeepEqual(got, want) {
+		t.Errorf("ingredients:\n got %q\nwant %q", got, want)
+	}
+	steps := "1. Разомните творог вилкой, добавьте яйца и сахар.\n2. Всыпьте муку и соль, перемешайте.\n" +
+		"3. Сформуйте сырники и обжарьте по 3–4 минуты с каждой стороны."
+	if r.Instructions != steps {
+		t.Errorf("instructions:\n%s", r.Instructions)
+	}
+	if !r.IsActive || r.ID != 0 {
+		t.Errorf("draft must be active and unsaved: %+v", r)
+	}
+}
+
+func TestParseJSONLDSections(t *testing.T) {
+	d := parseFixture(t, "sections.html")
+	r := d.Recipe
+	if r.Name != "Baked Mac & Cheese" || r.Description != "Creamy macaroni with a crunchy top." {
+		t.Errorf("name/description: %q %q", r.Name, r.Description)
+	}
+	// Есть только totalTime — время готовки = total - prep
+	if r.PrepTime != 10 || r.CookTime != 60 || r.Servings != 6 {
+		t.Errorf("times/servings: prep %d cook %d servings %d", r.PrepTime, r.CookTime, r.Servings)
+	}
+	if r.VideoURL != "https://kitchen.example.com/mac.mp4" || r.Tags != "pasta, cheese" {
+		t.Errorf("video/tags: %q %q", r.VideoURL, r.Tags)
+	}
+	want := []string{"macaroni|250|г|", "milk|1.5|стакан|", "butter|2|ст.л.|", "cheddar|200|г|grated", "Salt to taste|||"}
+	if got := lines(r); !reflect.DeepEqual(got, want) {
+		t.Errorf("ingredients:\n got %q\nwant %q", got, want)
+	}
+	steps := "Sauce:\n1. Melt the butter.\n2. Whisk in the milk and cheese.\nBake:\n3. Mix with cooked macaroni and bake for 30 minutes."
+	if r.Instructions != steps {
+		t.Errorf("instructions:\n%s", r.Instructions)
+	}
+}
+
+func TestParseText(t *testing.T) {
+	d := parseFixture(t, "plain.txt")
+	r := d.Recipe
+	if d.Format != FormatText || r.Name != "Борщ" || r.Description != "Классический борщ на говядине." {
+		t.Errorf("name/description: %q %q (%s)", r.Name, r.Description, d.Format)
+	}
+	if r.Servings != 6 || r.PrepTime != 20 || r.CookTime != 90 || r.Tags != "суп, обед" {
+		t.Errorf("meta: servings %d prep %d cook %d tags %q", r.Servings, r.PrepTime, r.CookTime, r.Tags)
+	}
+	if len(r.RecipeIngredients) != 5 || r.Ingredients != "Свекла - 2 шт, Капуста - 300 г, Картофель - 3 шт, Мясо - 500 г, Томатная паста - 2 ст.л." {
+		t.Errorf("ingredients: %q", r.Ingredients)
+	}
+	if r.Instructions != "1. Сварите бульон.\n2. Добавьте овощи.\n3. Варите до готовности." {
+		t.Errorf("instructions:\n%s", r.Instructions)
+	}
+	if len(d.Warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", d.Warnings)
+	}
+}
+
+func TestParseFallbacks(t *testing.T) {
+	// HTML без JSON-LD разбирается как текст страницы
+	d := parseFixture(t, "page.html")
+	if d.Format != FormatText || d.Recipe.Name != "Овсяная каша" || d.Recipe.Servings != 2 || d.Recipe.CookTime != 10 {
+		t.Errorf("page.html: %+v", d)
+	}
+	if got := lines(d.Recipe); len(got) != 3 || d.Recipe.Instructions != "1. Вскипятите молоко.\n2. Всыпьте хлопья и варите 5 минут." {
+		t.Errorf("page.html: %q\n%s", got, d.Recipe.Instructions)
+	}
+
+	// Текст в windows-1251, состав одной строкой после заголовка
+	d = parseFixture(t, "plain_cp1251.txt")
+	if d.Recipe.Name != "Блины" || len(d.Recipe.RecipeIngredients) != 4 || d.Recipe.Instructions != "1. Смешайте всё и жарьте." {
+		t.Errorf("plain_cp1251.txt: %+v", d.Recipe)
+	}
+
+	data, err := os.ReadFile(filepath.Join("testdata", "no_recipe.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Parse(data); !errors.Is(err, ErrNoRecipe) {
+		t.Errorf("no_recipe.html: got %v, want ErrNoRecipe", err)
+	}
+}
@@ -0,0 +1,264 @@
+package importer
+
+import (
+	"encoding/json"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	jsonLDRe   = regexp.MustCompile(`(?is)<script[^>]+type\s*=\s*["']?application/ld\+json["']?[^>]*>(.*?)</script\s*>`)
+	durationRe = regexp.MustCompile(`(?i)^P(?:(\d+)D)?(?:T(?:(\d+(?:\.\d+)?)H)?(?:(\d+(?:\.\d+)?)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+	integerRe  = regexp.MustCompile(`\d+`)
+)
+
+// maxTags — сколько ключевых слов страницы переносить в теги: на сайтах их бывают десятки.
+const maxTags = 10
+
+// ParseHTML ищет на странице JSON-LD schema.org/Recipe (в том числе внутри @graph и
+// списков) и разбирает первый найденный рецепт.
+func ParseHTML(page string) (*Draft, error) {
+	for _, m := range jsonLDRe.FindAllStringSubmatch(page, -1) {
+		var v any
+		if err := json.Unmarshal([]byte(m[1]), &v); err != nil {
+			// Переводы строк внутри строк — частая ошибка разметки
+			fixed := strings.NewReplacer("\r", " ", "\n", " ", "\t", " ").Replace(m[1])
+			if json.Unmarshal([]byte(fixed), &v) != nil {
+				continue
+			}
+		}
+		if recipe := findRecipe(v); recipe != nil {
+			return fromJSONLD(recipe)
+		}
+	}
+	return nil, ErrNoRecipe
+}
+
+// findRecipe возвращает первый объект с @type Recipe.
+func findRecipe(v any) map[string]any {
+	switch v := v.(type) {
+	case []any:
+		for _, item := range v {
+			if r := findRecipe(item); r != nil {
+				return r
+			}
+		}
+	case map[string]any:
+		if hasType(v["@type"], "Recipe") {
+			return v
+		}
+		for _, key := range []string{"@graph", "mainEntity", "mainEntityOfPage", "itemListElement", "item"} {
+			if r := findRecipe(v[key]); r != nil {
+				return r
+			}
+		}
+	}
+	return nil
+}
+
+func hasType(v any, name string) bool {
+	switch v := v.(type) {
+	case string:
+		return v == name || strings.HasSuffix(v, "/"+name) || strings.HasSuffix(v, ":"+name)
+	case []any:
+		for _, t := range v {
+			if hasType(t, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func fromJSONLD(m map[string]any) (*Draft, error) {
+	d := &Draft{Format: FormatJSONLD}
+	r := &d.Recipe
+	r.Name = text(m["name"])
+	if r.Name == "" {
+		r.Name = text(m["headline"])
+	}
+	r.Description = text(m["description"])
+
+	raw := texts(m["recipeIngredient"])
+	if len(raw) == 0 {
+		raw = texts(m["ingredients"]) // устаревшее свойство
+	}
+	r.RecipeIngredients = ingredientLines(raw)
+	r.Instructions = numberedSteps(instructions(m["recipeInstructions"]))
+
+	prep, prepOK := duration(m["prepTime"])
+	cook, cookOK := duration(m["cookTime"])
+	if total, ok := duration(m["totalTime"]); ok && !cookOK && total > prep {
+		cook, cookOK = total-prep, true
+	}
+	if prepOK {
+		r.PrepTime = prep
+	}
+	if cookOK {
+		r.CookTime = cook
+	}
+	r.Servings = servings(m["recipeYield"])
+	if r.Servings == 0 {
+		r.Servings = servings(m["yield"])
+	}
+	r.ImageURL = url(m["image"])
+	r.VideoURL = url(m["video"])
+	r.Tags = tags(m["recipeCategory"], m["recipeCuisine"], m["keywords"])
+	return finish(d)
+}
+
+// text — строковое значение свойства: строка, число, объект с text/name или первый элемент списка.
+func text(v any) string {
+	switch v := v.(type) {
+	case string:
+		return cleanText(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case map[string]any:
+		if s := text(v["text"]); s != "" {
+			return s
+		}
+		return text(v["name"])
+	case []any:
+		for _, item := range v {
+			if s := text(item); s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// texts — список строковых значений.
+func texts(v any) []string {
+	switch v := v.(type) {
+	case []any:
+		var out []string
+		for _, item := range v {
+			if s := text(item); s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	case string:
+		// Иногда состав или шаги приходят одной строкой с переводами строк
+		var out []string
+		for _, line := range strings.Split(htmlToText(v), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				out = append(out, line)
+			}
+		}
+		return out
+	}
+	if s := text(v); s != "" {
+		return []string{s}
+	}
+	return nil
+}
+
+// instructions разворачивает recipeInstructions: строку, список строк, HowToStep и
+// HowToSection с вложенными шагами (название раздела — строкой с двоеточием).
+func instructions(v any) []string {
+	switch v := v.(type) {
+	case []any:
+		var out []string
+		for _, item := range v {
+			out = append(out, instructions(item)...)
+		}
+		return out
+	case map[string]any:
+		if steps, ok := v["itemListElement"]; ok {
+			var out []string
+			if name := text(v["name"]); name != "" {
+				out = append(out, strings.TrimSuffix(name, ":")+":")
+			}
+			return append(out, instructions(steps)...)
+		}
+		if s := text(v["text"]); s != "" {
+			return []string{s}
+		}
+		if s := text(v["name"]); s != "" {
+			return []string{s}
+		}
+		return nil
+	}
+	return texts(v)
+}
+
+// duration разбирает ISO 8601 ("PT1H30M") в минуты.
+func duration(v any) (int, bool) {
+	s, _ := v.(string)
+	m := durationRe.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil || s == "P" || s == "PT" {
+		return 0, false
+	}
+	num := func(i int) float64 {
+		f, _ := strconv.ParseFloat(m[i], 64)
+		return f
+	}
+	minutes := num(1)*24*60 + num(2)*60 + num(3) + num(4)/60
+	return int(math.Round(minutes)), true
+}
+
+// servings — число порций из recipeYield: 4, "4", "4 servings", "на 4 персоны", ["4", "4 servings"].
+func servings(v any) int {
+	switch v := v.(type) {
+	case float64:
+		return int(v)
+	case string:
+		if s := integerRe.FindString(v); s != "" {
+			n, _ := strconv.Atoi(s)
+			return n
+		}
+	case []any:
+		for _, item := range v {
+			if n := servings(item); n > 0 {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+// url — адрес картинки или видео: строка, ImageObject/VideoObject или первый элемент списка.
+func url(v any) string {
+	switch v := v.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case map[string]any:
+		for _, key := range []string{"url", "contentUrl", "embedUrl", "@id"} {
+			if s := url(v[key]); strings.HasPrefix(s, "http") {
+				return s
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if s := url(item); s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// tags собирает теги через запятую из категорий, кухни и ключевых слов, без повторов.
+func tags(values ...any) string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, v := range values {
+		for _, s := range texts(v) {
+			for _, tag := range strings.Split(s, ",") {
+				tag = strings.TrimSpace(tag)
+				key := strings.ToLower(tag)
+				if tag == "" || seen[key] || len(out) == maxTags {
+					continue
+				}
+				seen[key] = true
+				out = append(out, tag)
+			}
+		}
+	}
+	return strings.Join(out, ", ")
+}
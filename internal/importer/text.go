@@ -0,0 +1,164 @@
+package importer
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"podlevskikh/awesomeProject/internal/ingredients"
+	"podlevskikh/awesomeProject/internal/models"
+)
+
+// Разделы простого формата. Заголовок — отдельная строка, двоеточие необязательно.
+const (
+	sectionNone = iota
+	sectionIngredients
+	sectionSteps
+)
+
+var sectionHeaders = map[string]int{
+	"ингредиенты": sectionIngredients, "состав": sectionIngredients, "продукты": sectionIngredients, "ingredients": sectionIngredients,
+	"приготовление": sectionSteps, "способ приготовления": sectionSteps, "инструкция": sectionSteps,
+	"шаги": sectionSteps, "instructions": sectionSteps, "directions": sectionSteps,
+	"method": sectionSteps, "steps": sectionSteps, "preparation": sectionSteps,
+}
+
+// Поля "Ключ: значение" в начале текста.
+var metaKeys = map[string]string{
+	"порции": "servings", "порций": "servings", "количество порций": "servings", "выход": "servings",
+	"servings": "servings", "serves": "servings", "yield": "servings",
+	"подготовка": "prep", "время подготовки": "prep", "prep": "prep", "prep time": "prep",
+	"готовка": "cook", "время готовки": "cook", "время приготовления": "cook", "cook": "cook", "cook time": "cook",
+	"фото": "image", "изображение": "image", "картинка": "image", "image": "image", "photo": "image",
+	"видео": "video", "video": "video",
+	"теги": "tags", "метки": "tags", "tags": "tags",
+	"описание": "description", "description": "description",
+}
+
+var (
+	hoursRe   = regexp.MustCompile(`(?i)(\d+(?:[.,]\d+)?)\s*(?:ч|час|часа|часов|h|hr|hrs|hour|hours)(?:\PL|$)`)
+	minutesRe = regexp.MustCompile(`(?i)(\d+)\s*(?:м|мин|минут|минуты|минута|m|min|mins|minute|minutes)(?:\PL|$)`)
+	headingRe = regexp.MustCompile(`^[#=*\s]+|[#=*\s]+$`)
+)
+
+// ParseText разбирает простой текстовый формат:
+//
+//	Блины на молоке
+//	Порции: 4
+//	Подготовка: 10 мин
+//	Готовка: 30 мин
+//	Тонкие блины к завтраку.
+//
+//	Ингредиенты:
+//	- Мука - 200г
+//	- Молоко - 500 мл
+//
+//	Приготовление:
+//	1. Смешать муку с молоком.
+//	2. Жарить на сковороде.
+//
+// Первая строка — название; строки до разделов, не являющиеся полями, — описание.
+// Заголовки и поля понимаются и по-английски (Ingredients, Instructions, Servings, ...).
+// Без раздела ингредиентов возвращается ErrNoRecipe.
+func ParseText(text string) (*Draft, error) {
+	d := &Draft{Format: FormatText}
+	r := &d.Recipe
+	section := sectionNone
+	var description, rawIngredients, steps []string
+	found := false
+
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = cleanText(line)
+		if line == "" {
+			continue
+		}
+		header := strings.ToLower(strings.TrimSuffix(headingRe.ReplaceAllString(line, ""), ":"))
+		if s, ok := sectionHeaders[header]; ok {
+			section = s
+			found = found || s == sectionIngredients
+			continue
+		}
+		// Заголовок и содержимое в одной строке: "Состав: Мука - 200г, Яйца - 2 шт"
+		if key, value, ok := strings.Cut(line, ":"); ok {
+			if s, known := sectionHeaders[strings.ToLower(strings.TrimSpace(key))]; known && strings.TrimSpace(value) != "" {
+				section, line = s, strings.TrimSpace(value)
+				found = found || s == sectionIngredients
+			}
+		}
+
+		switch section {
+		case sectionIngredients:
+			rawIngredients = append(rawIngredients, line)
+			continue
+		case sectionSteps:
+			steps = append(steps, line)
+			continue
+		}
+
+		if r.Name == "" {
+			r.Name = headingRe.ReplaceAllString(line, "")
+			continue
+		}
+		if key, value, ok := strings.Cut(line, ":"); ok {
+			if field, known := metaKeys[strings.ToLower(strings.TrimSpace(key))]; known {
+				applyMeta(r, field, strings.TrimSpace(value), &description)
+				continue
+			}
+		}
+		if line != r.Name { // заголовок страницы часто повторяет <title>
+			description = append(description, line)
+		}
+	}
+	if !found {
+		return nil, ErrNoRecipe
+	}
+
+	// Состав одной строкой: "Мука - 200г, Яйца - 2 шт, Соль"
+	if len(rawIngredients) == 1 {
+		rawIngredients = ingredients.Split(rawIngredients[0])
+	}
+	r.RecipeIngredients = ingredientLines(rawIngredients)
+	r.Instructions = numberedSteps(steps)
+	if r.Description == "" {
+		r.Description = strings.Join(description, " ")
+	}
+	return finish(d)
+}
+
+func applyMeta(r *models.Recipe, field, value string, description *[]string) {
+	switch field {
+	case "servings":
+		r.Servings = servings(value)
+	case "prep":
+		r.PrepTime = minutes(value)
+	case "cook":
+		r.CookTime = minutes(value)
+	case "image":
+		r.ImageURL = value
+	case "video":
+		r.VideoURL = value
+	case "tags":
+		r.Tags = tags(value)
+	case "description":
+		*description = append(*description, value)
+	}
+}
+
+// minutes разбирает время: "30 мин", "1 ч 15 мин", "1.5 h", "45".
+func minutes(s string) int {
+	total := 0.0
+	if m := hoursRe.FindStringSubmatch(s); m != nil {
+		h, _ := strconv.ParseFloat(strings.ReplaceAll(m[1], ",", "."), 64)
+		total += h * 60
+	}
+	if m := minutesRe.FindStringSubmatch(s); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		total += float64(n)
+	}
+	if total == 0 {
+		if n, err := strconv.Atoi(strings.TrimSpace(s)); err == nil {
+			total = float64(n)
+		}
+	}
+	return int(total + 0.5)
+}
@@ -0,0 +1,185 @@
+// Package ingredients — структурированный состав рецептов: разбор свободного текста
+// ("Мука - 200г, Яйца - 2 шт, Соль") в строки RecipeIngredient и обратное форматирование.
+package ingredients
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"podlevskikh/awesomeProject/internal/models"
+	"podlevskikh/awesomeProject/internal/units"
+)
+
+// Line — разобранная строка состава.
+type Line struct {
+	Name        string
+	Quantity    *float64 // nil — «по вкусу» или количество не указано
+	Unit        string
+	Note        string
+	Raw         string
+	NeedsReview bool // парсер не уверен в результате
+}
+
+// toTaste — количества без числа, которые не требуют проверки.
+var toTaste = map[string]bool{"по вкусу": true, "to taste": true, "": true}
+
+var (
+	// "Name - amount", "Name – amount", "Name: amount"
+	separatorRe = regexp.MustCompile(`\s+[-–—]\s+|:\s+`)
+	// "Мука 200г" — количество в конце строки без разделителя
+	trailingAmountRe = regexp.MustCompile(`^(.*\D)\s+(\d[\d.,/\s]*\s*\pL[\pL. ]*|\d[\d.,/]*)$`)
+	noteRe           = regexp.MustCompile(`\s*\(([^)]*)\)\s*`)
+)
+
+// Parse разбивает свободный текст на строки и разбирает каждую.
+func Parse(text string) []Line {
+	var lines []Line
+	for _, item := range Split(text) {
+		lines = append(lines, ParseLine(item))
+	}
+	return lines
+}
+
+// Split делит текст на строки по переводам строк, ';' и запятым.
+// Запятая между цифрами ("1,5 кг") считается десятичной.
+func Split(text string) []string {
+	var items []string
+	var cur strings.Builder
+	runes := []rune(text)
+	flush := func() {
+		if s := strings.TrimSpace(cur.String()); s != "" {
+			items = append(items, s)
+		}
+		cur.Reset()
+	}
+	for i, r := range runes {
+		switch {
+		case r == '\n' || r == ';':
+			flush()
+		case r == ',' && !(i > 0 && i+1 < len(runes) && unicode.IsDigit(runes[i-1]) && unicode.IsDigit(runes[i+1])):
+			flush()
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	flush()
+	return items
+}
+
+// ParseLine разбирает одну строку: "Рыба (филе) - 400г" → Рыба, 400, г, note "филе".
+func ParseLine(raw string) Line {
+	line := Line{Raw: strings.TrimSpace(raw)}
+	text := strings.TrimLeft(line.Raw, "-•*· \t")
+
+	name, amount := text, ""
+	if loc := separatorRe.FindStringIndex(text); loc != nil {
+		name, amount = text[:loc[0]], text[loc[1]:]
+	} else if m := trailingAmountRe.FindStringSubmatch(text); m != nil {
+		name, amount = m[1], m[2]
+	} else if number, rest, ok := units.Split(text); ok && startsWithLetter(rest) {
+		name, amount = leadingAmount(number, rest)
+	}
+
+	if m := noteRe.FindStringSubmatch(name); m != nil {
+		line.Note = strings.TrimSpace(m[1])
+		name = noteRe.ReplaceAllString(name, " ")
+	}
+	line.Name = strings.TrimSpace(name)
+	if line.Name == "" {
+		line.Name = line.Raw
+		line.NeedsReview = true
+	}
+
+	amount = strings.TrimSpace(amount)
+	number, unitText, hasNumber := units.Split(amount)
+	if !hasNumber {
+		// Количество без числа: "по вкусу", "щепотка"
+		if unit, ok := units.Normalize(amount); ok && amount != "" {
+			one := 1.0
+			line.Quantity, line.Unit = &one, unit
+		} else if !toTaste[strings.ToLower(amount)] {
+			line.Note = joinNote(line.Note, amount)
+			line.NeedsReview = true
+		}
+		if amount == "" && strings.ContainsAny(line.Name, "0123456789") {
+			line.NeedsReview = true
+		}
+		return line
+	}
+
+	q, ok := units.ParseNumber(number)
+	if !ok {
+		line.NeedsReview = true
+		line.Note = joinNote(line.Note, amount)
+		return line
+	}
+	line.Quantity = &q
+	if strings.Contains(number, "-") {
+		line.Note = joinNote(line.Note, number) // диапазон: берём верхнюю границу, оставляем исходный
+		line.NeedsReview = true
+	}
+
+	unit, ok := units.Normalize(unitText)
+	if !ok {
+		line.NeedsReview = true
+	}
+	line.Unit = unit
+	return line
+}
+
+// leadingAmount разбирает строку с количеством в начале, как пишут на сайтах:
+// "200 g flour", "2 ст. л. сахара", "1 onion, chopped" (после запятой — заметка).
+func leadingAmount(number, rest string) (name, amount string) {
+	name, amount = rest, number
+	words := strings.Fields(rest)
+	for n := 2; n >= 1; n-- {
+		if len(words) <= n {
+			continue
+		}
+		unitText := strings.Join(words[:n], " ")
+		if code, ok := units.Normalize(unitText); ok && code != "" {
+			name, amount = strings.Join(words[n:], " "), number+" "+unitText
+			break
+		}
+	}
+	name = strings.TrimPrefix(name, "of ")
+	if head, note, ok := strings.Cut(name, ","); ok && strings.TrimSpace(note) != "" {
+		name = head + " (" + strings.TrimSpace(note) + ")"
+	}
+	return name, amount
+}
+
+func startsWithLetter(s string) bool {
+	for _, r := range s {
+		return unicode.IsLetter(r)
+	}
+	return false
+}
+
+func joinNote(note, extra string) string {
+	switch {
+	case extra == "":
+		return note
+	case note == "":
+		return extra
+	default:
+		return note + "; " + extra
+	}
+}
+
+// Format собирает из строк состава текст в формате свободного поля Recipe.Ingredients.
+func Format(items []models.RecipeIngredient) string {
+	parts := make([]string, 0, len(items))
+	for _, it := range items {
+		s := it.Name
+		if it.Note != "" {
+			s += " (" + it.Note + ")"
+		}
+		if it.Quantity != nil {
+			s += " - " + units.Quantity{Amount: *it.Quantity, Unit: it.Unit}.String()
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, ", ")
+}
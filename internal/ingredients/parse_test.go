@@ -0,0 +1,69 @@
+package ingredients
+
+import "testing"
+
+func TestParseSeedText(t *testing.T) {
+	lines := Parse("Рыба (филе) - 400г, Молоко - 2 стакана, Мед - 1 ст.л., Чеснок - 4 зубчика, Мука - 1,5 кг, Соль, перец")
+
+	want := []struct {
+		name, unit, note string
+		qty              float64 // 0 — без количества
+	}{
+		{"Рыба", "г", "филе", 400},
+		{"Молоко", "стакан", "", 2},
+		{"Мед", "ст.л.", "", 1},
+		{"Чеснок", "зубчик", "", 4},
+		{"Мука", "кг", "", 1.5},
+		{"Соль", "", "", 0},
+		{"перец", "", "", 0},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d: %+v", len(lines), len(want), lines)
+	}
+	for i, w := range want {
+		l := lines[i]
+		if l.Name != w.name || l.Unit != w.unit || l.Note != w.note || l.NeedsReview {
+			t.Errorf("line %d: got %+v, want %+v", i, l, w)
+		}
+		switch {
+		case w.qty == 0 && l.Quantity != nil:
+			t.Errorf("line %d: unexpected quantity %v", i, *l.Quantity)
+		case w.qty != 0 && (l.Quantity == nil || *l.Quantity != w.qty):
+			t.Errorf("line %d: quantity %v, want %v", i, l.Quantity, w.qty)
+		}
+	}
+}
+
+func TestParseFlagsUnclearLines(t *testing.T) {
+	for _, raw := range []string{
+		"Сыр - 2-3 куска",  // диапазон и неизвестная единица
+		"Сливки - немного", // количество без числа
+		"Яйца 3 крупных",   // неизвестная единица
+	} {
+		if l := ParseLine(raw); !l.NeedsReview || l.Raw != raw {
+			t.Errorf("%q: expected needs_review with raw text kept, got %+v", raw, l)
+		}
+	}
+	if l := ParseLine("Масло сливочное 20г"); l.NeedsReview || l.Name != "Масло сливочное" || l.Unit != "г" {
+		t.Errorf("trailing amount: got %+v", l)
+	}
+}
+
+func TestParseLeadingAmount(t *testing.T) {
+	cases := []struct {
+		raw, name, unit, note string
+		qty                   float64
+	}{
+		{"200 g flour", "flour", "г", "", 200},
+		{"2 ст. л. сахара", "сахара", "ст.л.", "", 2},
+		{"1 1/2 cups of milk", "milk", "стакан", "", 1.5},
+		{"1 onion, finely chopped", "onion", "", "finely chopped", 1},
+		{"3 яйца", "яйца", "", "", 3},
+	}
+	for _, c := range cases {
+		l := ParseLine(c.raw)
+		if l.Name != c.name || l.Unit != c.unit || l.Note != c.note || l.Quantity == nil || *l.Quantity != c.qty || l.NeedsReview {
+			t.Errorf("%q: got %+v (qty %v)", c.raw, l, l.Quantity)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package ingredients
+
+import (
+	"fmt"
+	"strings"
+
+	"podlevskikh/awesomeProject/internal/models"
+	"podlevskikh/awesomeProject/internal/units"
+
+	"gorm.io/gorm"
+)
+
+// NormalizeName приводит имя продукта к ключу справочника: нижний регистр, одиночные пробелы.
+func NormalizeName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// FromText разбирает свободный текст в строки состава (без привязки к рецепту).
+func FromText(text string) []models.RecipeIngredient {
+	var items []models.RecipeIngredient
+	for _, l := range Parse(text) {
+		items = append(items, models.RecipeIngredient{
+			Name:        l.Name,
+			Quantity:    l.Quantity,
+			Unit:        l.Unit,
+			Note:        l.Note,
+			RawText:     l.Raw,
+			NeedsReview: l.NeedsReview,
+		})
+	}
+	return items
+}
+
+// Validate проверяет строки состава, присланные через API.
+func Validate(items []models.RecipeIngredient) error {
+	for i, it := range items {
+		if strings.TrimSpace(it.Name) == "" {
+			return fmt.Errorf("recipe_ingredients[%d]: name is required", i)
+		}
+		if it.Quantity != nil && *it.Quantity < 0 {
+			return fmt.Errorf("recipe_ingredients[%d]: quantity must not be negative", i)
+		}
+	}
+	return nil
+}
+
+// Replace заменяет состав рецепта строками items и обновляет текстовое поле
+// Recipe.Ingredients, чтобы старые клиенты видели тот же состав.
+// db может быть транзакцией.
+func Replace(db *gorm.DB, recipe *models.Recipe, items []models.RecipeIngredient) error {
+	if err := Validate(items); err != nil {
+		return err
+	}
+	if err := db.Where("recipe_id = ?", recipe.ID).Delete(&models.RecipeIngredient{}).Error; err != nil {
+		return err
+	}
+	saved, err := insert(db, recipe, items)
+	if err != nil {
+		return err
+	}
+	recipe.RecipeIngredients = saved
+	recipe.Ingredients = Format(saved)
+	return db.Model(&models.Recipe{}).Where("id = ?", recipe.ID).
+		Update("ingredients", recipe.Ingredients).Error
+}
+
+// ReplaceFromText заменяет состав рецепта разбором его текстового поля Recipe.Ingredients.
+// Текст не переписывается: клиент прислал его сам.
+func ReplaceFromText(db *gorm.DB, recipe *models.Recipe) error {
+	if err := db.Where("recipe_id = ?", recipe.ID).Delete(&models.RecipeIngredient{}).Error; err != nil {
+		return err
+	}
+	saved, err := insert(db, recipe, FromText(recipe.Ingredients))
+	if err != nil {
+		return err
+	}
+	recipe.RecipeIngredients = saved
+	return nil
+}
+
+// insert создаёт строки состава, связывая их с продуктами справочника организации рецепта.
+func insert(db *gorm.DB, recipe *models.Recipe, items []models.RecipeIngredient) ([]models.RecipeIngredient, error) {
+	saved := make([]models.RecipeIngredient, 0, len(items))
+	for i, it := range items {
+		row := models.RecipeIngredient{
+			RecipeID:    recipe.ID,
+			Position:    i,
+			Name:        strings.TrimSpace(it.Name),
+			Quantity:    it.Quantity,
+			Unit:        strings.TrimSpace(it.Unit),
+			Note:        strings.TrimSpace(it.Note),
+			RawText:     it.RawText,
+			NeedsReview: it.NeedsReview,
+		}
+		if unit, ok := units.Normalize(row.Unit); ok {
+			row.Unit = unit
+		}
+		ingredient, err := resolve(db, recipe.OrganizationID, row.Name)
+		if err != nil {
+			return nil, err
+		}
+		row.IngredientID = &ingredient.ID
+		row.Ingredient = ingredient
+		if err := db.Omit("Ingredient").Create(&row).Error; err != nil {
+			return nil, err
+		}
+		saved = append(saved, row)
+	}
+	return saved, nil
+}
+
+// resolve находит продукт организации по нормализованному имени или создаёт его.
+func resolve(db *gorm.DB, orgID uint, name string) (*models.Ingredient, error) {
+	ingredient := models.Ingredient{OrganizationID: orgID, NormalizedName: NormalizeName(name)}
+	err := db.Where("organization_id = ? AND normalized_name = ?", orgID, ingredient.NormalizedName).
+		Attrs(models.Ingredient{Name: strings.TrimSpace(name)}).
+		FirstOrCreate(&ingredient).Error
+	if err != nil {
+		return nil, fmt.Errorf("resolve ingredient %q: %w", name, err)
+	}
+	return &ingredient, nil
+}
+
+// MigrateFreeText — best-effort перенос свободного текста Recipe.Ingredients в структурированный
+// состав для рецептов, у которых его ещё нет. Текст рецепта не меняется; строки, которые не удалось
+// уверенно разобрать, помечаются NeedsReview. Идемпотентно.
+func MigrateFreeText(db *gorm.DB) (migrated, flagged int, err error) {
+	var recipes []models.Recipe
+	err = db.Where("ingredients IS NOT NULL AND ingredients <> ''").
+		Where("NOT EXISTS (SELECT 1 FROM recipe_ingredients ri WHERE ri.recipe_id = recipes.id)").
+		Order("id").
+		Find(&recipes).Error
+	if err != nil {
+		return 0, 0, err
+	}
+	for i := range recipes {
+		recipe := &recipes[i]
+		items := FromText(recipe.Ingredients)
+		if len(items) == 0 {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			_, err := insert(tx, recipe, items)
+			return err
+		})
+		if err != nil {
+			return migrated, flagged, fmt.Errorf("recipe %d: %w", recipe.ID, err)
+		}
+		migrated++
+		for _, it := range items {
+			if it.NeedsReview {
+				flagged++
+			}
+		}
+	}
+	return migrated, flagged, nil
+}
+
+// Scale пересчитывает состав рецепта, рассчитанного на from порций, на to порций.
+// Количества переводятся в удобные единицы (1500 г → 1.5 кг), строки «по вкусу» не меняются.
+// Если число порций рецепта неизвестно, состав возвращается как есть.
+func Scale(items []models.RecipeIngredient, from, to int) []models.RecipeIngredient {
+	scaled := make([]models.RecipeIngredient, len(items))
+	copy(scaled, items)
+	if from <= 0 || to <= 0 || from == to {
+		return scaled
+	}
+	factor := float64(to) / float64(from)
+	for i := range scaled {
+		if scaled[i].Quantity == nil {
+			continue
+		}
+		q := units.Quantity{Amount: *scaled[i].Quantity, Unit: scaled[i].Unit}.Scale(factor).Humanize()
+		scaled[i].Quantity, scaled[i].Unit = &q.Amount, q.Unit
+	}
+	return scaled
+}
@@ -0,0 +1,160 @@
+package jobs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule — разобранное cron-выражение из пяти полей:
+// минута, час, день месяца, месяц, день недели ("0 2 * * *" — каждый день в 02:00).
+// Поддерживаются "*", числа, списки "1,3", диапазоны "1-5" и шаги "*/15", "0-30/10".
+// Время сверяется в том часовом поясе, в котором передано.
+type Schedule struct {
+	spec    string
+	minute  fieldSet
+	hour    fieldSet
+	dom     fieldSet
+	month   fieldSet
+	dow     fieldSet
+	domStar bool
+	dowStar bool
+}
+
+type fieldSet map[int]bool
+
+// ParseSchedule разбирает cron-выражение.
+func ParseSchedule(spec string) (*Schedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron %q: expected 5 fields, got %d", spec, len(fields))
+	}
+
+	s := &Schedule{spec: spec}
+	var err error
+	if s.minute, err = parseField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("cron %q: minute: %w", spec, err)
+	}
+	if s.hour, err = parseField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("cron %q: hour: %w", spec, err)
+	}
+	if s.dom, err = parseField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("cron %q: day of month: %w", spec, err)
+	}
+	if s.month, err = parseField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("cron %q: month: %w", spec, err)
+	}
+	if s.dow, err = parseField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("cron %q: day of week: %w", spec, err)
+	}
+	if s.dow[7] {
+		s.dow[0] = true // 7 — тоже воскресенье
+	}
+	s.domStar = fields[2] == "*"
+	s.dowStar = fields[4] == "*"
+	return s, nil
+}
+
+// MustParseSchedule — ParseSchedule для выражений, заданных в коде.
+func MustParseSchedule(spec string) *Schedule {
+	s, err := ParseSchedule(spec)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func (s *Schedule) String() string {
+	return s.spec
+}
+
+// Matches сообщает, приходится ли минута t на расписание.
+func (s *Schedule) Matches(t time.Time) bool {
+	return s.minute[t.Minute()] && s.hour[t.Hour()] && s.month[int(t.Month())] && s.dayMatches(t)
+}
+
+// Next возвращает первую минуту строго после after, подходящую под расписание
+// (в часовом поясе after). Ищет не дальше чем на 5 лет вперёд.
+func (s *Schedule) Next(after time.Time) time.Time {
+	loc := after.Location()
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case !s.month[int(t.Month())]:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case !s.hour[t.Hour()]:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case !s.minute[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches проверяет день месяца и день недели. Как в классическом cron,
+// если ограничены оба поля, достаточно совпадения любого из них.
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domOK := s.dom[t.Day()]
+	dowOK := s.dow[int(t.Weekday())]
+	switch {
+	case s.domStar && s.dowStar:
+		return true
+	case s.domStar:
+		return dowOK
+	case s.dowStar:
+		return domOK
+	default:
+		return domOK || dowOK
+	}
+}
+
+// parseField разбирает одно поле cron-выражения в множество допустимых значений.
+func parseField(field string, min, max int) (fieldSet, error) {
+	set := make(fieldSet)
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+			step = n
+			part = part[:i]
+		}
+
+		lo, hi := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(bounds[0])
+			hi, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("invalid range %q", part)
+			}
+		default:
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q", part)
+			}
+			lo = n
+			if step == 1 {
+				hi = n
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("value out of range %d-%d in %q", min, max, field)
+		}
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+	return set, nil
+}
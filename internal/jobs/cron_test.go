@@ -0,0 +1,53 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, spec := range []string{"", "0 2 * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "a * * * *", "5-1 * * * *"} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q): expected error", spec)
+		}
+	}
+}
+
+func TestScheduleMatches(t *testing.T) {
+	nicosia, err := time.LoadLocation("Asia/Nicosia")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		spec string
+		at   time.Time
+		want bool
+	}{
+		{"0 2 * * *", time.Date(2025, 6, 10, 2, 0, 0, 0, nicosia), true},
+		{"0 2 * * *", time.Date(2025, 6, 10, 2, 1, 0, 0, nicosia), false},
+		{"*/15 * * * *", time.Date(2025, 6, 10, 13, 45, 0, 0, time.UTC), true},
+		{"0 9 * * 1-5", time.Date(2025, 6, 14, 9, 0, 0, 0, time.UTC), false}, // Saturday
+		{"0 9 * * 7", time.Date(2025, 6, 15, 9, 0, 0, 0, time.UTC), true},    // 7 = Sunday
+		{"0 0 1 * 1", time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC), true},     // dom OR dow
+	}
+	for _, tc := range testCases {
+		if got := MustParseSchedule(tc.spec).Matches(tc.at); got != tc.want {
+			t.Errorf("%q matches %s = %v, want %v", tc.spec, tc.at, got, tc.want)
+		}
+	}
+
+	// 02:00 in Nicosia is not 02:00 UTC
+	if MustParseSchedule("0 2 * * *").Matches(time.Date(2025, 6, 10, 2, 0, 0, 0, nicosia).UTC()) {
+		t.Error("schedule must be evaluated in the given location")
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	s := MustParseSchedule("30 2 * * 1")
+	got := s.Next(time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)) // Tuesday
+	want := time.Date(2025, 6, 16, 2, 30, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Errorf("Next = %s, want %s", got, want)
+	}
+}
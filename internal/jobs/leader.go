@@ -0,0 +1,70 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"log"
+)
+
+// Leader — выбор ведущей реплики через session-level advisory lock в Postgres.
+// Блокировка живёт, пока открыто выделенное соединение: если реплика падает,
+// Postgres снимает её сам, и лидерство переходит к следующей.
+type Leader struct {
+	db   *sql.DB
+	key  int64
+	conn *sql.Conn
+}
+
+func NewLeader(db *sql.DB, key int64) *Leader {
+	return &Leader{db: db, key: key}
+}
+
+// IsLeader сообщает, удерживает ли реплика блокировку (по последней проверке).
+func (l *Leader) IsLeader() bool {
+	return l.conn != nil
+}
+
+// TryAcquire пытается стать лидером; если лидерство уже есть — проверяет, что
+// соединение с блокировкой живо. Возвращает текущее состояние.
+func (l *Leader) TryAcquire(ctx context.Context) bool {
+	if l.conn != nil {
+		if err := l.conn.PingContext(ctx); err == nil {
+			return true
+		}
+		log.Printf("jobs: lost leader connection, giving up leadership")
+		l.conn.Close()
+		l.conn = nil
+	}
+
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		log.Printf("jobs: leader election: %v", err)
+		return false
+	}
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", l.key).Scan(&acquired); err != nil {
+		log.Printf("jobs: leader election: %v", err)
+		conn.Close()
+		return false
+	}
+	if !acquired {
+		conn.Close()
+		return false
+	}
+
+	l.conn = conn
+	log.Printf("jobs: this replica is now the leader")
+	return true
+}
+
+// Release снимает блокировку и закрывает соединение.
+func (l *Leader) Release() {
+	if l.conn == nil {
+		return
+	}
+	if _, err := l.conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", l.key); err != nil {
+		log.Printf("jobs: failed to release leader lock: %v", err)
+	}
+	l.conn.Close()
+	l.conn = nil
+}
@@ -0,0 +1,217 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"podlevskikh/awesomeProject/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// leaderLockKey — ключ advisory lock, которым реплики делят право запускать задачи.
+const leaderLockKey int64 = 0x68656c706572 // "helper"
+
+// leaderCheckInterval — как часто ведомые реплики пробуют стать лидером,
+// а лидер проверяет, что блокировка ещё за ним.
+const leaderCheckInterval = 15 * time.Second
+
+// maxCatchUp — сколько пропущенных минут догоняем после долгой задачи или паузы.
+const maxCatchUp = 60 * time.Minute
+
+// ErrSkipped возвращается задачей, которой нечего делать (например, выключена настройкой).
+// Запуск записывается в историю со статусом skipped.
+var ErrSkipped = errors.New("skipped")
+
+// Job — фоновая задача по расписанию.
+type Job struct {
+	Name     string
+	Schedule *Schedule
+	// PerOrg — расписание сверяется с местным временем каждой организации,
+	// а Run вызывается отдельно для каждой из них. Иначе orgID = 0.
+	PerOrg bool
+	// RunOnStart — выполнить сразу, как только реплика стала лидером.
+	RunOnStart bool
+	Run        func(ctx context.Context, orgID uint) error
+}
+
+// Runner выполняет задачи по расписанию на реплике-лидере и пишет историю в job_runs.
+type Runner struct {
+	db     *gorm.DB
+	leader *Leader
+	jobs   []Job
+	host   string
+}
+
+func NewRunner(db *gorm.DB) (*Runner, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("jobs: %w", err)
+	}
+	host, _ := os.Hostname()
+	return &Runner{db: db, leader: NewLeader(sqlDB, leaderLockKey), host: host}, nil
+}
+
+// Register добавляет задачу. Вызывать до Run.
+func (r *Runner) Register(job Job) {
+	r.jobs = append(r.jobs, job)
+	log.Printf("jobs: registered %q (%s), next run at %s", job.Name, job.Schedule, job.Schedule.Next(time.Now()).Format(time.RFC3339))
+}
+
+// Run крутит расписание до отмены ctx. Задачи выполняются последовательно,
+// так что к возврату из Run текущая задача уже завершена и блокировка лидера снята.
+func (r *Runner) Run(ctx context.Context) {
+	defer r.leader.Release()
+
+	leaderTicker := time.NewTicker(leaderCheckInterval)
+	defer leaderTicker.Stop()
+
+	r.checkLeadership(ctx)
+	last := time.Now().Truncate(time.Minute)
+
+	for {
+		next := last.Add(time.Minute)
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			log.Printf("jobs: runner stopped")
+			return
+		case <-leaderTicker.C:
+			timer.Stop()
+			r.checkLeadership(ctx)
+		case <-timer.C:
+			now := time.Now().Truncate(time.Minute)
+			if now.Sub(last) > maxCatchUp {
+				last = now.Add(-maxCatchUp)
+			}
+			for m := last.Add(time.Minute); !m.After(now); m = m.Add(time.Minute) {
+				if ctx.Err() != nil {
+					break
+				}
+				if r.leader.IsLeader() {
+					r.tick(ctx, m)
+				}
+			}
+			last = now
+		}
+	}
+}
+
+// checkLeadership обновляет лидерство; при его получении закрывает «зависшие»
+// запуски прошлого лидера и выполняет задачи с RunOnStart.
+func (r *Runner) checkLeadership(ctx context.Context) {
+	wasLeader := r.leader.IsLeader()
+	if !r.leader.TryAcquire(ctx) || wasLeader {
+		return
+	}
+
+	res := r.db.Model(&models.JobRun{}).
+		Where("status = ?", models.JobRunRunning).
+		Updates(map[string]interface{}{"status": models.JobRunFailed, "error": "interrupted: replica stopped before the job finished"})
+	if res.RowsAffected > 0 {
+		log.Printf("jobs: marked %d interrupted runs as failed", res.RowsAffected)
+	}
+
+	now := time.Now().Truncate(time.Minute)
+	for _, job := range r.jobs {
+		if !job.RunOnStart {
+			continue
+		}
+		targets, err := r.targets(job)
+		if err != nil {
+			log.Printf("jobs: %s: %v", job.Name, err)
+			continue
+		}
+		for _, org := range targets {
+			if ctx.Err() != nil {
+				return
+			}
+			r.execute(ctx, job, org.ID, now)
+		}
+	}
+}
+
+// tick выполняет все задачи, расписание которых приходится на минуту m.
+func (r *Runner) tick(ctx context.Context, m time.Time) {
+	for _, job := range r.jobs {
+		targets, err := r.targets(job)
+		if err != nil {
+			log.Printf("jobs: %s: %v", job.Name, err)
+			continue
+		}
+		for _, org := range targets {
+			if ctx.Err() != nil {
+				return
+			}
+			if job.Schedule.Matches(m.In(org.Location())) {
+				r.execute(ctx, job, org.ID, m)
+			}
+		}
+	}
+}
+
+// targets возвращает организации для PerOrg-задачи или одну «пустую» (UTC) для глобальной.
+func (r *Runner) targets(job Job) ([]models.Organization, error) {
+	if !job.PerOrg {
+		return []models.Organization{{}}, nil
+	}
+	var orgs []models.Organization
+	if err := r.db.Order("id").Find(&orgs).Error; err != nil {
+		return nil, fmt.Errorf("failed to load organizations: %w", err)
+	}
+	return orgs, nil
+}
+
+// execute запускает задачу и записывает результат в историю.
+func (r *Runner) execute(ctx context.Context, job Job, orgID uint, scheduledFor time.Time) {
+	run := models.JobRun{
+		JobName:      job.Name,
+		ScheduledFor: scheduledFor,
+		StartedAt:    time.Now(),
+		Status:       models.JobRunRunning,
+		Host:         r.host,
+	}
+	if orgID != 0 {
+		run.OrganizationID = &orgID
+	}
+	if err := r.db.Create(&run).Error; err != nil {
+		log.Printf("jobs: %s: failed to record run: %v", job.Name, err)
+	}
+
+	err := r.safeRun(ctx, job, orgID)
+
+	finished := time.Now()
+	run.FinishedAt = &finished
+	run.DurationMs = finished.Sub(run.StartedAt).Milliseconds()
+	switch {
+	case err == nil:
+		run.Status = models.JobRunSucceeded
+	case errors.Is(err, ErrSkipped):
+		run.Status = models.JobRunSkipped
+	default:
+		run.Status = models.JobRunFailed
+		run.Error = err.Error()
+		log.Printf("jobs: %s (org %d) failed: %v", job.Name, orgID, err)
+	}
+	if run.ID != 0 {
+		if err := r.db.Save(&run).Error; err != nil {
+			log.Printf("jobs: %s: failed to record run result: %v", job.Name, err)
+		}
+	}
+}
+
+// safeRun вызывает задачу, превращая панику в ошибку, чтобы раннер продолжал работать.
+func (r *Runner) safeRun(ctx context.Context, job Job, orgID uint) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return job.Run(ctx, orgID)
+}
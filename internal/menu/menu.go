@@ -0,0 +1,445 @@
+// Package menu — планировщик меню на неделю поверх задач расписания
+// (ScheduleTask с task_type = "meal" и join-таблицы meal_recipes).
+package menu
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"podlevskikh/awesomeProject/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Операции массового редактирования.
+const (
+	OpMove   = "move"   // перенести рецепты (или один recipe_id) из задачи в другую ячейку
+	OpSwap   = "swap"   // поменять рецепты двух ячеек местами
+	OpSet    = "set"    // заменить рецепты ячейки на recipe_ids
+	OpLock   = "lock"   // закрепить ячейку: перегенерация и массовые правки её не трогают
+	OpUnlock = "unlock" // снять закрепление
+)
+
+// RecipeRef — рецепт в ячейке сетки.
+type RecipeRef struct {
+	ID       uint   `json:"id"`
+	Name     string `json:"name"`
+	ImageURL string `json:"image_url,omitempty"`
+}
+
+// Cell — приём пищи в конкретный день. TaskID пуст, если задачи на этот день нет; Time —
+// время задачи, если его поменяли и оно отличается от времени слота.
+type Cell struct {
+	Date      models.Date `json:"date"`
+	TaskID    *uint       `json:"task_id"`
+	Time      string      `json:"time,omitempty"`
+	Recipes   []RecipeRef `json:"recipes"`
+	Locked    bool        `json:"locked"`
+	Edited    bool        `json:"edited"`
+	Completed bool        `json:"completed"`
+}
+
+// Slot — строка сетки: слот приёма пищи на каждый день недели. Слот задаётся приёмом пищи
+// и временем, на которое его сгенерировали (MealTimeID + MealSlot задачи), так что задача с
+// изменённым временем остаётся в своей строке; у задач без MealTimeID — заголовком и временем.
+type Slot struct {
+	MealTimeID *uint  `json:"meal_time_id,omitempty"`
+	Title      string `json:"title"`
+	Time       string `json:"time"`
+	Cells      []Cell `json:"cells"`
+}
+
+// Week — сетка меню на 7 дней начиная со Start.
+type Week struct {
+	Start models.Date   `json:"start"`
+	Days  []models.Date `json:"days"`
+	Slots []Slot        `json:"slots"`
+}
+
+// CellRef адресует ячейку по дню и слоту — для переноса в день, где задачи ещё нет.
+// MealTimeID, Title и Time — поля строки сетки (Slot).
+type CellRef struct {
+	Date       models.Date `json:"date"`
+	MealTimeID *uint       `json:"meal_time_id,omitempty"`
+	Title      string      `json:"title"`
+	Time       string      `json:"time"`
+}
+
+// slotTime — время слота задачи: время генерации, а у задач, сохранённых до него, — время задачи.
+func slotTime(t models.ScheduleTask) string {
+	if t.MealSlot != "" {
+		return t.MealSlot
+	}
+	return t.Time
+}
+
+// slotKey — ключ строки сетки для задачи.
+func slotKey(t models.ScheduleTask) string {
+	if t.MealTimeID != nil {
+		return fmt.Sprintf("\x01%d\x00%s", *t.MealTimeID, slotTime(t))
+	}
+	return t.Title + "\x00" + slotTime(t)
+}
+
+// matches сообщает, что задача занимает слот ячейки: по приёму пищи, если он известен и
+// ячейке, и задаче, иначе по заголовку; время сравнивается со временем слота задачи.
+func (r CellRef) matches(t models.ScheduleTask) bool {
+	if slotTime(t) != r.Time {
+		return false
+	}
+	if r.MealTimeID != nil && t.MealTimeID != nil {
+		return *r.MealTimeID == *t.MealTimeID
+	}
+	return t.Title == r.Title
+}
+
+// Operation — одна операция массового редактирования.
+type Operation struct {
+	Op           string   `json:"op"`
+	TaskID       uint     `json:"task_id"`
+	TargetTaskID uint     `json:"target_task_id,omitempty"` // move/swap: целевая задача
+	Target       *CellRef `json:"target,omitempty"`         // move: целевая ячейка, если задачи там нет
+	RecipeID     uint     `json:"recipe_id,omitempty"`      // move: перенести только этот рецепт
+	RecipeIDs    []uint   `json:"recipe_ids,omitempty"`     // set
+}
+
+// OpError — ошибка в конкретной операции; вся пачка откатывается.
+type OpError struct {
+	Index   int
+	Message string
+}
+
+func (e *OpError) Error() string {
+	return fmt.Sprintf("operation %d: %s", e.Index, e.Message)
+}
+
+// Planner — сервис планировщика меню.
+type Planner struct {
+	db *gorm.DB
+}
+
+func NewPlanner(db *gorm.DB) *Planner {
+	return &Planner{db: db}
+}
+
+// Week собирает сетку приёмов пищи организации на 7 дней начиная со start.
+func (p *Planner) Week(orgID uint, start models.Date) (*Week, error) {
+	end := start.AddDays(7)
+
+	var schedules []models.DailySchedule
+	if err := p.db.
+		Preload("Tasks", "task_type = ?", "meal").
+		Preload("Tasks.Recipes").
+		Preload("Tasks.Recipe").
+		Where("organization_id = ? AND date >= ? AND date < ?", orgID, start, end).
+		Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+
+	return buildWeek(start, schedules), nil
+}
+
+// buildWeek раскладывает meal-задачи расписаний недели, начинающейся со start, по строкам сетки.
+func buildWeek(start models.Date, schedules []models.DailySchedule) *Week {
+	week := &Week{Start: start, Slots: []Slot{}}
+	for i := 0; i < 7; i++ {
+		week.Days = append(week.Days, start.AddDays(i))
+	}
+
+	slots := map[string]*Slot{}
+	slotFor := func(t models.ScheduleTask) *Slot {
+		key := slotKey(t)
+		if s, ok := slots[key]; ok {
+			return s
+		}
+		s := &Slot{MealTimeID: t.MealTimeID, Title: t.Title, Time: slotTime(t), Cells: make([]Cell, 7)}
+		for i := range s.Cells {
+			s.Cells[i] = Cell{Date: week.Days[i], Recipes: []RecipeRef{}}
+		}
+		slots[key] = s
+		return s
+	}
+
+	for _, sch := range schedules {
+		day := sch.Date.DaysSince(start)
+		for _, t := range sch.Tasks {
+			taskID := t.ID
+			slot := slotFor(t)
+			cell := &slot.Cells[day]
+			if cell.TaskID != nil {
+				continue // дубль слота в тот же день — показываем первую задачу
+			}
+			cell.TaskID = &taskID
+			if t.Time != slot.Time {
+				cell.Time = t.Time
+			}
+			cell.Locked, cell.Edited, cell.Completed = t.Locked, t.Edited, t.Completed
+			for _, r := range withLegacyRecipe(t) {
+				cell.Recipes = append(cell.Recipes, RecipeRef{ID: r.ID, Name: r.Name, ImageURL: r.ImageURL})
+			}
+		}
+	}
+
+	for _, s := range slots {
+		week.Slots = append(week.Slots, *s)
+	}
+	sort.Slice(week.Slots, func(i, j int) bool {
+		a, b := week.Slots[i], week.Slots[j]
+		if a.Time != b.Time {
+			return a.Time < b.Time
+		}
+		return a.Title < b.Title
+	})
+	return week
+}
+
+// Apply выполняет операции по порядку в одной транзакции: либо все, либо ни одной.
+// Изменённые ячейки помечаются edited и переживают перегенерацию.
+func (p *Planner) Apply(orgID uint, ops []Operation) error {
+	return p.db.Transaction(func(tx *gorm.DB) error {
+		b := &batch{tx: tx, orgID: orgID}
+		for i, op := range ops {
+			if err := b.apply(op); err != nil {
+				var opErr *OpError
+				if errors.As(err, &opErr) {
+					opErr.Index = i
+					return opErr
+				}
+				return fmt.Errorf("operation %d: %w", i, err)
+			}
+		}
+		return nil
+	})
+}
+
+// batch — состояние одной транзакции Apply.
+type batch struct {
+	tx    *gorm.DB
+	orgID uint
+}
+
+func (b *batch) apply(op Operation) error {
+	source, err := b.task(op.TaskID)
+	if err != nil {
+		return err
+	}
+
+	var target *models.ScheduleTask
+	var recipes []models.Recipe
+	switch op.Op {
+	case OpLock, OpUnlock:
+		return b.tx.Model(source).Update("locked", op.Op == OpLock).Error
+	case OpSet:
+		recipes, err = b.recipes(op.RecipeIDs)
+	case OpSwap:
+		target, err = b.task(op.TargetTaskID)
+	case OpMove:
+		target, err = b.target(op, source)
+	}
+	if err != nil {
+		return err
+	}
+
+	src, dst, err := edit(op, source, target, recipes)
+	if err != nil {
+		return err
+	}
+	if err := b.setRecipes(source, src); err != nil {
+		return err
+	}
+	if target == nil {
+		return nil
+	}
+	return b.setRecipes(target, dst)
+}
+
+// edit вычисляет рецепты ячеек source и target после операции set, swap или move; recipes —
+// рецепты recipe_ids для set. Закреплённые и выполненные ячейки не меняются.
+func edit(op Operation, source, target *models.ScheduleTask, recipes []models.Recipe) (src, dst []models.Recipe, err error) {
+	if op.Op != OpSet && op.Op != OpSwap && op.Op != OpMove {
+		return nil, nil, &OpError{Message: fmt.Sprintf("unknown op %q", op.Op)}
+	}
+	if err := editable(source); err != nil {
+		return nil, nil, err
+	}
+	if op.Op == OpSet {
+		return recipes, nil, nil
+	}
+	if target.ID == source.ID {
+		return nil, nil, &OpError{Message: "source and target are the same task"}
+	}
+	if err := editable(target); err != nil {
+		return nil, nil, err
+	}
+	if op.Op == OpSwap {
+		return target.Recipes, source.Recipes, nil
+	}
+
+	var moved, rest []models.Recipe
+	for _, r := range source.Recipes {
+		if op.RecipeID == 0 || r.ID == op.RecipeID {
+			moved = append(moved, r)
+		} else {
+			rest = append(rest, r)
+		}
+	}
+	if len(moved) == 0 {
+		return nil, nil, &OpError{Message: "nothing to move"}
+	}
+	return rest, mergeRecipes(target.Recipes, moved), nil
+}
+
+// task загружает приём пищи организации вместе с рецептами.
+func (b *batch) task(id uint) (*models.ScheduleTask, error) {
+	var task models.ScheduleTask
+	err := b.tx.Preload("Recipes", func(db *gorm.DB) *gorm.DB { return db.Order("recipes.id") }).
+		Preload("Recipe").
+		Where("organization_id = ? AND task_type = ?", b.orgID, "meal").
+		First(&task, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, &OpError{Message: fmt.Sprintf("meal task %d not found", id)}
+	}
+	if err != nil {
+		return nil, err
+	}
+	task.Recipes = withLegacyRecipe(task)
+	return &task, nil
+}
+
+// withLegacyRecipe возвращает рецепты задачи; для задач, созданных до meal_recipes,
+// — рецепт из устаревшего recipe_id.
+func withLegacyRecipe(task models.ScheduleTask) []models.Recipe {
+	if len(task.Recipes) == 0 && task.Recipe != nil {
+		return []models.Recipe{*task.Recipe}
+	}
+	return task.Recipes
+}
+
+// target находит целевую задачу move: по target_task_id или по ячейке, создавая задачу
+// (и расписание дня), если её ещё нет. Новая задача берёт приём пищи из ячейки, а если его
+// там нет — из source той же строки.
+func (b *batch) target(op Operation, source *models.ScheduleTask) (*models.ScheduleTask, error) {
+	if op.TargetTaskID != 0 {
+		return b.task(op.TargetTaskID)
+	}
+	ref := op.Target
+	if ref == nil || ref.Date.IsZero() || ref.Title == "" {
+		return nil, &OpError{Message: "move needs target_task_id or target {date, title, time}"}
+	}
+
+	var schedule models.DailySchedule
+	err := b.tx.Where("organization_id = ? AND date = ?", b.orgID, ref.Date).First(&schedule).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		schedule = models.DailySchedule{OrganizationID: b.orgID, Date: ref.Date}
+		err = b.tx.Create(&schedule).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var meals []models.ScheduleTask
+	if err := b.tx.Where("schedule_id = ? AND task_type = ?", schedule.ID, "meal").Order("id").Find(&meals).Error; err != nil {
+		return nil, err
+	}
+	for _, t := range meals {
+		if ref.matches(t) {
+			return b.task(t.ID)
+		}
+	}
+
+	mealTimeID := ref.MealTimeID
+	if mealTimeID == nil && source.Title == ref.Title && slotTime(*source) == ref.Time {
+		mealTimeID = source.MealTimeID
+	}
+	task := models.ScheduleTask{
+		OrganizationID: b.orgID,
+		ScheduleID:     schedule.ID,
+		TaskType:       "meal",
+		Time:           ref.Time,
+		MealTimeID:     mealTimeID,
+		MealSlot:       ref.Time,
+		Title:          ref.Title,
+		Duration:       60,
+		Edited:         true,
+	}
+	if err := b.tx.Create(&task).Error; err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// recipes загружает рецепты организации, сохраняя порядок ids.
+func (b *batch) recipes(ids []uint) ([]models.Recipe, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var found []models.Recipe
+	if err := b.tx.Where("organization_id = ? AND id IN ?", b.orgID, ids).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.Recipe, len(found))
+	for _, r := range found {
+		byID[r.ID] = r
+	}
+	recipes := make([]models.Recipe, 0, len(ids))
+	for _, id := range ids {
+		r, ok := byID[id]
+		if !ok {
+			return nil, &OpError{Message: fmt.Sprintf("recipe %d not found", id)}
+		}
+		recipes = append(recipes, r)
+	}
+	return recipes, nil
+}
+
+// setRecipes заменяет рецепты задачи в meal_recipes и синхронизирует
+// устаревшие recipe_id/description: первый рецепт и список названий.
+func (b *batch) setRecipes(task *models.ScheduleTask, recipes []models.Recipe) error {
+	if err := b.tx.Model(task).Association("Recipes").Replace(recipes); err != nil {
+		return err
+	}
+	task.Recipes = recipes
+
+	var recipeID *uint
+	names := make([]string, 0, len(recipes))
+	for i := range recipes {
+		if recipeID == nil {
+			recipeID = &recipes[i].ID
+		}
+		names = append(names, recipes[i].Name)
+	}
+	return b.tx.Model(task).Updates(map[string]interface{}{
+		"recipe_id":   recipeID,
+		"description": strings.Join(names, ", "),
+		"edited":      true,
+	}).Error
+}
+
+// editable проверяет, что ячейку можно менять массовыми правками.
+func editable(task *models.ScheduleTask) error {
+	if task.Locked {
+		return &OpError{Message: fmt.Sprintf("task %d is locked", task.ID)}
+	}
+	if task.Completed {
+		return &OpError{Message: fmt.Sprintf("task %d is already completed", task.ID)}
+	}
+	return nil
+}
+
+// mergeRecipes добавляет к списку рецепты, которых в нём ещё нет.
+func mergeRecipes(list, add []models.Recipe) []models.Recipe {
+	seen := make(map[uint]bool, len(list))
+	for _, r := range list {
+		seen[r.ID] = true
+	}
+	merged := append([]models.Recipe(nil), list...)
+	for _, r := range add {
+		if !seen[r.ID] {
+			merged = append(merged, r)
+			seen[r.ID] = true
+		}
+	}
+	return merged
+}
@@ -0,0 +1,131 @@
+package menu
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"podlevskikh/awesomeProject/internal/models"
+)
+
+func cell(id uint, recipeIDs ...uint) *models.ScheduleTask {
+	task := &models.ScheduleTask{ID: id, TaskType: "meal"}
+	for _, r := range recipeIDs {
+		task.Recipes = append(task.Recipes, models.Recipe{ID: r})
+	}
+	return task
+}
+
+func ids(recipes []models.Recipe) string {
+	parts := make([]string, len(recipes))
+	for i, r := range recipes {
+		parts[i] = fmt.Sprint(r.ID)
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+func TestEdit(t *testing.T) {
+	locked, completed, edited := cell(3, 30), cell(4, 40), cell(5, 50)
+	locked.Locked, completed.Completed, edited.Edited = true, true, true
+
+	cases := []struct {
+		name           string
+		op             Operation
+		source, target *models.ScheduleTask
+		recipes        []models.Recipe
+		want           string // рецепты source и target после операции или текст ошибки
+	}{
+		{"set", Operation{Op: OpSet}, cell(1, 10), nil, []models.Recipe{{ID: 11}, {ID: 12}}, "[11 12] []"},
+		{"set в изменённую вручную ячейку", Operation{Op: OpSet}, edited, nil, []models.Recipe{{ID: 11}}, "[11] []"},
+		{"swap", Operation{Op: OpSwap}, cell(1, 10), cell(2, 20, 21), nil, "[20 21] [10]"},
+		{"move всех рецептов", Operation{Op: OpMove}, cell(1, 10, 11), cell(2, 20), nil, "[] [20 10 11]"},
+		{"move одного рецепта", Operation{Op: OpMove, RecipeID: 11}, cell(1, 10, 11), cell(2, 11, 20), nil, "[10] [11 20]"},
+		{"move в ту же ячейку", Operation{Op: OpMove}, cell(1, 10), cell(1, 10), nil, "source and target are the same task"},
+		{"move отсутствующего рецепта", Operation{Op: OpMove, RecipeID: 99}, cell(1, 10), cell(2), nil, "nothing to move"},
+		{"set в закреплённую ячейку", Operation{Op: OpSet}, locked, nil, []models.Recipe{{ID: 11}}, "task 3 is locked"},
+		{"swap с закреплённой ячейкой", Operation{Op: OpSwap}, cell(1, 10), locked, nil, "task 3 is locked"},
+		{"move из закреплённой ячейки", Operation{Op: OpMove}, locked, cell(2), nil, "task 3 is locked"},
+		{"move в выполненную ячейку", Operation{Op: OpMove}, cell(1, 10), completed, nil, "task 4 is already completed"},
+		{"неизвестная операция", Operation{Op: "copy"}, cell(1, 10), nil, nil, `unknown op "copy"`},
+	}
+	for _, c := range cases {
+		src, dst, err := edit(c.op, c.source, c.target, c.recipes)
+		got := ids(src) + " " + ids(dst)
+		if err != nil {
+			var opErr *OpError
+			if !errors.As(err, &opErr) {
+				t.Errorf("%s: %v is not an OpError", c.name, err)
+			}
+			got = opErr.Message
+		}
+		if got != c.want {
+			t.Errorf("%s: got %s, want %s", c.name, got, c.want)
+		}
+	}
+}
+
+func meal(id uint, mealTimeID *uint, title, slot, time string) models.ScheduleTask {
+	return models.ScheduleTask{ID: id, TaskType: "meal", MealTimeID: mealTimeID, Title: title, MealSlot: slot, Time: time}
+}
+
+// Строка сетки — приём пищи и время слота: завтрак, перенесённый на 09:30, остаётся в строке 08:00.
+func TestBuildWeek(t *testing.T) {
+	breakfast := uint(1)
+	monday := models.NewDate(2025, time.June, 2)
+	schedules := []models.DailySchedule{
+		{Date: monday, Tasks: []models.ScheduleTask{
+			meal(1, &breakfast, "Breakfast - adult", "08:00", "08:00"),
+			meal(2, nil, "Lunch", "", "13:00"),
+		}},
+		{Date: monday.AddDays(1), Tasks: []models.ScheduleTask{
+			meal(3, &breakfast, "Breakfast - adult", "08:00", "09:30"),
+			meal(4, nil, "Lunch", "", "13:00"),
+		}},
+	}
+	week := buildWeek(monday, schedules)
+
+	var got []string
+	for _, s := range week.Slots {
+		row := s.Time + " " + s.Title + ":"
+		for _, c := range s.Cells {
+			if c.TaskID != nil {
+				row += fmt.Sprintf(" %d", *c.TaskID)
+				if c.Time != "" {
+					row += "@" + c.Time
+				}
+			}
+		}
+		got = append(got, row)
+	}
+	want := "08:00 Breakfast - adult: 1 3@09:30|13:00 Lunch: 2 4"
+	if strings.Join(got, "|") != want {
+		t.Errorf("rows %q, want %q", strings.Join(got, "|"), want)
+	}
+	if week.Slots[0].MealTimeID == nil || *week.Slots[0].MealTimeID != breakfast {
+		t.Errorf("breakfast row meal time = %v, want %d", week.Slots[0].MealTimeID, breakfast)
+	}
+}
+
+func TestCellRefMatches(t *testing.T) {
+	breakfast, lunch := uint(1), uint(2)
+	moved := meal(1, &breakfast, "Breakfast - adult", "08:00", "09:30")
+	cases := []struct {
+		name string
+		ref  CellRef
+		task models.ScheduleTask
+		want bool
+	}{
+		{"тот же приём пищи, время изменено", CellRef{MealTimeID: &breakfast, Title: "Breakfast - adult", Time: "08:00"}, moved, true},
+		{"ячейка без приёма пищи — по заголовку", CellRef{Title: "Breakfast - adult", Time: "08:00"}, moved, true},
+		{"по текущему времени задачи не находится", CellRef{MealTimeID: &breakfast, Title: "Breakfast - adult", Time: "09:30"}, moved, false},
+		{"другой приём пищи с тем же заголовком", CellRef{MealTimeID: &lunch, Title: "Breakfast - adult", Time: "08:00"}, moved, false},
+		{"старая задача без слота", CellRef{MealTimeID: &lunch, Title: "Lunch", Time: "13:00"}, meal(2, nil, "Lunch", "", "13:00"), true},
+	}
+	for _, c := range cases {
+		if got := c.ref.matches(c.task); got != c.want {
+			t.Errorf("%s: matches = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
@@ -10,10 +10,13 @@ import (
 	"gorm.io/gorm"
 )
 
-const ContextKeyMembership = "membership"
+const (
+	ContextKeyMembership   = "membership"
+	ContextKeyOrganization = "organization"
+)
 
 // OrgContext читает X-Org-Id, проверяет активное Membership пользователя
-// и кладёт *models.Membership в контекст. Должен стоять после Auth().
+// и кладёт *models.Membership и *models.Organization в контекст. Должен стоять после Auth().
 func OrgContext(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		orgIDStr := c.GetHeader("X-Org-Id")
@@ -43,7 +46,14 @@ func OrgContext(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		var org models.Organization
+		if err := db.First(&org, membership.OrganizationID).Error; err != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "organization not found"})
+			return
+		}
+
 		c.Set(ContextKeyMembership, &membership)
+		c.Set(ContextKeyOrganization, &org)
 		c.Next()
 	}
 }
@@ -54,3 +64,10 @@ func MustMembership(c *gin.Context) *models.Membership {
 	m, _ := c.Get(ContextKeyMembership)
 	return m.(*models.Membership)
 }
+
+// MustOrganization возвращает Organization из контекста (паникует если не установлена —
+// значит OrgContext не был применён к маршруту).
+func MustOrganization(c *gin.Context) *models.Organization {
+	o, _ := c.Get(ContextKeyOrganization)
+	return o.(*models.Organization)
+}
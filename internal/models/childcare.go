@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// ChildcarePattern — еженедельный шаблон присмотра за детьми: дни недели и время блока,
+// например пн–пт 08:00–13:00 или ср 18:00–21:00. Планировщик разворачивает шаблоны
+// в ChildcareSchedule на каждый подходящий рабочий день (см. пакет childcare); в один день
+// может приходиться несколько блоков.
+type ChildcarePattern struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	OrganizationID uint      `gorm:"index;not null" json:"organization_id"`
+	Name           string    `json:"name"`
+	Weekdays       string    `gorm:"not null" json:"weekdays"`   // "MO-FR", "MO,WE,FR"
+	StartTime      string    `gorm:"not null" json:"start_time"` // HH:MM
+	EndTime        string    `gorm:"not null" json:"end_time"`   // HH:MM
+	Notes          string    `json:"notes"`
+	ValidFrom      *Date     `json:"valid_from,omitempty"`
+	ValidUntil     *Date     `json:"valid_until,omitempty"` // включительно
+	Active         bool      `gorm:"default:true" json:"active"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+
+	Children []FamilyMember `gorm:"many2many:childcare_pattern_children;" json:"children,omitempty"` // за кем присмотр
+}
+
+// ChildcareException — дни, когда шаблоны не применяются: отпуск родителя по уходу, поездка.
+// Без PatternID исключение действует на все шаблоны организации. Праздники и воскресенья
+// пропускаются и без исключений.
+type ChildcareException struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	OrganizationID uint      `gorm:"index;not null" json:"organization_id"`
+	PatternID      *uint     `gorm:"index" json:"pattern_id,omitempty"`
+	StartDate      Date      `gorm:"not null" json:"start_date"`
+	EndDate        Date      `gorm:"not null" json:"end_date"` // включительно
+	Reason         string    `json:"reason"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
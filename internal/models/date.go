@@ -0,0 +1,138 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+	_ "time/tzdata" // встраиваем базу IANA-зон: в alpine-образе её нет
+)
+
+const dateLayout = "2006-01-02"
+
+// Date — календарная дата без времени и часового пояса (YYYY-MM-DD).
+// Единый тип для всех «дневных» полей: расписания, уход за ребёнком, праздники.
+// В БД хранится в колонке типа date, в JSON — строкой "2006-01-02".
+type Date struct {
+	t time.Time // всегда полночь UTC
+}
+
+// NewDate собирает дату из года, месяца и дня (с нормализацией переполнения, как time.Date).
+func NewDate(year int, month time.Month, day int) Date {
+	return Date{t: time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+// DateOf возвращает календарную дату момента t в его собственном часовом поясе.
+func DateOf(t time.Time) Date {
+	return NewDate(t.Year(), t.Month(), t.Day())
+}
+
+// ParseDate разбирает "2006-01-02". Для совместимости со старым фронтендом
+// принимает и RFC3339 ("2006-01-02T00:00:00Z") — берётся только дата.
+func ParseDate(s string) (Date, error) {
+	if len(s) > len(dateLayout) && s[len(dateLayout)] == 'T' {
+		s = s[:len(dateLayout)]
+	}
+	t, err := time.Parse(dateLayout, s)
+	if err != nil {
+		return Date{}, fmt.Errorf("invalid date %q, use YYYY-MM-DD", s)
+	}
+	return DateOf(t), nil
+}
+
+func (d Date) IsZero() bool             { return d.t.IsZero() }
+func (d Date) Year() int                { return d.t.Year() }
+func (d Date) Month() time.Month        { return d.t.Month() }
+func (d Date) Day() int                 { return d.t.Day() }
+func (d Date) Weekday() time.Weekday    { return d.t.Weekday() }
+func (d Date) AddDays(n int) Date       { return Date{t: d.t.AddDate(0, 0, n)} }
+func (d Date) AddDate(y, m, n int) Date { return Date{t: d.t.AddDate(y, m, n)} }
+func (d Date) Before(o Date) bool       { return d.t.Before(o.t) }
+func (d Date) After(o Date) bool        { return d.t.After(o.t) }
+func (d Date) Equal(o Date) bool        { return d.t.Equal(o.t) }
+
+// DaysSince возвращает число дней от o до d (отрицательное, если d раньше o).
+func (d Date) DaysSince(o Date) int {
+	return int(d.t.Sub(o.t).Hours() / 24)
+}
+
+// In возвращает начало дня d в часовом поясе loc.
+func (d Date) In(loc *time.Location) time.Time {
+	return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, loc)
+}
+
+// At возвращает момент "HH:MM" дня d в часовом поясе loc.
+func (d Date) At(clock string, loc *time.Location) (time.Time, error) {
+	hm, err := time.Parse("15:04", clock)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, use HH:MM", clock)
+	}
+	return time.Date(d.Year(), d.Month(), d.Day(), hm.Hour(), hm.Minute(), 0, 0, loc), nil
+}
+
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.t.Format(dateLayout)
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(d.String())
+}
+
+func (d *Date) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	if s == "" {
+		*d = Date{}
+		return nil
+	}
+	parsed, err := ParseDate(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// GormDataType — колонка хранится как date, без времени и зоны.
+func (Date) GormDataType() string {
+	return "date"
+}
+
+func (d Date) Value() (driver.Value, error) {
+	if d.IsZero() {
+		return nil, nil
+	}
+	return d.String(), nil
+}
+
+func (d *Date) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*d = Date{}
+	case time.Time:
+		*d = DateOf(v.UTC())
+	case string:
+		parsed, err := ParseDate(v)
+		if err != nil {
+			return err
+		}
+		*d = parsed
+	case []byte:
+		parsed, err := ParseDate(string(v))
+		if err != nil {
+			return err
+		}
+		*d = parsed
+	default:
+		return fmt.Errorf("cannot scan %T into Date", src)
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    Date
+		wantErr bool
+	}{
+		{"2025-06-10", NewDate(2025, time.June, 10), false},
+		{"2024-02-29", NewDate(2024, time.February, 29), false},
+		{"2025-06-10T00:00:00Z", NewDate(2025, time.June, 10), false},      // старый фронтенд
+		{"2025-06-10T23:30:00+03:00", NewDate(2025, time.June, 10), false}, // берётся только дата
+		{"2025-02-29", Date{}, true},
+		{"10.06.2025", Date{}, true},
+		{"2025-6-10", Date{}, true},
+		{"", Date{}, true},
+	}
+	for _, c := range cases {
+		got, err := ParseDate(c.in)
+		if (err != nil) != c.wantErr {
+			t.Errorf("ParseDate(%q) error = %v, want error %v", c.in, err, c.wantErr)
+			continue
+		}
+		if !got.Equal(c.want) {
+			t.Errorf("ParseDate(%q) = %s, want %s", c.in, got, c.want)
+		}
+	}
+}
+
+// Value → Scan возвращает ту же дату, в каком бы виде её ни отдал драйвер.
+func TestDateScanValue(t *testing.T) {
+	nicosia, err := time.LoadLocation("Asia/Nicosia")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := NewDate(2025, time.March, 30)
+	v, err := d.Value()
+	if err != nil || v != "2025-03-30" {
+		t.Fatalf("Value() = %v, %v, want \"2025-03-30\"", v, err)
+	}
+
+	cases := []struct {
+		name string
+		src  interface{}
+		want Date
+	}{
+		{"value", v, d},
+		{"bytes", []byte("2025-03-30"), d},
+		{"time UTC", time.Date(2025, time.March, 30, 0, 0, 0, 0, time.UTC), d},
+		{"time with zone", time.Date(2025, time.March, 30, 1, 0, 0, 0, nicosia), NewDate(2025, time.March, 29)}, // 23:00 UTC
+		{"nil", nil, Date{}},
+	}
+	for _, c := range cases {
+		var got Date
+		if err := got.Scan(c.src); err != nil {
+			t.Errorf("%s: Scan error %v", c.name, err)
+			continue
+		}
+		if !got.Equal(c.want) {
+			t.Errorf("%s: Scan = %s, want %s", c.name, got, c.want)
+		}
+	}
+
+	if v, err := (Date{}).Value(); err != nil || v != nil {
+		t.Errorf("zero Value() = %v, %v, want nil", v, err)
+	}
+	var bad Date
+	if err := bad.Scan(42); err == nil {
+		t.Error("Scan(42): want error")
+	}
+	if err := bad.Scan("not a date"); err == nil {
+		t.Error(`Scan("not a date"): want error`)
+	}
+}
+
+func TestDateJSON(t *testing.T) {
+	type wrapper struct {
+		Date *Date `json:"date"`
+		Day  Date  `json:"day"`
+	}
+	d := NewDate(2025, time.October, 26)
+	b, err := json.Marshal(wrapper{Date: &d, Day: d})
+	if err != nil || string(b) != `{"date":"2025-10-26","day":"2025-10-26"}` {
+		t.Fatalf("Marshal = %s, %v", b, err)
+	}
+	if b, _ := json.Marshal(Date{}); string(b) != "null" {
+		t.Errorf("zero date marshals to %s, want null", b)
+	}
+
+	cases := []struct {
+		in      string
+		want    Date
+		wantErr bool
+	}{
+		{`"2025-10-26"`, d, false},
+		{`"2025-10-26T00:00:00Z"`, d, false},
+		{`""`, Date{}, false},
+		{`"26.10.2025"`, Date{}, true},
+		{`20251026`, Date{}, true},
+	}
+	for _, c := range cases {
+		var got Date
+		err := json.Unmarshal([]byte(c.in), &got)
+		if (err != nil) != c.wantErr {
+			t.Errorf("Unmarshal(%s) error = %v, want error %v", c.in, err, c.wantErr)
+			continue
+		}
+		if !got.Equal(c.want) {
+			t.Errorf("Unmarshal(%s) = %s, want %s", c.in, got, c.want)
+		}
+	}
+
+	var w wrapper
+	if err := json.Unmarshal(b, &w); err != nil || w.Date == nil || !w.Date.Equal(d) || !w.Day.Equal(d) {
+		t.Errorf("round trip: %+v, %v", w, err)
+	}
+}
+
+// Дата не зависит от часового пояса: переход на летнее и зимнее время не сдвигает дни.
+func TestAddDaysAcrossDST(t *testing.T) {
+	nicosia, err := time.LoadLocation("Asia/Nicosia")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		from Date
+		days int
+		want Date
+	}{
+		{NewDate(2025, time.March, 29), 1, NewDate(2025, time.March, 30)}, // переход на летнее время
+		{NewDate(2025, time.March, 30), 1, NewDate(2025, time.March, 31)},
+		{NewDate(2025, time.March, 25), 7, NewDate(2025, time.April, 1)},
+		{NewDate(2025, time.October, 25), 1, NewDate(2025, time.October, 26)}, // переход на зимнее время
+		{NewDate(2025, time.October, 26), 1, NewDate(2025, time.October, 27)},
+		{NewDate(2025, time.October, 27), -7, NewDate(2025, time.October, 20)},
+		{NewDate(2025, time.January, 1), 365, NewDate(2026, time.January, 1)},
+	}
+	for _, c := range cases {
+		got := c.from.AddDays(c.days)
+		if !got.Equal(c.want) {
+			t.Errorf("%s + %d days = %s, want %s", c.from, c.days, got, c.want)
+		}
+		if n := got.DaysSince(c.from); n != c.days {
+			t.Errorf("%s since %s = %d days, want %d", got, c.from, n, c.days)
+		}
+		// Начала дней в местной зоне отстоят на 23 или 25 часов, но дата остаётся той же
+		if back := DateOf(got.In(nicosia)); !back.Equal(got) {
+			t.Errorf("DateOf(%s in Nicosia) = %s", got, back)
+		}
+	}
+}
+
+// В Никосии (UTC+2 зимой, UTC+3 летом) новые сутки наступают в 22:00 или 21:00 UTC.
+func TestOrganizationDateAt(t *testing.T) {
+	org := Organization{Timezone: "Asia/Nicosia"}
+	cases := []struct {
+		now  time.Time
+		want Date
+	}{
+		{time.Date(2025, time.June, 10, 20, 59, 0, 0, time.UTC), NewDate(2025, time.June, 10)},
+		{time.Date(2025, time.June, 10, 21, 0, 0, 0, time.UTC), NewDate(2025, time.June, 11)},
+		{time.Date(2025, time.June, 11, 0, 0, 0, 0, time.UTC), NewDate(2025, time.June, 11)},
+		{time.Date(2025, time.June, 11, 2, 59, 0, 0, time.UTC), NewDate(2025, time.June, 11)},
+		{time.Date(2025, time.January, 10, 21, 59, 0, 0, time.UTC), NewDate(2025, time.January, 10)},
+		{time.Date(2025, time.January, 10, 22, 0, 0, 0, time.UTC), NewDate(2025, time.January, 11)},
+		{time.Date(2025, time.January, 11, 1, 30, 0, 0, time.UTC), NewDate(2025, time.January, 11)},
+		{time.Date(2025, time.March, 30, 0, 30, 0, 0, time.UTC), NewDate(2025, time.March, 30)},     // 02:30 EET, до перевода часов
+		{time.Date(2025, time.October, 26, 0, 30, 0, 0, time.UTC), NewDate(2025, time.October, 26)}, // 03:30 EEST, до перевода часов
+	}
+	for _, c := range cases {
+		if got := org.DateAt(c.now); !got.Equal(c.want) {
+			t.Errorf("DateAt(%s) = %s, want %s", c.now.Format(time.RFC3339), got, c.want)
+		}
+	}
+
+	// Без зоны или с неизвестной зоной — UTC
+	utcNow := time.Date(2025, time.June, 10, 23, 0, 0, 0, time.UTC)
+	for _, tz := range []string{"", "Mars/Olympus"} {
+		if got := (Organization{Timezone: tz}).DateAt(utcNow); !got.Equal(NewDate(2025, time.June, 10)) {
+			t.Errorf("timezone %q: DateAt = %s, want 2025-06-10", tz, got)
+		}
+	}
+}
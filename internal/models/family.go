@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// FamilyMember — член семьи, для которого готовят: возраст и ограничения питания.
+// Списки хранятся через запятую, как Recipe.Tags. Аллергия — группа аллергенов
+// (nuts, milk, eggs, ...) или название продукта; диеты — известные теги
+// (vegetarian, no-nuts, no-added-sugar, ...), см. пакет diet.
+type FamilyMember struct {
+	ID                  uint      `gorm:"primaryKey" json:"id"`
+	OrganizationID      uint      `gorm:"not null;uniqueIndex:idx_family_member_org_name" json:"organization_id"`
+	Name                string    `gorm:"not null;uniqueIndex:idx_family_member_org_name" json:"name"`
+	BirthDate           *Date     `json:"birth_date,omitempty"`
+	Allergies           string    `gorm:"type:text" json:"allergies"`            // "nuts, milk, клубника"
+	DietaryTags         string    `gorm:"type:text" json:"dietary_tags"`         // "vegetarian, no-added-sugar"
+	DislikedIngredients string    `gorm:"type:text" json:"disliked_ingredients"` // "лук, кинза"
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+// Реакции на первое знакомство с аллергеном.
+const (
+	ReactionNone   = "none"
+	ReactionMild   = "mild"
+	ReactionSevere = "severe" // аллерген больше не назначается
+)
+
+// AllergenIntroduction — первое знакомство ребёнка с аллергеном: когда, с каким рецептом
+// и какая была реакция. На ребёнка и аллерген — одна запись. Reaction пуст, пока не записана.
+type AllergenIntroduction struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	OrganizationID uint      `gorm:"index;not null" json:"organization_id"`
+	FamilyMemberID uint      `gorm:"not null;uniqueIndex:idx_introduction_member_allergen" json:"family_member_id"`
+	Allergen       string    `gorm:"not null;uniqueIndex:idx_introduction_member_allergen" json:"allergen"`
+	IntroducedOn   Date      `gorm:"not null;index" json:"introduced_on"`
+	RecipeID       *uint     `json:"recipe_id,omitempty"`
+	ScheduleTaskID *uint     `json:"schedule_task_id,omitempty"` // meal-задача, при выполнении которой записано
+	Reaction       string    `json:"reaction"`                   // none, mild, severe
+	Notes          string    `gorm:"type:text" json:"notes"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
@@ -49,10 +49,33 @@ type Organization struct {
 	ID          uint      `gorm:"primaryKey" json:"id"`
 	Name        string    `gorm:"not null" json:"name"`
 	OwnerUserID uint      `gorm:"index" json:"owner_user_id"`
+	Timezone    string    `gorm:"not null;default:'UTC'" json:"timezone"` // IANA-зона, напр. "Asia/Nicosia"; от неё считается «сегодня»
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
+// Location возвращает часовой пояс организации (UTC, если зона не задана или некорректна).
+func (o Organization) Location() *time.Location {
+	if o.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(o.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Today возвращает текущую календарную дату в часовом поясе организации.
+func (o Organization) Today() Date {
+	return o.DateAt(time.Now())
+}
+
+// DateAt возвращает календарную дату момента now в часовом поясе организации.
+func (o Organization) DateAt(now time.Time) Date {
+	return DateOf(now.In(o.Location()))
+}
+
 // Membership — связь User ↔ Organization с ролью. Через неё скоупятся все данные и проверяются права.
 type Membership struct {
 	ID             uint             `gorm:"primaryKey" json:"id"`
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Ingredient — продукт из справочника организации (мука, молоко, ...).
+// Имя уникально в пределах организации без учёта регистра (NormalizedName).
+type Ingredient struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	OrganizationID uint      `gorm:"not null;uniqueIndex:idx_ingredient_org_name" json:"organization_id"`
+	Name           string    `gorm:"not null" json:"name"`
+	NormalizedName string    `gorm:"not null;uniqueIndex:idx_ingredient_org_name" json:"-"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// RecipeIngredient — строка состава рецепта: продукт, количество, единица и примечание.
+// Quantity пуст для «по вкусу» (соль, специи). RawText хранит исходную строку свободного текста;
+// NeedsReview помечает строки, которые парсер не смог уверенно разобрать.
+type RecipeIngredient struct {
+	ID           uint        `gorm:"primaryKey" json:"id"`
+	RecipeID     uint        `gorm:"index;not null" json:"recipe_id"`
+	IngredientID *uint       `gorm:"index" json:"ingredient_id,omitempty"`
+	Position     int         `gorm:"not null;default:0" json:"position"`
+	Name         string      `gorm:"not null" json:"name"` // как написано в рецепте
+	Quantity     *float64    `json:"quantity"`
+	Unit         string      `json:"unit"`
+	Note         string      `json:"note"`
+	RawText      string      `json:"raw_text,omitempty"`
+	NeedsReview  bool        `gorm:"default:false" json:"needs_review"`
+	CreatedAt    time.Time   `json:"created_at"`
+	UpdatedAt    time.Time   `json:"updated_at"`
+	Ingredient   *Ingredient `gorm:"foreignKey:IngredientID" json:"ingredient,omitempty"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// JobRunStatus — состояние запуска фоновой задачи.
+type JobRunStatus string
+
+const (
+	JobRunRunning   JobRunStatus = "running"
+	JobRunSucceeded JobRunStatus = "succeeded"
+	JobRunFailed    JobRunStatus = "failed"
+	JobRunSkipped   JobRunStatus = "skipped"
+)
+
+// JobRun — запись истории запуска фоновой задачи (cron-раннер в internal/jobs).
+// OrganizationID пуст для глобальных задач.
+type JobRun struct {
+	ID             uint         `gorm:"primaryKey" json:"id"`
+	JobName        string       `gorm:"index;not null" json:"job_name"`
+	OrganizationID *uint        `gorm:"index" json:"organization_id,omitempty"`
+	ScheduledFor   time.Time    `json:"scheduled_for"` // минута расписания, на которую пришёлся запуск
+	StartedAt      time.Time    `json:"started_at"`
+	FinishedAt     *time.Time   `json:"finished_at,omitempty"`
+	DurationMs     int64        `json:"duration_ms"`
+	Status         JobRunStatus `gorm:"index;not null" json:"status"`
+	Error          string       `gorm:"type:text" json:"error,omitempty"`
+	Host           string       `json:"host"` // реплика, выполнившая задачу
+	CreatedAt      time.Time    `json:"created_at"`
+}
@@ -10,7 +10,7 @@ type Recipe struct {
 	OrganizationID uint      `gorm:"index;not null;default:1" json:"organization_id"`
 	Name           string    `gorm:"not null" json:"name"`
 	Description  string    `json:"description"`
-	Ingredients  string    `gorm:"type:text" json:"ingredients"` // JSON or text list
+	Ingredients  string    `gorm:"type:text" json:"ingredients"` // legacy free-text list, kept in sync with RecipeIngredients
 	Instructions string    `gorm:"type:text" json:"instructions"`
 	PrepTime     int       `json:"prep_time"` // in minutes
 	CookTime     int       `json:"cook_time"` // in minutes
@@ -18,6 +18,8 @@ type Recipe struct {
 	Category     string    `json:"category,omitempty"` // DEPRECATED: use MealTimes relation instead
 	FamilyMember string    `json:"family_member"` // all, adult, baby, specific person
 	Tags         string    `json:"tags"` // comma-separated tags
+	MinAgeMonths int       `gorm:"default:0" json:"min_age_months"` // baby food: youngest age in months it suits; 0 = any age
+	Allergens    string    `json:"allergens"` // comma-separated allergens a baby is introduced to (milk, eggs, ...); also detected from ingredients
 	ImageURL     string    `json:"image_url"` // URL to recipe image
 	VideoURL     string    `json:"video_url"` // URL to recipe video
 	Rating       float64   `gorm:"default:0" json:"rating"` // 0-5 stars
@@ -27,6 +29,9 @@ type Recipe struct {
 
 	// Relations
 	MealTimes []MealTime `gorm:"many2many:recipe_meal_times;" json:"meal_times,omitempty"` // multiple meal types for this recipe
+	RecipeIngredients []RecipeIngredient `gorm:"foreignKey:RecipeID" json:"recipe_ingredients,omitempty"` // structured ingredients, ordered by position
+
+	Nutrition *RecipeNutrition `gorm:"-" json:"nutrition,omitempty"` // per-serving estimate, filled on read (see package nutrition)
 }
 
 // MealTime represents configured meal times
@@ -36,13 +41,22 @@ type MealTime struct {
 	Name           string    `gorm:"not null" json:"name"` // breakfast, lunch, dinner, snack1, babyfood, etc
 	DefaultTime  string    `gorm:"not null" json:"default_time"` // HH:MM format (primary time, kept for backward compatibility)
 	DefaultTimes string    `gorm:"type:text" json:"default_times"` // JSON array of times ["09:00", "12:00", "15:00"]
-	FamilyMember string    `json:"family_member"` // who this meal is for
+	FamilyMember string    `json:"family_member"` // who this meal is for: all, adult, child, baby or member names (used when Members is empty)
+	Headcount    int       `gorm:"default:0" json:"headcount"` // how many people eat this meal; 0 = as many as the recipe serves
+	Strategy       string `json:"strategy"`                         // recipe selection strategy (see scheduler.Strategies); empty = weighted_rotation
+	StrategyParams string `gorm:"type:text" json:"strategy_params"` // JSON parameters of the strategy
 	Active       bool      `gorm:"default:true" json:"active"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 
 	// Relations
 	Recipes []Recipe `gorm:"many2many:recipe_meal_times;" json:"recipes,omitempty"` // recipes for this meal type
+	Members []FamilyMember `gorm:"many2many:meal_time_members;" json:"members,omitempty"` // who eats this meal; empty = resolved from FamilyMember
+}
+
+// TaskTitle is the title of the meal tasks generated for this meal time, e.g. "Breakfast - adult"
+func (m MealTime) TaskTitle() string {
+	return m.Name + " - " + m.FamilyMember
 }
 
 // CleaningZone represents a zone in the house that needs cleaning
@@ -53,28 +67,42 @@ type CleaningZone struct {
 	Description     string    `json:"description"`
 	FrequencyPerWeek int      `gorm:"not null" json:"frequency_per_week"` // how many times per week
 	Priority        string    `gorm:"default:'medium'" json:"priority"` // high, medium, low
+	IntervalDays    int       `gorm:"default:0" json:"interval_days"` // target days between cleanings; 0 = 7 / frequency_per_week
+	LastCleanedOn   *Date     `json:"last_cleaned_on,omitempty"` // set by an admin for a cleaning done outside the schedule; completed tasks count on their own
+	DurationMinutes int       `gorm:"default:30" json:"duration_minutes"` // how long one cleaning takes
+	PreferredTime   string    `json:"preferred_time"` // when to clean: "09:00-12:00", "morning", "after dinner", "before lunch"; empty = first free slot
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
 }
 
-// ChildcareSchedule represents daily childcare times (manually added each day)
+// ChildcareSchedule represents a childcare block on a date, added manually or expanded from a
+// ChildcarePattern. A day can have several blocks.
 type ChildcareSchedule struct {
 	ID             uint      `gorm:"primaryKey" json:"id"`
 	OrganizationID uint      `gorm:"index;not null;default:1" json:"organization_id"`
-	Date           time.Time `gorm:"not null;index" json:"date"` // date for this schedule
+	Date           Date      `gorm:"not null;index" json:"date"` // date for this schedule
 	StartTime   string    `gorm:"not null" json:"start_time"` // HH:MM format
 	EndTime     string    `gorm:"not null" json:"end_time"`   // HH:MM format
 	Notes       string    `json:"notes"`
+	PatternID   *uint     `gorm:"index" json:"pattern_id,omitempty"` // weekly pattern the block was expanded from
+	OriginDate  *Date     `gorm:"index" json:"origin_date,omitempty"` // date the pattern was expanded for; differs from Date once the block is moved
+	Edited      bool      `gorm:"default:false" json:"edited"` // a pattern block changed manually; kept when the pattern changes
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	Children []FamilyMember `gorm:"many2many:childcare_schedule_children;" json:"children,omitempty"` // children the block covers
 }
 
 // DailySchedule represents the generated daily schedule for the helper
 type DailySchedule struct {
 	ID             uint      `gorm:"primaryKey" json:"id"`
 	OrganizationID uint      `gorm:"index;not null;default:1" json:"organization_id"`
-	Date           time.Time `gorm:"not null;index" json:"date"`
+	Date           Date      `gorm:"not null;index" json:"date"`
 	Generated bool      `gorm:"default:false" json:"generated"` // whether schedule was auto-generated
+	Seed      int64     `gorm:"default:0" json:"seed"`          // salt for random choices; changed only by a reshuffle
+	CapacityMinutes int  `gorm:"default:0" json:"capacity_minutes"` // helpers' working time when generated; 0 = no work profiles
+	PlannedMinutes  int  `gorm:"default:0" json:"planned_minutes"`  // total duration of the tasks when generated
+	Warnings  string    `gorm:"type:text" json:"warnings,omitempty"` // workload warnings for admins, one per line
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	
@@ -101,10 +129,11 @@ type ScheduleTask struct {
 	ID             uint      `gorm:"primaryKey" json:"id"`
 	OrganizationID uint      `gorm:"index;not null;default:1" json:"organization_id"`
 	ScheduleID     uint      `gorm:"not null;index" json:"schedule_id"`
-	TaskType    string    `gorm:"not null" json:"task_type"` // meal, cleaning, childcare, custom (legacy)
-	Time        string    `json:"time"` // HH:MM format (can be empty for flexible tasks like cleaning)
-	EndTime     string    `json:"end_time"` // HH:MM format (for childcare tasks with time range)
+	TaskType    string    `gorm:"not null" json:"task_type"` // meal, cleaning, childcare, recurring, custom (legacy)
+	Time        string    `json:"time"` // HH:MM format (empty for a flexible task that found no free slot)
+	EndTime     string    `json:"end_time"` // HH:MM format (childcare blocks and placed flexible tasks like cleaning)
 	Duration    int       `json:"duration"` // in minutes
+	Servings    int       `gorm:"default:0" json:"servings,omitempty"` // meal tasks: portions to cook (MealTime.Headcount or Recipe.Servings)
 	Title       string    `gorm:"not null" json:"title"`
 	Description string    `json:"description"`
 	RecipeID    *uint     `json:"recipe_id,omitempty"` // if task_type is meal (deprecated, use Recipes relation)
@@ -112,7 +141,14 @@ type ScheduleTask struct {
 	// M2: категория и назначение
 	TaskCategoryID     *uint `gorm:"index" json:"task_category_id,omitempty"`
 	AssignedToUserID   *uint `gorm:"index" json:"assigned_to_user_id,omitempty"`
+	RecurringTaskID    *uint `gorm:"index" json:"recurring_task_id,omitempty"` // occurrence of a recurring task series
+	OccurrenceDate     *Date `gorm:"index" json:"occurrence_date,omitempty"` // recurring tasks: series date the task stands for; differs from the schedule date once moved
+	MealTimeID         *uint  `gorm:"index" json:"meal_time_id,omitempty"` // meal tasks: the meal time that generated the task
+	MealSlot           string `json:"meal_slot,omitempty"` // meal tasks: generated time slot (HH:MM); stays when the time is edited
 	Completed   bool      `gorm:"default:false" json:"completed"`
+	Edited      bool      `gorm:"default:false" json:"edited"` // changed manually by an admin; kept on regeneration
+	Locked      bool      `gorm:"default:false" json:"locked"` // pinned in the menu planner; kept on regeneration and not changed by bulk edits
+	StockDeducted bool    `gorm:"default:false" json:"stock_deducted"` // meal ingredients were taken from the pantry on completion
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 
@@ -132,9 +168,15 @@ type ShoppingListItem struct {
 	Quantity    string    `json:"quantity"`
 	Category    string    `json:"category"` // produce, dairy, meat, etc
 	Purchased   bool      `gorm:"default:false" json:"purchased"`
-	AddedBy     string    `json:"added_by"` // admin or helper
+	AddedBy     string    `json:"added_by"` // admin, helper or generator
+	IngredientID *uint    `gorm:"index" json:"ingredient_id,omitempty"` // catalog ingredient for generated items
+	Amount      *float64  `json:"amount,omitempty"`                     // numeric total of Quantity when known
+	Unit        string    `json:"unit,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Relations
+	Sources []ShoppingItemSource `gorm:"foreignKey:ShoppingListItemID;constraint:OnDelete:SET NULL" json:"sources,omitempty"`
 }
 
 // Settings represents per-organisation system settings
@@ -151,7 +193,7 @@ type Settings struct {
 type Holiday struct {
 	ID          uint      `gorm:"primaryKey" json:"id"`
 	Name        string    `gorm:"not null" json:"name"`
-	Date        time.Time `gorm:"not null" json:"date"` // Specific date
+	Date        Date      `gorm:"not null" json:"date"` // Specific date
 	IsRecurring bool      `gorm:"default:true" json:"is_recurring"` // Repeats every year
 	Country     string    `json:"country"` // e.g., "Cyprus"
 	CreatedAt   time.Time `json:"created_at"`
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Nutrition — пищевая ценность: калории, белки, жиры, углеводы и клетчатка (граммы).
+type Nutrition struct {
+	Kcal    float64 `json:"kcal"`
+	Protein float64 `json:"protein"`
+	Fat     float64 `json:"fat"`
+	Carbs   float64 `json:"carbs"`
+	Fiber   float64 `json:"fiber"`
+}
+
+// RecipeNutrition — оценка пищевой ценности порции рецепта по таблице продуктов.
+// Unmatched — строки состава, которые не удалось оценить (нет в таблице или единица
+// не переводится в граммы); оценка без них занижена.
+type RecipeNutrition struct {
+	PerServing Nutrition `json:"per_serving"`
+	Servings   int       `json:"servings"`
+	Unmatched  []string  `json:"unmatched,omitempty"`
+}
+
+// NutritionFact — строка таблицы пищевой ценности организации: правка или дополнение
+// к таблице, поставляемой с проектом (пакет nutrition). Значения — на 100 г продукта.
+type NutritionFact struct {
+	ID             uint   `gorm:"primaryKey" json:"id"`
+	OrganizationID uint   `gorm:"not null;uniqueIndex:idx_nutrition_org_name" json:"organization_id"`
+	Name           string `gorm:"not null" json:"name"`
+	NormalizedName string `gorm:"not null;uniqueIndex:idx_nutrition_org_name" json:"-"`
+	Aliases        string `gorm:"type:text" json:"aliases"` // другие названия через запятую
+	Nutrition      `gorm:"embedded"`
+	PieceGrams     float64   `json:"piece_grams"` // вес штуки (зубчика, ломтика); 0 — штуками не оценивается
+	Density        float64   `json:"density"`     // г/мл для объёмных единиц; 0 — как вода
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// PantryItem — запас продукта дома: сколько, где лежит и до какого числа годен.
+// Один продукт может лежать несколькими строками (разные места, сроки годности);
+// списание идёт с тех, что испортятся раньше.
+type PantryItem struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	OrganizationID uint      `gorm:"index;not null" json:"organization_id"`
+	IngredientID   *uint     `gorm:"index" json:"ingredient_id,omitempty"`
+	Name           string    `gorm:"not null" json:"name"`
+	Quantity       *float64  `json:"quantity"` // nil — количество неизвестно («есть пачка»)
+	Unit           string    `json:"unit"`
+	Location       string    `json:"location"` // fridge, freezer, pantry, ...
+	ExpiresOn      *Date     `gorm:"index" json:"expires_on,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+
+	Ingredient *Ingredient `gorm:"foreignKey:IngredientID" json:"ingredient,omitempty"`
+}
+
+// PantryDeduction — что списано из строки запаса при выполнении meal-задачи. По этим строкам
+// запас восстанавливается, если отметку о выполнении снимают.
+type PantryDeduction struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	OrganizationID uint      `gorm:"index;not null" json:"organization_id"`
+	TaskID         uint      `gorm:"index;not null" json:"task_id"`
+	IngredientID   *uint     `json:"ingredient_id,omitempty"`
+	Name           string    `gorm:"not null" json:"name"`
+	Quantity       float64   `json:"quantity"` // в единице строки запаса
+	Unit           string    `json:"unit"`
+	Location       string    `json:"location"`
+	ExpiresOn      *Date     `json:"expires_on,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
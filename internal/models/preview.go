@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// PreviewStatus — состояние предпросмотра перегенерации.
+type PreviewStatus string
+
+const (
+	PreviewPending  PreviewStatus = "pending"
+	PreviewAccepted PreviewStatus = "accepted"
+	PreviewRejected PreviewStatus = "rejected"
+	PreviewExpired  PreviewStatus = "expired"
+)
+
+// SchedulePreview — сохранённый предпросмотр перегенерации расписания (dry-run).
+// Payload хранит предложенные расписания и причины решений генератора (scheduler.Preview в JSON);
+// админ принимает или отклоняет предпросмотр целиком.
+type SchedulePreview struct {
+	ID              uint          `gorm:"primaryKey" json:"id"`
+	OrganizationID  uint          `gorm:"index;not null" json:"organization_id"`
+	CreatedByUserID uint          `json:"created_by_user_id"`
+	DateFrom        Date          `gorm:"not null" json:"date_from"`
+	DateTo          Date          `gorm:"not null" json:"date_to"` // не включительно
+	TaskTypes       string        `json:"task_types"`              // через запятую
+	Status          PreviewStatus `gorm:"index;not null;default:'pending'" json:"status"`
+	Payload         string        `gorm:"type:text" json:"-"`
+	Fingerprint     string        `json:"-"` // хэш задач диапазона на момент предпросмотра
+	ExpiresAt       time.Time     `json:"expires_at"`
+	DecidedByUserID *uint         `json:"decided_by_user_id,omitempty"`
+	DecidedAt       *time.Time    `json:"decided_at,omitempty"`
+	CreatedAt       time.Time     `json:"created_at"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// RecurringTask — повторяющаяся задача: правило повторения в формате iCalendar RRULE
+// ("FREQ=WEEKLY;BYDAY=TU,FR"), начало серии, время и длительность. Планировщик разворачивает
+// серию в задачи расписания (см. пакет recurrence); ExDates — пропущенные повторения.
+type RecurringTask struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	OrganizationID   uint      `gorm:"index;not null" json:"organization_id"`
+	Title            string    `gorm:"not null" json:"title"`
+	Description      string    `json:"description"`
+	RRule            string    `gorm:"column:rrule;not null" json:"rrule"`
+	StartDate        Date      `gorm:"not null" json:"start_date"` // DTSTART
+	Time             string    `json:"time"`                       // HH:MM; пусто — гибкая задача, время подбирается по свободным промежуткам
+	Duration         int       `gorm:"default:30" json:"duration"` // в минутах
+	ExDates          string    `gorm:"type:text" json:"exdates"`   // "2025-06-10,2025-06-24"
+	TaskCategoryID   *uint     `gorm:"index" json:"task_category_id,omitempty"`
+	AssignedToUserID *uint     `gorm:"index" json:"assigned_to_user_id,omitempty"`
+	Active           bool      `gorm:"default:true" json:"active"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+
+	TaskCategory *TaskCategory `gorm:"foreignKey:TaskCategoryID" json:"task_category,omitempty"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// ShoppingItemSource — вклад одного рецепта запланированного приёма пищи в позицию списка покупок.
+// Уникальный ключ (день, слот приёма пищи, рецепт, продукт, единица) не даёт генератору посчитать
+// одну потребность дважды: повторный запуск и перегенерация расписания с тем же рецептом ничего
+// не добавляют. После удаления позиции ShoppingListItemID обнуляется, а источник продолжает
+// помечать потребность учтённой.
+type ShoppingItemSource struct {
+	ID                 uint      `gorm:"primaryKey" json:"id"`
+	OrganizationID     uint      `gorm:"not null;uniqueIndex:idx_shopping_source" json:"organization_id"`
+	ShoppingListItemID *uint     `gorm:"index" json:"shopping_list_item_id"`
+	Date               Date      `gorm:"not null;uniqueIndex:idx_shopping_source" json:"date"`
+	Slot               string    `gorm:"not null;uniqueIndex:idx_shopping_source" json:"slot"` // заголовок meal-задачи
+	RecipeID           uint      `gorm:"not null;uniqueIndex:idx_shopping_source" json:"recipe_id"`
+	IngredientID       uint      `gorm:"not null;uniqueIndex:idx_shopping_source" json:"ingredient_id"`
+	Unit               string    `gorm:"not null;default:'';uniqueIndex:idx_shopping_source" json:"unit"`
+	ScheduleTaskID     uint      `gorm:"index" json:"schedule_task_id"`
+	RecipeName         string    `json:"recipe_name"`
+	Quantity           *float64  `json:"quantity"` // nil — «по вкусу»
+	CreatedAt          time.Time `json:"created_at"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// WorkProfile — рабочие часы помощника в организации: начало и конец дня и перерывы.
+// Сумма профилей — сколько минут работы помещается в день (см. пакет workload);
+// без профилей объём дня не ограничивается.
+type WorkProfile struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	OrganizationID uint      `gorm:"not null;uniqueIndex:idx_work_profile_org_user" json:"organization_id"`
+	UserID         uint      `gorm:"not null;uniqueIndex:idx_work_profile_org_user" json:"user_id"`
+	StartTime      string    `gorm:"not null" json:"start_time"` // HH:MM
+	EndTime        string    `gorm:"not null" json:"end_time"`   // HH:MM
+	Breaks         string    `gorm:"type:text" json:"breaks"`    // "13:00-14:00, 16:30-16:45"
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
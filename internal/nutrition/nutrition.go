@@ -0,0 +1,336 @@
+// Package nutrition — приблизительная пищевая ценность рецептов и дневного меню: калории,
+// белки, жиры, углеводы и клетчатка. Считается по таблице продуктов на 100 г, поставляемой
+// с проектом (table.csv), с правками организации (models.NutritionFact) — без внешних сервисов.
+package nutrition
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"podlevskikh/awesomeProject/internal/ingredients"
+	"podlevskikh/awesomeProject/internal/models"
+	"podlevskikh/awesomeProject/internal/units"
+
+	"gorm.io/gorm"
+)
+
+//go:embed table.csv
+var tableCSV string
+
+// Fact — продукт таблицы: значения на 100 г, вес штуки и плотность.
+type Fact struct {
+	ID         uint             `json:"id,omitempty"` // models.NutritionFact.ID для правок организации
+	Name       string           `json:"name"`
+	Aliases    []string         `json:"aliases"`
+	Per100g    models.Nutrition `json:"per_100g"`
+	PieceGrams float64          `json:"piece_grams"`
+	Density    float64          `json:"density"`
+	Custom     bool             `json:"custom"` // правка или дополнение организации
+}
+
+var (
+	bundledOnce sync.Once
+	bundled     []Fact
+	bundledErr  error
+)
+
+// Bundled возвращает таблицу, поставляемую с проектом.
+func Bundled() ([]Fact, error) {
+	bundledOnce.Do(func() {
+		bundled, bundledErr = parseTable(tableCSV)
+	})
+	return bundled, bundledErr
+}
+
+func parseTable(text string) ([]Fact, error) {
+	r := csv.NewReader(strings.NewReader(text))
+	r.Comma = ';'
+	r.Comment = '#'
+	r.FieldsPerRecord = 9
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("nutrition table: %w", err)
+	}
+	facts := make([]Fact, 0, len(records))
+	for _, rec := range records {
+		var nums [7]float64
+		for i, s := range rec[2:] {
+			if s == "" {
+				continue
+			}
+			if nums[i], err = strconv.ParseFloat(s, 64); err != nil {
+				return nil, fmt.Errorf("nutrition table: %s: %w", rec[0], err)
+			}
+		}
+		var aliases []string
+		if rec[1] != "" {
+			aliases = strings.Split(rec[1], "|")
+		}
+		facts = append(facts, Fact{
+			Name:       rec[0],
+			Aliases:    aliases,
+			Per100g:    models.Nutrition{Kcal: nums[0], Protein: nums[1], Fat: nums[2], Carbs: nums[3], Fiber: nums[4]},
+			PieceGrams: nums[5],
+			Density:    nums[6],
+		})
+	}
+	return facts, nil
+}
+
+// FromModel переводит правку организации в продукт таблицы.
+func FromModel(f models.NutritionFact) Fact {
+	return Fact{
+		ID:         f.ID,
+		Name:       f.Name,
+		Aliases:    List(f.Aliases),
+		Per100g:    f.Nutrition,
+		PieceGrams: f.PieceGrams,
+		Density:    f.Density,
+		Custom:     true,
+	}
+}
+
+// List разбирает список названий через запятую.
+func List(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// Key — ключ продукта: нижний регистр, ё→е, одиночные пробелы.
+func Key(name string) string {
+	return strings.ReplaceAll(ingredients.NormalizeName(name), "ё", "е")
+}
+
+// Table — таблица продуктов организации: поставляемая с правками поверх.
+type Table struct {
+	facts []Fact
+	keys  []key
+}
+
+type key struct {
+	words []string
+	fact  int
+}
+
+// NewTable собирает таблицу: продукты overrides заменяют одноимённые из base.
+func NewTable(base, overrides []Fact) *Table {
+	t := &Table{}
+	index := make(map[string]int)
+	for _, list := range [][]Fact{base, overrides} {
+		for _, f := range list {
+			if i, ok := index[Key(f.Name)]; ok {
+				t.facts[i] = f
+				continue
+			}
+			index[Key(f.Name)] = len(t.facts)
+			t.facts = append(t.facts, f)
+		}
+	}
+	for i, f := range t.facts {
+		for _, name := range append([]string{f.Name}, f.Aliases...) {
+			if words := tokens(name); len(words) > 0 {
+				t.keys = append(t.keys, key{words: words, fact: i})
+			}
+		}
+	}
+	return t
+}
+
+// Load возвращает таблицу организации.
+func Load(db *gorm.DB, orgID uint) (*Table, error) {
+	base, err := Bundled()
+	if err != nil {
+		return nil, err
+	}
+	var rows []models.NutritionFact
+	if err := db.Where("organization_id = ?", orgID).Order("id").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load nutrition facts: %w", err)
+	}
+	overrides := make([]Fact, len(rows))
+	for i, row := range rows {
+		overrides[i] = FromModel(row)
+	}
+	return NewTable(base, overrides), nil
+}
+
+// Facts возвращает продукты таблицы по названию.
+func (t *Table) Facts() []Fact {
+	out := append([]Fact(nil), t.facts...)
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Lookup находит продукт для названия из рецепта. Слова названия продукта должны идти
+// подряд в начале слов строки ("лук" находит "лука репчатого"); из подходящих берётся
+// самое точное: больше совпавших целиком слов, затем длиннее название.
+func (t *Table) Lookup(name string) (Fact, bool) {
+	words := tokens(name)
+	best, bestScore := -1, 0
+	for _, k := range t.keys {
+		exact, ok := match(words, k.words)
+		if !ok {
+			continue
+		}
+		score := exact*1000 + len(strings.Join(k.words, " "))
+		if score > bestScore {
+			best, bestScore = k.fact, score
+		}
+	}
+	if best < 0 {
+		return Fact{}, false
+	}
+	return t.facts[best], true
+}
+
+// match ищет want подряд в words (слово строки начинается со слова продукта) и
+// возвращает число совпавших целиком слов.
+func match(words, want []string) (int, bool) {
+	bestExact, found := 0, false
+outer:
+	for i := 0; i+len(want) <= len(words); i++ {
+		exact := 0
+		for j, w := range want {
+			if !strings.HasPrefix(words[i+j], w) {
+				continue outer
+			}
+			if words[i+j] == w {
+				exact++
+			}
+		}
+		if !found || exact > bestExact {
+			bestExact, found = exact, true
+		}
+	}
+	return bestExact, found
+}
+
+func tokens(s string) []string {
+	return strings.FieldsFunc(Key(s), func(r rune) bool { return !unicode.IsLetter(r) })
+}
+
+// Grams переводит количество продукта в граммы: массу — напрямую, объём — через плотность
+// (по умолчанию 1 г/мл), штуки и штучные единицы (зубчик, ломтик, пучок) — через вес штуки.
+func (f Fact) Grams(quantity float64, unit string) (float64, bool) {
+	u := units.Lookup(unit)
+	switch {
+	case u.Dimension == units.Mass:
+		return quantity * u.Factor, true
+	case u.Dimension == units.Volume:
+		density := f.Density
+		if density <= 0 {
+			density = 1
+		}
+		return quantity * u.Factor * density, true
+	case u.Code == "" || u.Dimension == units.Count || pieceUnits[u.Code]:
+		if f.PieceGrams <= 0 {
+			return 0, false
+		}
+		return quantity * f.PieceGrams, true
+	}
+	return 0, false
+}
+
+// pieceUnits — штучные единицы без перевода, для которых берётся вес штуки продукта.
+var pieceUnits = map[string]bool{"зубчик": true, "ломтик": true, "пучок": true}
+
+// negligibleUnits — единицы, количество в которых на пищевую ценность не влияет.
+var negligibleUnits = map[string]bool{"щепотка": true}
+
+// Recipe оценивает пищевую ценность порции рецепта. Строки без количества ("соль по вкусу")
+// не учитываются; строки, которые есть в составе, но не оцениваются, попадают в Unmatched.
+// Рецепт без числа порций считается на одну порцию.
+func (t *Table) Recipe(recipe models.Recipe) *models.RecipeNutrition {
+	lines := recipe.RecipeIngredients
+	if len(lines) == 0 && recipe.Ingredients != "" {
+		lines = ingredients.FromText(recipe.Ingredients)
+	}
+	servings := recipe.Servings
+	if servings <= 0 {
+		servings = 1
+	}
+
+	var total models.Nutrition
+	var unmatched []string
+	for _, line := range lines {
+		if line.Quantity == nil || *line.Quantity <= 0 {
+			continue
+		}
+		code, _ := units.Normalize(line.Unit)
+		if negligibleUnits[code] {
+			continue
+		}
+		fact, ok := t.Lookup(line.Name)
+		if !ok && line.Ingredient != nil {
+			fact, ok = t.Lookup(line.Ingredient.Name)
+		}
+		if !ok {
+			unmatched = append(unmatched, line.Name)
+			continue
+		}
+		grams, ok := fact.Grams(*line.Quantity, line.Unit)
+		if !ok {
+			unmatched = append(unmatched, line.Name)
+			continue
+		}
+		total = Add(total, Scale(fact.Per100g, grams/100))
+	}
+	return &models.RecipeNutrition{
+		PerServing: Round(Scale(total, 1/float64(servings))),
+		Servings:   servings,
+		Unmatched:  unmatched,
+	}
+}
+
+// Fill заполняет Recipe.Nutrition у рецептов. Для оценки по составу рецепты должны быть
+// загружены с RecipeIngredients (иначе берётся свободный текст Ingredients).
+func (t *Table) Fill(recipes ...*models.Recipe) {
+	for _, r := range recipes {
+		r.Nutrition = t.Recipe(*r)
+	}
+}
+
+// Add складывает значения.
+func Add(a, b models.Nutrition) models.Nutrition {
+	return models.Nutrition{
+		Kcal:    a.Kcal + b.Kcal,
+		Protein: a.Protein + b.Protein,
+		Fat:     a.Fat + b.Fat,
+		Carbs:   a.Carbs + b.Carbs,
+		Fiber:   a.Fiber + b.Fiber,
+	}
+}
+
+// Scale умножает значения на factor.
+func Scale(n models.Nutrition, factor float64) models.Nutrition {
+	return models.Nutrition{
+		Kcal:    n.Kcal * factor,
+		Protein: n.Protein * factor,
+		Fat:     n.Fat * factor,
+		Carbs:   n.Carbs * factor,
+		Fiber:   n.Fiber * factor,
+	}
+}
+
+// Round округляет калории до целых, граммы — до десятых: точнее оценка всё равно не бывает.
+func Round(n models.Nutrition) models.Nutrition {
+	tenth := func(v float64) float64 { return math.Round(v*10) / 10 }
+	return models.Nutrition{
+		Kcal:    math.Round(n.Kcal),
+		Protein: tenth(n.Protein),
+		Fat:     tenth(n.Fat),
+		Carbs:   tenth(n.Carbs),
+		Fiber:   tenth(n.Fiber),
+	}
+}
@@ -0,0 +1,64 @@
+package nutrition
+
+import (
+	"testing"
+
+	"podlevskikh/awesomeProject/internal/models"
+)
+
+func TestLookup(t *testing.T) {
+	base, err := Bundled()
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := NewTable(base, nil)
+	cases := map[string]string{
+		"Масло сливочное":      "сливочное масло",
+		"Масло":                "растительное масло",
+		"Лука репчатого":       "лук",
+		"Куриное филе":         "куриное филе",
+		"Курица":               "курица",
+		"Перец черный молотый": "черный перец",
+		"Рыба (филе)":          "рыба",
+		"Свёкла":               "свекла",
+		"Овсяные хлопья":       "овсяные хлопья",
+		"Помидоры черри":       "помидоры",
+	}
+	for name, want := range cases {
+		f, ok := table.Lookup(name)
+		if !ok || f.Name != want {
+			t.Errorf("Lookup(%q) = %q, %v; want %q", name, f.Name, ok, want)
+		}
+	}
+	if f, ok := table.Lookup("Кардамон"); ok {
+		t.Errorf("Lookup(Кардамон) = %q, want no match", f.Name)
+	}
+}
+
+func TestRecipe(t *testing.T) {
+	base, err := Bundled()
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipe := models.Recipe{
+		Servings:    2,
+		Ingredients: "Яйца - 3 шт, Хлеб - 2 ломтика, Масло сливочное - 20г, Кардамон - 1 ч.л., Соль, перец",
+	}
+
+	// 3 яйца по 55 г, 2 ломтика хлеба по 30 г и 20 г масла — на две порции; соль и перец без количества
+	got := NewTable(base, nil).Recipe(recipe)
+	want := models.Nutrition{Kcal: 279, Protein: 12.9, Fat: 18.6, Carbs: 15.4, Fiber: 0.9}
+	if got.PerServing != want || got.Servings != 2 {
+		t.Errorf("PerServing = %+v (servings %d), want %+v", got.PerServing, got.Servings, want)
+	}
+	if len(got.Unmatched) != 1 || got.Unmatched[0] != "Кардамон" {
+		t.Errorf("Unmatched = %v, want [Кардамон]", got.Unmatched)
+	}
+
+	// Правка организации перекрывает поставляемое значение
+	override := Fact{Name: "Хлеб", Aliases: []string{"bread"}, Per100g: models.Nutrition{Kcal: 0}, PieceGrams: 30}
+	got = NewTable(base, []Fact{override}).Recipe(recipe)
+	if got.PerServing.Kcal != 204 {
+		t.Errorf("Kcal with override = %v, want 204", got.PerServing.Kcal)
+	}
+}
@@ -0,0 +1,158 @@
+package nutrition
+
+import (
+	"podlevskikh/awesomeProject/internal/diet"
+	"podlevskikh/awesomeProject/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Meal — приём пищи в сводке: одна порция каждого рецепта meal-задачи.
+type Meal struct {
+	TaskID    uint             `json:"task_id"`
+	Time      string           `json:"time"`
+	Title     string           `json:"title"`
+	Recipes   []string         `json:"recipes"`
+	Nutrition models.Nutrition `json:"nutrition"`
+}
+
+// Day — пищевая ценность дня члена семьи.
+type Day struct {
+	Date      models.Date      `json:"date"`
+	Meals     []Meal           `json:"meals"`
+	Nutrition models.Nutrition `json:"nutrition"`
+}
+
+// MemberSummary — сводка по члену семьи за период.
+type MemberSummary struct {
+	MemberID uint             `json:"member_id"`
+	Name     string           `json:"name"`
+	Days     []Day            `json:"days"`
+	Total    models.Nutrition `json:"total"`
+	// DailyAverage — среднее по дням, в которые есть приёмы пищи
+	DailyAverage models.Nutrition `json:"daily_average"`
+	// Incomplete — рецепты, в составе которых есть неоценённые продукты: итог занижен
+	Incomplete []string `json:"incomplete,omitempty"`
+}
+
+// Summary — сводка пищевой ценности меню по членам семьи с from на days дней.
+type Summary struct {
+	From    models.Date     `json:"from"`
+	To      models.Date     `json:"to"`
+	Members []MemberSummary `json:"members"`
+}
+
+// Summarize считает, сколько съедает каждый член семьи по сгенерированным meal-задачам:
+// приём пищи относится к тем, для кого он настроен (diet.MembersFor), каждый получает
+// одну порцию каждого рецепта. Задачи, не связанные с настроенным приёмом пищи, не учитываются.
+func Summarize(db *gorm.DB, orgID uint, from models.Date, days int) (*Summary, error) {
+	to := from.AddDays(days - 1)
+	table, err := Load(db, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	var members []models.FamilyMember
+	if err := db.Where("organization_id = ?", orgID).Order("name").Find(&members).Error; err != nil {
+		return nil, err
+	}
+	var mealTimes []models.MealTime
+	if err := db.Where("organization_id = ?", orgID).Order("id").Find(&mealTimes).Error; err != nil {
+		return nil, err
+	}
+	byTitle := make(map[string]models.MealTime, len(mealTimes))
+	for _, mt := range mealTimes {
+		if _, ok := byTitle[mt.TaskTitle()]; !ok {
+			byTitle[mt.TaskTitle()] = mt
+		}
+	}
+
+	var schedules []models.DailySchedule
+	err = db.Preload("Tasks", "task_type = ?", "meal").
+		Preload("Tasks.Recipes.RecipeIngredients.Ingredient").
+		Preload("Tasks.Recipe.RecipeIngredients.Ingredient").
+		Where("organization_id = ? AND date >= ? AND date <= ?", orgID, from, to).
+		Order("date").
+		Find(&schedules).Error
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]MemberSummary, len(members))
+	index := make(map[uint]int, len(members))
+	for i, m := range members {
+		summaries[i] = MemberSummary{MemberID: m.ID, Name: m.Name, Days: make([]Day, 0, days)}
+		index[m.ID] = i
+		for d := 0; d < days; d++ {
+			summaries[i].Days = append(summaries[i].Days, Day{Date: from.AddDays(d), Meals: []Meal{}})
+		}
+	}
+	incomplete := make(map[uint]map[string]bool)
+
+	for _, schedule := range schedules {
+		d := schedule.Date.DaysSince(from)
+		for _, task := range schedule.Tasks {
+			mt, ok := byTitle[task.Title]
+			if !ok {
+				continue
+			}
+			recipes := task.Recipes
+			if len(recipes) == 0 && task.Recipe != nil {
+				recipes = []models.Recipe{*task.Recipe}
+			}
+			if len(recipes) == 0 {
+				continue
+			}
+			eaters, err := diet.MembersFor(db, mt, schedule.Date)
+			if err != nil {
+				return nil, err
+			}
+
+			meal := Meal{TaskID: task.ID, Time: task.Time, Title: task.Title}
+			var partial []string
+			for _, r := range recipes {
+				est := table.Recipe(r)
+				meal.Recipes = append(meal.Recipes, r.Name)
+				meal.Nutrition = Add(meal.Nutrition, est.PerServing)
+				if len(est.Unmatched) > 0 {
+					partial = append(partial, r.Name)
+				}
+			}
+			for _, m := range eaters {
+				i, ok := index[m.ID]
+				if !ok {
+					continue
+				}
+				day := &summaries[i].Days[d]
+				day.Meals = append(day.Meals, meal)
+				day.Nutrition = Add(day.Nutrition, meal.Nutrition)
+				for _, name := range partial {
+					if incomplete[m.ID] == nil {
+						incomplete[m.ID] = make(map[string]bool)
+					}
+					if !incomplete[m.ID][name] {
+						incomplete[m.ID][name] = true
+						summaries[i].Incomplete = append(summaries[i].Incomplete, name)
+					}
+				}
+			}
+		}
+	}
+
+	for i := range summaries {
+		s := &summaries[i]
+		eaten := 0
+		for j := range s.Days {
+			if len(s.Days[j].Meals) > 0 {
+				eaten++
+			}
+			s.Total = Add(s.Total, s.Days[j].Nutrition)
+			s.Days[j].Nutrition = Round(s.Days[j].Nutrition)
+		}
+		if eaten > 0 {
+			s.DailyAverage = Round(Scale(s.Total, 1/float64(eaten)))
+		}
+		s.Total = Round(s.Total)
+	}
+	return &Summary{From: from, To: to, Members: summaries}, nil
+}
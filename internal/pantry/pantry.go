@@ -0,0 +1,356 @@
+// Package pantry — домашние запасы продуктов: пополнение при покупке, списание при
+// приготовлении и остатки для генератора списка покупок.
+package pantry
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"podlevskikh/awesomeProject/internal/ingredients"
+	"podlevskikh/awesomeProject/internal/models"
+	"podlevskikh/awesomeProject/internal/units"
+
+	"gorm.io/gorm"
+)
+
+// Product — продукт запаса: из справочника (IngredientID) или по названию.
+type Product struct {
+	IngredientID *uint
+	Name         string
+}
+
+// matches — та же позиция запаса: тот же продукт справочника или то же название.
+func (p Product) matches(item models.PantryItem) bool {
+	if p.IngredientID != nil && item.IngredientID != nil {
+		return *p.IngredientID == *item.IngredientID
+	}
+	return ingredients.NormalizeName(p.Name) == ingredients.NormalizeName(item.Name)
+}
+
+// items возвращает все запасы продукта организации, в том числе просроченные.
+func items(db *gorm.DB, orgID uint, p Product) ([]models.PantryItem, error) {
+	q := db.Where("organization_id = ?", orgID)
+	if p.IngredientID != nil {
+		q = q.Where("ingredient_id = ? OR (ingredient_id IS NULL AND LOWER(name) = ?)", *p.IngredientID, ingredients.NormalizeName(p.Name))
+	} else {
+		q = q.Where("LOWER(name) = ?", ingredients.NormalizeName(p.Name))
+	}
+	var list []models.PantryItem
+	if err := q.Order("id").Find(&list).Error; err != nil {
+		return nil, fmt.Errorf("failed to load pantry: %w", err)
+	}
+	var out []models.PantryItem
+	for _, item := range list {
+		if p.matches(item) {
+			out = append(out, item)
+		}
+	}
+	return out, nil
+}
+
+// Add кладёт продукт в запас. Количество добавляется к строке того же продукта с тем же
+// местом и сроком годности в совместимой единице, иначе создаётся новая строка.
+// q == nil — количество неизвестно.
+func Add(db *gorm.DB, orgID uint, p Product, q *units.Quantity, location string, expiresOn *models.Date) (*models.PantryItem, error) {
+	existing, err := items(db, orgID, p)
+	if err != nil {
+		return nil, err
+	}
+	for i := range existing {
+		item := &existing[i]
+		if item.Location != location || !sameDate(item.ExpiresOn, expiresOn) {
+			continue
+		}
+		switch {
+		case q == nil:
+			return item, nil // продукт уже есть, неизвестное количество ничего не меняет
+		case item.Quantity == nil:
+			item.Quantity, item.Unit = &q.Amount, q.Unit
+		case units.Compatible(item.Unit, q.Unit):
+			sum, err := units.Quantity{Amount: *item.Quantity, Unit: item.Unit}.Add(*q)
+			if err != nil {
+				return nil, err
+			}
+			item.Quantity, item.Unit = &sum.Amount, sum.Unit
+		default:
+			continue
+		}
+		if err := db.Save(item).Error; err != nil {
+			return nil, err
+		}
+		return item, nil
+	}
+
+	item := models.PantryItem{
+		OrganizationID: orgID,
+		IngredientID:   p.IngredientID,
+		Name:           strings.TrimSpace(p.Name),
+		Location:       location,
+		ExpiresOn:      expiresOn,
+	}
+	if q != nil {
+		item.Quantity, item.Unit = &q.Amount, q.Unit
+	}
+	if err := db.Create(&item).Error; err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func sameDate(a, b *models.Date) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
+
+// Use — списание из одной строки запаса.
+type Use struct {
+	Item  models.PantryItem // строка с остатком после списания
+	Taken float64           // сколько взято, в единице строки
+	Empty bool              // строка опустела и удаляется
+}
+
+// Deduct списывает количество продукта к дате date, начиная с запасов, которые испортятся
+// раньше; просроченные к date не трогает. Опустевшие строки удаляются. Возвращает, из каких
+// строк сколько взято, и то, чего не хватило (нулевое количество — хватило).
+func Deduct(db *gorm.DB, orgID uint, p Product, q units.Quantity, date models.Date) ([]Use, units.Quantity, error) {
+	list, err := items(db, orgID, p)
+	if err != nil {
+		return nil, q, err
+	}
+	uses, left, err := take(list, q, date)
+	if err != nil {
+		return nil, q, err
+	}
+	for i := range uses {
+		if uses[i].Empty {
+			err = db.Delete(&uses[i].Item).Error
+		} else {
+			err = db.Save(&uses[i].Item).Error
+		}
+		if err != nil {
+			return nil, q, err
+		}
+	}
+	return uses, left, nil
+}
+
+// take распределяет q по запасам list: сначала с ближайшим сроком, без срока — последними.
+// Просроченные к date, без количества и в несовместимой единице пропускаются.
+func take(list []models.PantryItem, q units.Quantity, date models.Date) ([]Use, units.Quantity, error) {
+	list = fresh(list, date)
+	sortByExpiry(list)
+
+	var uses []Use
+	left := q
+	for _, item := range list {
+		if left.Amount < 1e-9 {
+			break
+		}
+		if item.Quantity == nil || !units.Compatible(item.Unit, left.Unit) {
+			continue
+		}
+		have, err := units.Quantity{Amount: *item.Quantity, Unit: item.Unit}.In(left.Unit)
+		if err != nil {
+			return nil, q, err
+		}
+		if have.Amount <= left.Amount {
+			left.Amount -= have.Amount
+			uses = append(uses, Use{Item: item, Taken: *item.Quantity, Empty: true})
+			continue
+		}
+		rest, err := units.Quantity{Amount: have.Amount - left.Amount, Unit: left.Unit}.In(item.Unit)
+		if err != nil {
+			return nil, q, err
+		}
+		taken := *item.Quantity - rest.Amount
+		item.Quantity = &rest.Amount
+		uses = append(uses, Use{Item: item, Taken: taken})
+		left.Amount = 0
+	}
+	if left.Amount < 1e-9 {
+		left.Amount = 0
+	}
+	return uses, left, nil
+}
+
+// fresh возвращает запасы, не просроченные к date (нулевая date — все).
+func fresh(list []models.PantryItem, date models.Date) []models.PantryItem {
+	var out []models.PantryItem
+	for _, item := range list {
+		if date.IsZero() || item.ExpiresOn == nil || !item.ExpiresOn.Before(date) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// sortByExpiry упорядочивает запасы: сначала с ближайшим сроком, без срока — в конце.
+func sortByExpiry(list []models.PantryItem) {
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := list[i].ExpiresOn, list[j].ExpiresOn
+		switch {
+		case a == nil:
+			return false
+		case b == nil:
+			return true
+		default:
+			return a.Before(*b)
+		}
+	})
+}
+
+// Stock — сколько продукта есть к дате date (без просроченного) в единице unit и её
+// размерности. present — продукт вообще есть дома, даже если количество неизвестно.
+func Stock(db *gorm.DB, orgID uint, p Product, unit string, date models.Date) (have units.Quantity, present bool, err error) {
+	list, err := items(db, orgID, p)
+	if err != nil {
+		return units.Quantity{}, false, err
+	}
+	return total(fresh(list, date), unit)
+}
+
+// total складывает количества запасов list в единице unit; строки другой размерности и без
+// количества не складываются, но считаются наличием продукта.
+func total(list []models.PantryItem, unit string) (have units.Quantity, present bool, err error) {
+	have = units.Quantity{Unit: unit}
+	for _, item := range list {
+		present = true
+		if item.Quantity == nil || !units.Compatible(item.Unit, unit) {
+			continue
+		}
+		q, err := units.Quantity{Amount: *item.Quantity, Unit: item.Unit}.In(unit)
+		if err != nil {
+			return have, present, err
+		}
+		have.Amount += q.Amount
+	}
+	return have, present, nil
+}
+
+// Shortage — чего не хватило при списании.
+type Shortage struct {
+	Name     string  `json:"name"`
+	Quantity float64 `json:"quantity"`
+	Unit     string  `json:"unit"`
+}
+
+// DeductMeal списывает из запасов состав рецептов meal-задачи, пересчитанный на её порции;
+// просроченное ко дню задачи не списывается. Списанное записывается (PantryDeduction), чтобы
+// RestoreMeal мог его вернуть. Повторно для той же задачи не списывает
+// (ScheduleTask.StockDeducted). db может быть транзакцией.
+func DeductMeal(db *gorm.DB, task *models.ScheduleTask) ([]Shortage, error) {
+	if task.TaskType != "meal" || task.StockDeducted {
+		return nil, nil
+	}
+	var full models.ScheduleTask
+	err := db.Preload("Recipes.RecipeIngredients").Preload("Recipe.RecipeIngredients").First(&full, task.ID).Error
+	if err != nil {
+		return nil, err
+	}
+	var schedule models.DailySchedule
+	if err := db.Select("id", "date").First(&schedule, full.ScheduleID).Error; err != nil {
+		return nil, err
+	}
+	recipes := full.Recipes
+	if len(recipes) == 0 && full.Recipe != nil {
+		recipes = []models.Recipe{*full.Recipe}
+	}
+
+	var shortages []Shortage
+	for _, recipe := range recipes {
+		for _, line := range ingredients.Scale(recipe.RecipeIngredients, recipe.Servings, task.Servings) {
+			if line.Quantity == nil {
+				continue // «по вкусу» не списываем
+			}
+			uses, left, err := Deduct(db, task.OrganizationID, Product{IngredientID: line.IngredientID, Name: line.Name},
+				units.Quantity{Amount: *line.Quantity, Unit: line.Unit}, schedule.Date)
+			if err != nil {
+				return nil, fmt.Errorf("deduct %s: %w", line.Name, err)
+			}
+			for _, use := range uses {
+				deduction := models.PantryDeduction{
+					OrganizationID: task.OrganizationID,
+					TaskID:         task.ID,
+					IngredientID:   use.Item.IngredientID,
+					Name:           use.Item.Name,
+					Quantity:       use.Taken,
+					Unit:           use.Item.Unit,
+					Location:       use.Item.Location,
+					ExpiresOn:      use.Item.ExpiresOn,
+				}
+				if err := db.Create(&deduction).Error; err != nil {
+					return nil, err
+				}
+			}
+			if left.Amount > 0 {
+				shortages = append(shortages, Shortage{Name: line.Name, Quantity: left.Amount, Unit: left.Unit})
+			}
+		}
+	}
+
+	task.StockDeducted = true
+	if err := db.Model(&models.ScheduleTask{}).Where("id = ?", task.ID).Update("stock_deducted", true).Error; err != nil {
+		return nil, err
+	}
+	return shortages, nil
+}
+
+// RestoreMeal возвращает в запас то, что DeductMeal списал для задачи: в ту же строку
+// (то же место и срок годности) или новой строкой, если та опустела и удалена.
+func RestoreMeal(db *gorm.DB, task *models.ScheduleTask) error {
+	if !task.StockDeducted {
+		return nil
+	}
+	var deductions []models.PantryDeduction
+	if err := db.Where("task_id = ?", task.ID).Order("id").Find(&deductions).Error; err != nil {
+		return err
+	}
+	for _, d := range deductions {
+		q := units.Quantity{Amount: d.Quantity, Unit: d.Unit}
+		if _, err := Add(db, d.OrganizationID, Product{IngredientID: d.IngredientID, Name: d.Name}, &q, d.Location, d.ExpiresOn); err != nil {
+			return fmt.Errorf("restore %s: %w", d.Name, err)
+		}
+	}
+	if err := db.Where("task_id = ?", task.ID).Delete(&models.PantryDeduction{}).Error; err != nil {
+		return err
+	}
+	task.StockDeducted = false
+	return db.Model(&models.ScheduleTask{}).Where("id = ?", task.ID).Update("stock_deducted", false).Error
+}
+
+// AddPurchase кладёт купленную позицию списка покупок в запас.
+func AddPurchase(db *gorm.DB, item *models.ShoppingListItem, location string) (*models.PantryItem, error) {
+	var q *units.Quantity
+	if item.Amount != nil {
+		q = &units.Quantity{Amount: *item.Amount, Unit: item.Unit}
+	} else if parsed, ok := units.Parse(item.Quantity); ok {
+		q = &parsed
+	}
+	return Add(db, item.OrganizationID, Product{IngredientID: item.IngredientID, Name: item.Item}, q, location, nil)
+}
+
+// Expiring возвращает запасы организации, которые испортятся в ближайшие days дней
+// начиная с from (уже просроченные не входят): сначала с ближайшим сроком.
+func Expiring(db *gorm.DB, orgID uint, from models.Date, days int) ([]models.PantryItem, error) {
+	var list []models.PantryItem
+	err := db.Where("organization_id = ? AND expires_on >= ? AND expires_on <= ?", orgID, from, from.AddDays(days)).
+		Where("quantity IS NULL OR quantity > 0").
+		Order("expires_on, id").
+		Find(&list).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load expiring pantry items: %w", err)
+	}
+	return list, nil
+}
+
+// Uses — использует ли строка состава продукт из запаса: тот же продукт справочника
+// или то же название.
+func Uses(line models.RecipeIngredient, item models.PantryItem) bool {
+	if line.IngredientID != nil && item.IngredientID != nil {
+		return *line.IngredientID == *item.IngredientID
+	}
+	return ingredients.NormalizeName(line.Name) == ingredients.NormalizeName(item.Name)
+}
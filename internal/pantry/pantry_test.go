@@ -0,0 +1,100 @@
+package pantry
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"podlevskikh/awesomeProject/internal/models"
+	"podlevskikh/awesomeProject/internal/units"
+)
+
+func lot(id uint, amount float64, unit string, expires *models.Date) models.PantryItem {
+	return models.PantryItem{ID: id, Name: "Молоко", Quantity: &amount, Unit: unit, ExpiresOn: expires}
+}
+
+// uses печатает списание: "id:взято[/остаток]", опустевшие строки без остатка.
+func uses(list []Use) string {
+	parts := make([]string, len(list))
+	for i, u := range list {
+		parts[i] = fmt.Sprintf("%d:%s", u.Item.ID, units.FormatNumber(u.Taken))
+		if !u.Empty {
+			parts[i] += "/" + units.FormatNumber(*u.Item.Quantity)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestTake(t *testing.T) {
+	today := models.NewDate(2025, time.June, 10)
+	yesterday, soon, later := today.AddDays(-1), today.AddDays(2), today.AddDays(7)
+	cases := []struct {
+		name  string
+		lots  []models.PantryItem
+		need  units.Quantity
+		uses  string
+		short float64
+	}{
+		{
+			name: "ближайший срок первым, без срока последним",
+			lots: []models.PantryItem{lot(1, 500, units.Milliliter, nil), lot(2, 300, units.Milliliter, &later), lot(3, 200, units.Milliliter, &soon)},
+			need: units.Quantity{Amount: 600, Unit: units.Milliliter},
+			uses: "3:200 2:300 1:100/400",
+		},
+		{
+			name: "часть строки в другой единице",
+			lots: []models.PantryItem{lot(1, 1, units.Liter, &soon)},
+			need: units.Quantity{Amount: 250, Unit: units.Milliliter},
+			uses: "1:0.25/0.75",
+		},
+		{
+			name:  "просроченное не списывается",
+			lots:  []models.PantryItem{lot(1, 500, units.Milliliter, &yesterday), lot(2, 200, units.Milliliter, &today)},
+			need:  units.Quantity{Amount: 300, Unit: units.Milliliter},
+			uses:  "2:200",
+			short: 100,
+		},
+		{
+			name:  "несовместимая единица и неизвестное количество пропускаются",
+			lots:  []models.PantryItem{lot(1, 2, "банка", nil), {ID: 2, Name: "Молоко"}},
+			need:  units.Quantity{Amount: 300, Unit: units.Milliliter},
+			short: 300,
+		},
+	}
+	for _, c := range cases {
+		got, left, err := take(c.lots, c.need, today)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if uses(got) != c.uses || left.Amount != c.short {
+			t.Errorf("%s: uses %q, short %v; want %q, %v", c.name, uses(got), left.Amount, c.uses, c.short)
+		}
+	}
+}
+
+func TestTotal(t *testing.T) {
+	today := models.NewDate(2025, time.June, 10)
+	yesterday := today.AddDays(-1)
+	cases := []struct {
+		name    string
+		lots    []models.PantryItem
+		unit    string
+		want    float64
+		present bool
+	}{
+		{"разные единицы одной размерности", []models.PantryItem{lot(1, 1, units.Liter, nil), lot(2, 250, units.Milliliter, nil)}, units.Milliliter, 1250, true},
+		{"просроченное не считается", []models.PantryItem{lot(1, 1, units.Liter, &yesterday), lot(2, 200, units.Milliliter, &today)}, units.Milliliter, 200, true},
+		{"другая размерность — только наличие", []models.PantryItem{lot(1, 2, "банка", nil)}, units.Milliliter, 0, true},
+		{"ничего нет", nil, units.Milliliter, 0, false},
+	}
+	for _, c := range cases {
+		have, present, err := total(fresh(c.lots, today), c.unit)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if have.Amount != c.want || have.Unit != c.unit || present != c.present {
+			t.Errorf("%s: %v, present %v; want %v %s, present %v", c.name, have, present, c.want, c.unit, c.present)
+		}
+	}
+}
@@ -0,0 +1,381 @@
+// Package recurrence — повторяющиеся задачи по правилам iCalendar (RFC 5545): разбор RRULE
+// и вычисление дат повторений с учётом исключённых дат (EXDATE). Правила работают с датами:
+// время задачи хранится отдельно, поэтому BYHOUR/BYMINUTE и частоты меньше дня не поддерживаются.
+package recurrence
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"podlevskikh/awesomeProject/internal/models"
+)
+
+// Частоты повторения.
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+	Yearly  = "YEARLY"
+)
+
+// maxSpanDays — на сколько дней вперёд от DTSTART просматриваются даты (COUNT считается
+// от начала серии).
+const maxSpanDays = 20 * 366
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// WeekdayNum — день недели из BYDAY с необязательным номером в месяце: "FR" — каждая
+// пятница, "1MO" — первый понедельник, "-1FR" — последняя пятница. В годовых правилах номер
+// тоже считается в месяце (с BYMONTH), а не в году.
+type WeekdayNum struct {
+	N       int
+	Weekday time.Weekday
+}
+
+// Rule — разобранное правило RRULE.
+type Rule struct {
+	Freq       string
+	Interval   int
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []int
+	Count      int
+	Until      *models.Date
+	WeekStart  time.Weekday // WKST: с какого дня начинается неделя для INTERVAL у недельных правил
+}
+
+// Parse разбирает правило вида "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO" (префикс "RRULE:"
+// необязателен). Понимает FREQ, INTERVAL, BYDAY, BYMONTHDAY, BYMONTH, COUNT, UNTIL и WKST.
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return nil, fmt.Errorf("empty rrule")
+	}
+	r := &Rule{Interval: 1, WeekStart: time.Monday}
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid rrule part %q", part)
+		}
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.Freq = strings.ToUpper(value)
+			if r.Freq != Daily && r.Freq != Weekly && r.Freq != Monthly && r.Freq != Yearly {
+				return nil, fmt.Errorf("unsupported FREQ %q: use DAILY, WEEKLY, MONTHLY or YEARLY", value)
+			}
+		case "INTERVAL":
+			r.Interval, err = number(value, 1, 1000)
+		case "COUNT":
+			r.Count, err = number(value, 1, 10000)
+		case "UNTIL":
+			r.Until, err = until(value)
+		case "BYDAY":
+			r.ByDay, err = byDay(value)
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = numbers(value, -31, 31)
+		case "BYMONTH":
+			r.ByMonth, err = numbers(value, 1, 12)
+		case "WKST":
+			wd, ok := weekdays[strings.ToUpper(value)]
+			if !ok {
+				err = fmt.Errorf("invalid WKST %q", value)
+			}
+			r.WeekStart = wd
+		default:
+			return nil, fmt.Errorf("unsupported rrule part %s", key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if r.Freq == "" {
+		return nil, fmt.Errorf("rrule needs FREQ")
+	}
+	if r.Count > 0 && r.Until != nil {
+		return nil, fmt.Errorf("rrule cannot have both COUNT and UNTIL")
+	}
+	for _, d := range r.ByDay {
+		if d.N != 0 && r.Freq != Monthly && r.Freq != Yearly {
+			return nil, fmt.Errorf("numbered BYDAY is only allowed with FREQ=MONTHLY or YEARLY")
+		}
+	}
+	return r, nil
+}
+
+func number(s string, lo, hi int) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < lo || n > hi || n == 0 {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return n, nil
+}
+
+func numbers(s string, lo, hi int) ([]int, error) {
+	var out []int
+	for _, part := range strings.Split(s, ",") {
+		n, err := number(strings.TrimSpace(part), lo, hi)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+func byDay(s string) ([]WeekdayNum, error) {
+	var out []WeekdayNum
+	for _, part := range strings.Split(strings.ToUpper(s), ",") {
+		part = strings.TrimSpace(part)
+		if len(part) < 2 {
+			return nil, fmt.Errorf("invalid BYDAY %q", part)
+		}
+		wd, ok := weekdays[part[len(part)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("invalid BYDAY %q", part)
+		}
+		d := WeekdayNum{Weekday: wd}
+		if prefix := part[:len(part)-2]; prefix != "" {
+			n, err := number(strings.TrimPrefix(prefix, "+"), -53, 53)
+			if err != nil {
+				return nil, fmt.Errorf("invalid BYDAY %q", part)
+			}
+			d.N = n
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+// until разбирает UNTIL в формате даты (20250630) или даты-времени (20250630T235959Z).
+func until(s string) (*models.Date, error) {
+	if len(s) < 8 {
+		return nil, fmt.Errorf("invalid UNTIL %q", s)
+	}
+	t, err := time.Parse("20060102", s[:8])
+	if err != nil {
+		return nil, fmt.Errorf("invalid UNTIL %q", s)
+	}
+	d := models.DateOf(t)
+	return &d, nil
+}
+
+// Between возвращает даты повторений серии, начатой start (DTSTART), в [from, to), кроме
+// exdates. Сама start — первое повторение, только если подходит под правило.
+func (r *Rule) Between(start, from, to models.Date, exdates []models.Date) []models.Date {
+	excluded := make(map[string]bool, len(exdates))
+	for _, d := range exdates {
+		excluded[d.String()] = true
+	}
+	var out []models.Date
+	count := 0
+	end := start.AddDays(maxSpanDays)
+	for d := start; d.Before(to) && d.Before(end); d = d.AddDays(1) {
+		if r.Until != nil && d.After(*r.Until) {
+			break
+		}
+		if !r.matches(start, d) {
+			continue
+		}
+		// COUNT считает и исключённые даты (RFC 5545: EXDATE применяется после RRULE)
+		count++
+		if r.Count > 0 && count > r.Count {
+			break
+		}
+		if !d.Before(from) && !excluded[d.String()] {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// matches проверяет, выпадает ли на d повторение серии, начатой start.
+func (r *Rule) matches(start, d models.Date) bool {
+	if len(r.ByMonth) > 0 && !contains(r.ByMonth, int(d.Month())) {
+		return false
+	}
+	switch r.Freq {
+	case Daily:
+		if d.DaysSince(start)%r.Interval != 0 {
+			return false
+		}
+		return r.matchDays(d, start, false)
+	case Weekly:
+		if weeksBetween(start, d, r.WeekStart)%r.Interval != 0 {
+			return false
+		}
+		if len(r.ByDay) == 0 {
+			return d.Weekday() == start.Weekday()
+		}
+		return r.matchDays(d, start, false)
+	case Monthly:
+		if monthsBetween(start, d)%r.Interval != 0 {
+			return false
+		}
+		return r.matchDays(d, start, true)
+	case Yearly:
+		if (d.Year()-start.Year())%r.Interval != 0 {
+			return false
+		}
+		if len(r.ByMonth) == 0 && d.Month() != start.Month() {
+			return false
+		}
+		return r.matchDays(d, start, true)
+	}
+	return false
+}
+
+// matchDays проверяет BYDAY и BYMONTHDAY. Для месячных и годовых правил без них повторение
+// приходится на день месяца start.
+func (r *Rule) matchDays(d, start models.Date, monthly bool) bool {
+	if len(r.ByMonthDay) > 0 && !matchMonthDay(r.ByMonthDay, d) {
+		return false
+	}
+	if len(r.ByDay) > 0 {
+		for _, wd := range r.ByDay {
+			if wd.Weekday == d.Weekday() && (wd.N == 0 || nthInMonth(d, wd.N)) {
+				return true
+			}
+		}
+		return false
+	}
+	if monthly && len(r.ByMonthDay) == 0 {
+		return d.Day() == start.Day()
+	}
+	return true
+}
+
+func matchMonthDay(days []int, d models.Date) bool {
+	last := daysIn(d)
+	for _, n := range days {
+		if n == d.Day() || (n < 0 && last+n+1 == d.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+// nthInMonth проверяет, что d — n-й (n < 0 — с конца) такой день недели в своём месяце.
+func nthInMonth(d models.Date, n int) bool {
+	if n > 0 {
+		return (d.Day()-1)/7+1 == n
+	}
+	return (daysIn(d)-d.Day())/7+1 == -n
+}
+
+func daysIn(d models.Date) int {
+	return models.NewDate(d.Year(), d.Month()+1, 0).Day()
+}
+
+// weeksBetween — число недель между неделями a и b; неделя начинается с wkst.
+func weeksBetween(a, b models.Date, wkst time.Weekday) int {
+	return b.AddDays(-weekdayIndex(b, wkst)).DaysSince(a.AddDays(-weekdayIndex(a, wkst))) / 7
+}
+
+// weekdayIndex — номер дня d в неделе, начатой wkst (wkst — 0).
+func weekdayIndex(d models.Date, wkst time.Weekday) int {
+	return (int(d.Weekday()) - int(wkst) + 7) % 7
+}
+
+func monthsBetween(a, b models.Date) int {
+	return (b.Year()-a.Year())*12 + int(b.Month()) - int(a.Month())
+}
+
+func contains(list []int, n int) bool {
+	for _, v := range list {
+		if v == n {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseDates разбирает список дат через запятую ("2025-06-10, 2025-06-24"), как хранятся EXDATE.
+func ParseDates(s string) ([]models.Date, error) {
+	var out []models.Date
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		d, err := models.ParseDate(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q, use YYYY-MM-DD", part)
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+// FormatDates записывает даты через запятую по возрастанию без повторов.
+func FormatDates(dates []models.Date) string {
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	var parts []string
+	for i, d := range dates {
+		if i > 0 && d.Equal(dates[i-1]) {
+			continue
+		}
+		parts = append(parts, d.String())
+	}
+	return strings.Join(parts, ",")
+}
+
+// Validate проверяет серию: название, правило, начало, время, длительность и исключённые даты.
+func Validate(rt models.RecurringTask) error {
+	if strings.TrimSpace(rt.Title) == "" {
+		return fmt.Errorf("title is required")
+	}
+	if rt.StartDate.IsZero() {
+		return fmt.Errorf("start_date is required")
+	}
+	if _, err := Parse(rt.RRule); err != nil {
+		return fmt.Errorf("rrule: %w", err)
+	}
+	if rt.Time != "" {
+		if _, err := time.Parse("15:04", rt.Time); err != nil {
+			return fmt.Errorf("invalid time %q, expected HH:MM", rt.Time)
+		}
+	}
+	if rt.Duration < 1 || rt.Duration > 600 {
+		return fmt.Errorf("duration must be between 1 and 600 minutes")
+	}
+	if _, err := ParseDates(rt.ExDates); err != nil {
+		return fmt.Errorf("exdates: %w", err)
+	}
+	return nil
+}
+
+// Occurrences возвращает даты повторений серии в [from, to) без исключённых дат.
+func Occurrences(rt models.RecurringTask, from, to models.Date) ([]models.Date, error) {
+	rule, err := Parse(rt.RRule)
+	if err != nil {
+		return nil, err
+	}
+	exdates, err := ParseDates(rt.ExDates)
+	if err != nil {
+		return nil, err
+	}
+	return rule.Between(rt.StartDate, from, to, exdates), nil
+}
+
+// OccurrenceOf возвращает дату серии, повторением которой является задача из расписания дня
+// scheduled. Перенесённая задача остаётся повторением исходной даты; у задач без
+// OccurrenceDate (созданных до его появления) это дата расписания.
+func OccurrenceOf(t models.ScheduleTask, scheduled models.Date) models.Date {
+	if t.OccurrenceDate != nil {
+		return *t.OccurrenceDate
+	}
+	return scheduled
+}
+
+// IsOccurrence сообщает, что задача из расписания дня scheduled — повторение серии seriesID
+// на date. Повторение, перенесённое на date с другого дня, им не считается.
+func IsOccurrence(t models.ScheduleTask, scheduled models.Date, seriesID uint, date models.Date) bool {
+	return t.RecurringTaskID != nil && *t.RecurringTaskID == seriesID && OccurrenceOf(t, scheduled).Equal(date)
+}
@@ -0,0 +1,123 @@
+package recurrence
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"podlevskikh/awesomeProject/internal/models"
+)
+
+func dates(list []models.Date) string {
+	parts := make([]string, len(list))
+	for i, d := range list {
+		parts[i] = d.String()
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestBetween(t *testing.T) {
+	start := models.NewDate(2025, time.June, 2) // понедельник
+	cases := []struct {
+		rule     string
+		from, to models.Date
+		exdates  string
+		want     string
+	}{
+		{"FREQ=WEEKLY;BYDAY=TU,FR", start, start.AddDays(16), "2025-06-13",
+			"2025-06-03 2025-06-06 2025-06-10 2025-06-17"},
+		{"RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO", start, start.AddDays(35), "",
+			"2025-06-02 2025-06-16 2025-06-30"},
+		{"FREQ=MONTHLY", start, models.NewDate(2025, time.October, 1), "",
+			"2025-06-02 2025-07-02 2025-08-02 2025-09-02"},
+		{"FREQ=MONTHLY;BYDAY=-1FR", start, models.NewDate(2025, time.September, 1), "",
+			"2025-06-27 2025-07-25 2025-08-29"},
+		{"FREQ=MONTHLY;BYMONTHDAY=31", start, models.NewDate(2025, time.October, 1), "",
+			"2025-07-31 2025-08-31"},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", start, models.NewDate(2025, time.August, 1), "",
+			"2025-06-30 2025-07-31"},
+		// COUNT считает и исключённую дату
+		{"FREQ=DAILY;COUNT=4", start, start.AddDays(30), "2025-06-03",
+			"2025-06-02 2025-06-04 2025-06-05"},
+		{"FREQ=DAILY;INTERVAL=3;UNTIL=20250610T000000Z", start.AddDays(3), start.AddDays(30), "",
+			"2025-06-05 2025-06-08"},
+		{"FREQ=YEARLY;BYMONTH=6;BYDAY=1MO", start, models.NewDate(2027, time.January, 1), "",
+			"2025-06-02 2026-06-01"},
+	}
+	for _, c := range cases {
+		r, err := Parse(c.rule)
+		if err != nil {
+			t.Fatalf("%s: %v", c.rule, err)
+		}
+		ex, err := ParseDates(c.exdates)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := dates(r.Between(start, c.from, c.to, ex)); got != c.want {
+			t.Errorf("%s:\n got %s\nwant %s", c.rule, got, c.want)
+		}
+	}
+}
+
+// Пример из RFC 5545: с WKST=SU воскресенье открывает новую неделю, поэтому при INTERVAL=2
+// повторения другие, чем с WKST=MO.
+func TestWeekStart(t *testing.T) {
+	start := models.NewDate(1997, time.August, 5) // вторник
+	cases := []struct {
+		rule string
+		want string
+	}{
+		{"FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=MO", "1997-08-05 1997-08-10 1997-08-19 1997-08-24"},
+		{"FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=SU", "1997-08-05 1997-08-17 1997-08-19 1997-08-31"},
+	}
+	for _, c := range cases {
+		r, err := Parse(c.rule)
+		if err != nil {
+			t.Fatalf("%s: %v", c.rule, err)
+		}
+		if got := dates(r.Between(start, start, start.AddDays(60), nil)); got != c.want {
+			t.Errorf("%s:\n got %s\nwant %s", c.rule, got, c.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, rule := range []string{
+		"", "BYDAY=MO", "FREQ=HOURLY", "FREQ=WEEKLY;BYDAY=XX", "FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=DAILY;COUNT=3;UNTIL=20250101", "FREQ=DAILY;INTERVAL=0", "FREQ=DAILY;BYSETPOS=1", "FREQ=WEEKLY;WKST=XX",
+	} {
+		if _, err := Parse(rule); err == nil {
+			t.Errorf("Parse(%q) = nil error", rule)
+		}
+	}
+}
+
+// Повторение серии TU,FR перенесли со вторника на пятницу: в пятнице две задачи серии,
+// и у пятницы по-прежнему есть своё повторение.
+func TestIsOccurrence(t *testing.T) {
+	seriesID := uint(1)
+	tuesday, friday := models.NewDate(2025, time.June, 3), models.NewDate(2025, time.June, 6)
+	moved := models.ScheduleTask{RecurringTaskID: &seriesID, OccurrenceDate: &tuesday}
+	own := models.ScheduleTask{RecurringTaskID: &seriesID, OccurrenceDate: &friday}
+	legacy := models.ScheduleTask{RecurringTaskID: &seriesID}
+
+	cases := []struct {
+		name string
+		task models.ScheduleTask
+		date models.Date
+		want bool
+	}{
+		{"перенесённое — не повторение пятницы", moved, friday, false},
+		{"перенесённое — повторение вторника", moved, tuesday, true},
+		{"своё повторение пятницы", own, friday, true},
+		{"без OccurrenceDate — по дате расписания", legacy, friday, true},
+	}
+	for _, c := range cases {
+		if got := IsOccurrence(c.task, friday, seriesID, c.date); got != c.want {
+			t.Errorf("%s: IsOccurrence = %v, want %v", c.name, got, c.want)
+		}
+	}
+	if IsOccurrence(own, friday, seriesID+1, friday) {
+		t.Errorf("task of another series counts as an occurrence")
+	}
+}
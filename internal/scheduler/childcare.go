@@ -0,0 +1,100 @@
+package scheduler
+
+import (
+	"fmt"
+
+	"podlevskikh/awesomeProject/internal/childcare"
+	"podlevskikh/awesomeProject/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// expandChildcarePatterns adds the organization's weekly childcare patterns that apply on date
+// as ChildcareSchedule blocks, stored through the sink. A pattern that already has a block
+// expanded for the date (even one an admin edited or moved to another day) is not expanded again.
+// Holidays and Sundays never get here.
+func (s *Scheduler) expandChildcarePatterns(orgID uint, date models.Date) error {
+	var patterns []models.ChildcarePattern
+	if err := s.db.Preload("Children").Where("organization_id = ?", orgID).Order("start_time, id").
+		Find(&patterns).Error; err != nil {
+		return err
+	}
+	if len(patterns) == 0 {
+		return nil
+	}
+	var exceptions []models.ChildcareException
+	if err := s.db.Where("organization_id = ? AND start_date <= ? AND end_date >= ?", orgID, date, date).
+		Find(&exceptions).Error; err != nil {
+		return err
+	}
+	blocks, err := s.patternBlocks(orgID, date)
+	if err != nil {
+		return err
+	}
+	expanded := childcare.Expanded(blocks, date)
+
+	for _, p := range patterns {
+		ok, reason := childcare.Applies(p, exceptions, date)
+		if expanded[p.ID] || (!ok && reason == "") {
+			continue
+		}
+		if !ok {
+			s.output().decide(Decision{Date: date, Kind: "childcare", Subject: childcare.Title(p.Children),
+				Time: p.StartTime, Reason: reason})
+			continue
+		}
+		patternID := p.ID
+		block := models.ChildcareSchedule{
+			OrganizationID: orgID,
+			Date:           date,
+			StartTime:      p.StartTime,
+			EndTime:        p.EndTime,
+			Notes:          p.Notes,
+			PatternID:      &patternID,
+			OriginDate:     &date,
+			Children:       p.Children,
+		}
+		if err := s.output().saveChildcare(s.db, &block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// patternBlocks returns the organization's childcare blocks expanded from patterns for date,
+// wherever they were moved, including blocks the sink holds
+func (s *Scheduler) patternBlocks(orgID uint, date models.Date) ([]models.ChildcareSchedule, error) {
+	var blocks []models.ChildcareSchedule
+	if err := s.db.Where("organization_id = ? AND pattern_id IS NOT NULL", orgID).
+		Where("origin_date = ? OR (origin_date IS NULL AND date = ?)", date, date).
+		Find(&blocks).Error; err != nil {
+		return nil, err
+	}
+	return append(blocks, s.output().plannedChildcare(date)...), nil
+}
+
+// RefreshChildcare re-expands the organization's childcare patterns from the given date on,
+// after a pattern or an exception changed: blocks expanded from patterns are dropped (unless an
+// admin edited them) and the childcare tasks of the existing schedules are regenerated, all in
+// one transaction.
+func (s *Scheduler) RefreshChildcare(orgID uint, from models.Date) (*RegenerateDiff, error) {
+	var diff *RegenerateDiff
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		stale := tx.Model(&models.ChildcareSchedule{}).Select("id").
+			Where("organization_id = ? AND date >= ? AND pattern_id IS NOT NULL AND edited = ?", orgID, from, false)
+		if err := tx.Exec("DELETE FROM childcare_schedule_children WHERE childcare_schedule_id IN (?)", stale).Error; err != nil {
+			return fmt.Errorf("failed to delete childcare_schedule_children: %w", err)
+		}
+		if err := tx.Where("organization_id = ? AND date >= ? AND pattern_id IS NOT NULL AND edited = ?", orgID, from, false).
+			Delete(&models.ChildcareSchedule{}).Error; err != nil {
+			return fmt.Errorf("failed to delete childcare blocks: %w", err)
+		}
+		var err error
+		diff, err = s.withDB(tx).refresh(orgID, from, "childcare")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return diff, nil
+}
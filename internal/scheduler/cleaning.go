@@ -0,0 +1,128 @@
+package scheduler
+
+import (
+	"podlevskikh/awesomeProject/internal/cleaning"
+	"podlevskikh/awesomeProject/internal/data"
+	"podlevskikh/awesomeProject/internal/models"
+	"podlevskikh/awesomeProject/internal/settings"
+)
+
+// defaultMaxZonesPerDay caps the cleaning zones of a day when settings are unavailable
+const defaultMaxZonesPerDay = 3
+
+// generateCleaningTasks creates cleaning tasks for the zones whose interval has passed since
+// they were last cleaned, or which are below their weekly quota (see package cleaning). Zones missed on a holiday or left undone stay
+// overdue and are picked first on the next working day; the daily load follows the weekly
+// number of cleanings spread over the working days, up to cleaning_max_zones_per_day, and
+// fits into the helpers' working time left after the other tasks of the day.
+func (s *Scheduler) generateCleaningTasks(schedule *models.DailySchedule, date models.Date) error {
+	orgID := schedule.OrganizationID
+	var zones []models.CleaningZone
+	if err := s.db.Where("organization_id = ?", orgID).Order("id").Find(&zones).Error; err != nil {
+		return err
+	}
+	if len(zones) == 0 {
+		return nil
+	}
+
+	// Zones kept from a previous generation are already cleaned today and use up the limit
+	kept := 0
+	var open []models.CleaningZone
+	for _, zone := range zones {
+		zoneID := zone.ID
+		if hasTask(schedule, "cleaning", func(t models.ScheduleTask) bool { return t.ZoneID != nil && *t.ZoneID == zoneID }) {
+			s.output().decide(Decision{Date: date, Kind: "cleaning", Subject: zone.Name, ZoneID: &zoneID,
+				Reason: "zone kept from a previous generation"})
+			continue
+		}
+		open = append(open, zone)
+	}
+	for _, t := range schedule.Tasks {
+		if t.TaskType == "cleaning" {
+			kept++
+		}
+	}
+
+	// Uncompleted tasks count as planned from today on; earlier ones were missed
+	today := date
+	var org models.Organization
+	if err := s.db.First(&org, orgID).Error; err == nil {
+		today = org.Today()
+	}
+	planned := make(map[uint][]models.Date)
+	for _, use := range s.output().plannedZones(today, date) {
+		planned[use.ZoneID] = append(planned[use.ZoneID], use.Date)
+	}
+	states, err := cleaning.States(s.db, orgID, open, today, date, planned, s.output().hiddenTasks())
+	if err != nil {
+		return err
+	}
+
+	minutes, err := s.remainingMinutes(schedule)
+	if err != nil {
+		return err
+	}
+	limit := cleaning.DailyLimit(zones, s.workingDays(date), s.maxZonesPerDay(orgID)) - kept
+	picked, postponed := cleaning.Plan(states, date, max(limit, 0), minutes)
+	tasks := make([]*models.ScheduleTask, len(picked))
+	preferred := make([]string, len(picked))
+	for i, p := range picked {
+		zoneID := p.Zone.ID
+		tasks[i] = &models.ScheduleTask{
+			OrganizationID: orgID,
+			ScheduleID:     schedule.ID,
+			TaskType:       "cleaning",
+			Duration:       cleaning.Duration(p.Zone),
+			Title:          p.Zone.Name,
+			Description:    p.Zone.Description,
+			ZoneID:         &zoneID,
+		}
+		preferred[i] = p.Zone.PreferredTime
+		s.output().decide(Decision{Date: date, Kind: "cleaning", Subject: p.Zone.Name, ZoneID: &zoneID,
+			Choice: p.Zone.Name, Reason: p.Reason})
+	}
+	if err := s.placeTasks(schedule, tasks, preferred); err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		if err := s.output().saveTask(s.db, schedule, task); err != nil {
+			return err
+		}
+		schedule.Tasks = append(schedule.Tasks, *task)
+	}
+	var deferred []string
+	for _, p := range postponed {
+		zoneID := p.Zone.ID
+		s.output().decide(Decision{Date: date, Kind: "cleaning", Subject: p.Zone.Name, ZoneID: &zoneID, Reason: p.Reason})
+		if p.OverCapacity {
+			deferred = append(deferred, p.Zone.Name)
+		}
+	}
+	if len(deferred) > 0 {
+		left := minutes
+		for _, p := range picked {
+			left -= cleaning.Duration(p.Zone)
+		}
+		s.warn(schedule, deferredWarning(deferred, left))
+	}
+	return nil
+}
+
+// workingDays counts the days in the week starting at date that are not Sundays or holidays
+func (s *Scheduler) workingDays(date models.Date) int {
+	n := 0
+	for i := 0; i < 7; i++ {
+		if data.HolidayReason(s.db, date.AddDays(i)) == "" {
+			n++
+		}
+	}
+	return n
+}
+
+// maxZonesPerDay returns the organization's cleaning_max_zones_per_day setting
+func (s *Scheduler) maxZonesPerDay(orgID uint) int {
+	if s.settings == nil {
+		return defaultMaxZonesPerDay
+	}
+	return s.settings.Int(orgID, settings.KeyCleaningMaxZones)
+}
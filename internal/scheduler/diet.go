@@ -0,0 +1,175 @@
+package scheduler
+
+import (
+	"log"
+	"strings"
+
+	"podlevskikh/awesomeProject/internal/diet"
+	"podlevskikh/awesomeProject/internal/models"
+)
+
+// withoutConflicts drops the recipes that must not be served in the meal slot at clock on date
+// to the people the meal time is for: recipes conflicting with their allergies, diets or
+// dislikes, recipes for older children, and recipes breaking the baby food introduction rules
+// (see diet.IntroductionPlan). It returns the remaining recipes and a short description of what
+// was dropped ("" when nothing was).
+func (s *Scheduler) withoutConflicts(mealTime models.MealTime, recipes []models.Recipe, date models.Date, clock string) ([]models.Recipe, string, error) {
+	if len(recipes) == 0 {
+		return recipes, "", nil
+	}
+	members, err := diet.MembersFor(s.db, mealTime, date)
+	if err != nil || len(members) == 0 {
+		return recipes, "", err
+	}
+	plans, err := s.introductionPlans(mealTime.OrganizationID, members, date)
+	if err != nil {
+		return nil, "", err
+	}
+
+	ids := make([]uint, len(recipes))
+	for i, r := range recipes {
+		ids[i] = r.ID
+	}
+	byRecipe, err := s.recipeLines(ids)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var kept []models.Recipe
+	var dropped []string
+	for _, r := range recipes {
+		r.RecipeIngredients = byRecipe[r.ID]
+		conflicts := diet.Check(r, members)
+		conflicts = append(conflicts, diet.CheckAge(r, members, date)...)
+		if len(plans) > 0 {
+			allergens := diet.Allergens(r)
+			for _, p := range plans {
+				conflicts = append(conflicts, p.Check(allergens, date, clock)...)
+			}
+		}
+		if len(conflicts) > 0 {
+			dropped = append(dropped, r.Name+" ("+conflicts[0].String()+")")
+			continue
+		}
+		r.RecipeIngredients = nil
+		kept = append(kept, r)
+	}
+	return kept, strings.Join(dropped, "; "), nil
+}
+
+// recipeLines loads the structured ingredients of the recipes, grouped by recipe ID
+func (s *Scheduler) recipeLines(ids []uint) (map[uint][]models.RecipeIngredient, error) {
+	var lines []models.RecipeIngredient
+	if err := s.db.Preload("Ingredient").Where("recipe_id IN ?", ids).Order("recipe_id, position").Find(&lines).Error; err != nil {
+		return nil, err
+	}
+	byRecipe := make(map[uint][]models.RecipeIngredient)
+	for _, line := range lines {
+		byRecipe[line.RecipeID] = append(byRecipe[line.RecipeID], line)
+	}
+	return byRecipe, nil
+}
+
+// introductionPlans returns the allergen introduction state of the babies among members:
+// their introduction log and the new allergens they were introduced to, or are planned to get
+// in any meal slot they eat, within diet.IntroductionGapDays days of date
+func (s *Scheduler) introductionPlans(orgID uint, members []models.FamilyMember, date models.Date) ([]diet.IntroductionPlan, error) {
+	var babies []models.FamilyMember
+	for _, m := range members {
+		if diet.IsBaby(m, date) {
+			babies = append(babies, m)
+		}
+	}
+	if len(babies) == 0 {
+		return nil, nil
+	}
+
+	// Meal slots each baby eats
+	var mealTimes []models.MealTime
+	if err := s.db.Where("organization_id = ?", orgID).Order("id").Find(&mealTimes).Error; err != nil {
+		return nil, err
+	}
+	slots := make(map[uint][]models.MealTime)
+	for _, mt := range mealTimes {
+		eaters, err := diet.MembersFor(s.db, mt, date)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range eaters {
+			slots[m.ID] = append(slots[m.ID], mt)
+		}
+	}
+
+	from, to := date.AddDays(-diet.IntroductionGapDays), date.AddDays(diet.IntroductionGapDays)
+	plans := make([]diet.IntroductionPlan, 0, len(babies))
+	for _, baby := range babies {
+		introduced, err := diet.Introductions(s.db, baby.ID)
+		if err != nil {
+			return nil, err
+		}
+		plan := diet.IntroductionPlan{Member: baby, Introduced: introduced, Nearby: make(map[string]bool)}
+		for a, intro := range introduced {
+			if !intro.IntroducedOn.Before(from) && !intro.IntroducedOn.After(to) {
+				plan.Nearby[a] = true
+			}
+		}
+
+		uses := s.slotUses(orgID, slots[baby.ID], from, to)
+		if len(uses) > 0 {
+			ids := make([]uint, 0, len(uses))
+			for _, u := range uses {
+				ids = append(ids, u.RecipeID)
+			}
+			var recipes []models.Recipe
+			if err := s.db.Where("id IN ?", ids).Find(&recipes).Error; err != nil {
+				return nil, err
+			}
+			byRecipe, err := s.recipeLines(ids)
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range recipes {
+				r.RecipeIngredients = byRecipe[r.ID]
+				for _, a := range plan.New(diet.Allergens(r)) {
+					plan.Nearby[a] = true
+				}
+			}
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+// slotUses returns the recipes served in the organization's meal slots of the given meal times
+// from from to to inclusive, including days generated earlier in the same run. A meal task
+// belongs to a meal time by MealTimeID, or by title when it has none (tasks created before
+// slots kept their meal time); every recipe of a meal counts, not only the legacy RecipeID.
+func (s *Scheduler) slotUses(orgID uint, mealTimes []models.MealTime, from, to models.Date) []recipeUse {
+	if len(mealTimes) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(mealTimes))
+	titles := make([]string, len(mealTimes))
+	for i, mt := range mealTimes {
+		ids[i], titles[i] = mt.ID, mt.TaskTitle()
+	}
+	var uses []recipeUse
+	err := s.db.Table("schedule_tasks").
+		Select("COALESCE(meal_recipes.recipe_id, schedule_tasks.recipe_id) AS recipe_id, daily_schedules.date").
+		Joins("JOIN daily_schedules ON daily_schedules.id = schedule_tasks.schedule_id").
+		Joins("LEFT JOIN meal_recipes ON meal_recipes.schedule_task_id = schedule_tasks.id").
+		Where("daily_schedules.organization_id = ?", orgID).
+		Where("daily_schedules.date >= ? AND daily_schedules.date <= ?", from, to).
+		Where("schedule_tasks.task_type = 'meal'").
+		Where("schedule_tasks.meal_time_id IN ? OR (schedule_tasks.meal_time_id IS NULL AND schedule_tasks.title IN ?)", ids, titles).
+		Where("COALESCE(meal_recipes.recipe_id, schedule_tasks.recipe_id) IS NOT NULL").
+		Scopes(s.withoutHidden).
+		Scan(&uses).Error
+	if err != nil {
+		log.Printf("Warning: failed to query meal slot recipes: %v", err)
+	}
+	for _, title := range titles {
+		uses = append(uses, s.output().plannedRecipes(title, from, to.AddDays(1))...)
+	}
+	return uses
+}
@@ -0,0 +1,158 @@
+package scheduler
+
+import (
+	"log"
+	"sort"
+
+	"podlevskikh/awesomeProject/internal/models"
+	"podlevskikh/awesomeProject/internal/pantry"
+	"podlevskikh/awesomeProject/internal/settings"
+)
+
+// expiringBoost is added to a recipe's weight multiplier for every soon-to-expire pantry
+// item it uses: a recipe using two such items is picked 1 + 2*2 = 5 times as often
+const expiringBoost = 2.0
+
+// ExpiringItem is a pantry item a recipe would use up before it goes bad
+type ExpiringItem struct {
+	Name      string      `json:"name"`
+	ExpiresOn models.Date `json:"expires_on"`
+}
+
+// Suggestion is a recipe ranked by the soon-to-expire pantry items it uses
+type Suggestion struct {
+	RecipeID uint           `json:"recipe_id"`
+	Name     string         `json:"name"`
+	Rating   float64        `json:"rating"`
+	Expiring []ExpiringItem `json:"expiring"`
+}
+
+// expiringUse returns, for each of the recipes, the pantry items expiring within withinDays
+// from date that the recipe uses. Recipes using none are absent.
+func (s *Scheduler) expiringUse(orgID uint, recipes []models.Recipe, date models.Date, withinDays int) (map[uint][]ExpiringItem, error) {
+	use := make(map[uint][]ExpiringItem)
+	if len(recipes) == 0 {
+		return use, nil
+	}
+	items, err := pantry.Expiring(s.db, orgID, date, withinDays)
+	if err != nil || len(items) == 0 {
+		return use, err
+	}
+
+	ids := make([]uint, len(recipes))
+	for i, r := range recipes {
+		ids[i] = r.ID
+	}
+	var lines []models.RecipeIngredient
+	if err := s.db.Where("recipe_id IN ?", ids).Order("recipe_id, position").Find(&lines).Error; err != nil {
+		return nil, err
+	}
+	return usedExpiring(lines, items), nil
+}
+
+// usedExpiring matches recipe ingredient lines against expiring pantry items: for each recipe,
+// the items its lines use, each item once. Items without an expiry date are ignored.
+func usedExpiring(lines []models.RecipeIngredient, items []models.PantryItem) map[uint][]ExpiringItem {
+	use := make(map[uint][]ExpiringItem)
+	seen := make(map[uint]map[uint]bool)
+	for _, line := range lines {
+		for _, item := range items {
+			if item.ExpiresOn == nil || !pantry.Uses(line, item) {
+				continue
+			}
+			if seen[line.RecipeID] == nil {
+				seen[line.RecipeID] = make(map[uint]bool)
+			}
+			if seen[line.RecipeID][item.ID] {
+				continue
+			}
+			seen[line.RecipeID][item.ID] = true
+			use[line.RecipeID] = append(use[line.RecipeID], ExpiringItem{Name: item.Name, ExpiresOn: *item.ExpiresOn})
+		}
+	}
+	return use
+}
+
+// expiringPreference returns how many days ahead counts as soon-to-expire when the organization
+// wants meal selection to prefer recipes using such pantry items (setting prefer_expiring_ingredients)
+func (s *Scheduler) expiringPreference(orgID uint) (int, bool) {
+	if s.settings == nil || !s.settings.Bool(orgID, settings.KeyPreferExpiring) {
+		return 0, false
+	}
+	return s.settings.Int(orgID, settings.KeyExpiringWithinDays), true
+}
+
+// SuggestRecipes ranks the organization's active recipes (or, with mealTimeID, the recipes
+// eligible for that meal time that suit the people it is for) by how many pantry items expiring within withinDays from date
+// they use. Recipes using none are left out.
+func (s *Scheduler) SuggestRecipes(orgID, mealTimeID uint, date models.Date, withinDays int) ([]Suggestion, error) {
+	var recipes []models.Recipe
+	var err error
+	if mealTimeID != 0 {
+		var mealTime models.MealTime
+		if err := s.db.Where("organization_id = ?", orgID).First(&mealTime, mealTimeID).Error; err != nil {
+			return nil, err
+		}
+		recipes, err = s.eligibleRecipes(orgID, mealTimeID, mealTime.Name)
+		if err == nil {
+			recipes, _, err = s.withoutConflicts(mealTime, recipes, date, mealTime.DefaultTime)
+		}
+	} else {
+		err = s.db.Where("organization_id = ? AND is_active = ?", orgID, true).Order("id").Find(&recipes).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	use, err := s.expiringUse(orgID, recipes, date, withinDays)
+	if err != nil {
+		return nil, err
+	}
+	return rankSuggestions(recipes, use), nil
+}
+
+// rankSuggestions turns the recipes using expiring items into suggestions: most expiring items
+// first, then the one that expires soonest, then the better rated
+func rankSuggestions(recipes []models.Recipe, use map[uint][]ExpiringItem) []Suggestion {
+	suggestions := []Suggestion{}
+	for _, r := range recipes {
+		if items := use[r.ID]; len(items) > 0 {
+			suggestions = append(suggestions, Suggestion{RecipeID: r.ID, Name: r.Name, Rating: r.Rating, Expiring: items})
+		}
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if len(a.Expiring) != len(b.Expiring) {
+			return len(a.Expiring) > len(b.Expiring)
+		}
+		if !a.Expiring[0].ExpiresOn.Equal(b.Expiring[0].ExpiresOn) {
+			return a.Expiring[0].ExpiresOn.Before(b.Expiring[0].ExpiresOn)
+		}
+		return a.Rating > b.Rating
+	})
+	return suggestions
+}
+
+// expiringBoosts returns weight multipliers for the slot's recipes using soon-to-expire
+// pantry items, or nil when the organization does not prefer them
+func (s *Scheduler) expiringBoosts(orgID uint, recipes []models.Recipe, date models.Date) map[uint]float64 {
+	withinDays, ok := s.expiringPreference(orgID)
+	if !ok {
+		return nil
+	}
+	use, err := s.expiringUse(orgID, recipes, date, withinDays)
+	if err != nil {
+		log.Printf("Warning: failed to check expiring pantry items for org %d: %v", orgID, err)
+		return nil
+	}
+	return boostsFor(use)
+}
+
+// boostsFor returns the weight multiplier of each recipe using expiring items
+func boostsFor(use map[uint][]ExpiringItem) map[uint]float64 {
+	boosts := make(map[uint]float64, len(use))
+	for id, items := range use {
+		boosts[id] = 1 + expiringBoost*float64(len(items))
+	}
+	return boosts
+}
@@ -0,0 +1,151 @@
+package scheduler
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"podlevskikh/awesomeProject/internal/models"
+)
+
+func TestUsedExpiring(t *testing.T) {
+	id := func(v uint) *uint { return &v }
+	date := func(day int) *models.Date { d := models.NewDate(2025, time.June, day); return &d }
+	items := []models.PantryItem{
+		{ID: 1, Name: "Milk", IngredientID: id(10), ExpiresOn: date(11)},
+		{ID: 2, Name: "Spinach", ExpiresOn: date(12)},
+		{ID: 3, Name: "Rice", IngredientID: id(30)}, // no expiry date
+	}
+	lines := []models.RecipeIngredient{
+		{RecipeID: 1, Name: "whole milk", IngredientID: id(10)},
+		{RecipeID: 1, Name: "Milk", IngredientID: id(10)}, // the same ingredient counts once
+		{RecipeID: 1, Name: "  SPINACH "},
+		{RecipeID: 2, Name: "spinach"},
+		{RecipeID: 2, Name: "Milk", IngredientID: id(11)}, // another catalog ingredient
+		{RecipeID: 3, Name: "rice", IngredientID: id(30)},
+		{RecipeID: 4, Name: "Spinach "},
+	}
+
+	use := usedExpiring(lines, items)
+	want := map[uint][]string{1: {"Milk", "Spinach"}, 2: {"Spinach"}, 4: {"Spinach"}}
+	if len(use) != len(want) {
+		t.Fatalf("usedExpiring = %+v, want recipes %v", use, want)
+	}
+	for recipeID, names := range want {
+		got := use[recipeID]
+		if len(got) != len(names) {
+			t.Errorf("recipe %d: %+v, want %v", recipeID, got, names)
+			continue
+		}
+		for i, name := range names {
+			if got[i].Name != name {
+				t.Errorf("recipe %d: %+v, want %v", recipeID, got, names)
+			}
+		}
+	}
+	if !use[1][0].ExpiresOn.Equal(*date(11)) {
+		t.Errorf("milk expires on %s, want %s", use[1][0].ExpiresOn, *date(11))
+	}
+	if _, ok := use[3]; ok {
+		t.Errorf("recipe 3 uses an item without expiry date: %+v", use[3])
+	}
+}
+
+func TestBoostsFor(t *testing.T) {
+	item := ExpiringItem{Name: "Milk", ExpiresOn: models.NewDate(2025, time.June, 11)}
+	boosts := boostsFor(map[uint][]ExpiringItem{1: {item}, 2: {item, item}, 3: {item, item, item}})
+	for recipeID, want := range map[uint]float64{1: 3, 2: 5, 3: 7} {
+		if boosts[recipeID] != want {
+			t.Errorf("recipe %d: boost %v, want %v", recipeID, boosts[recipeID], want)
+		}
+	}
+	if len(boosts) != 3 {
+		t.Errorf("boosts %v, want only the recipes using expiring items", boosts)
+	}
+}
+
+// More expiring items rank higher; on a tie the one expiring sooner, then the better rated
+func TestRankSuggestions(t *testing.T) {
+	on := func(day int) ExpiringItem {
+		return ExpiringItem{Name: "item", ExpiresOn: models.NewDate(2025, time.June, day)}
+	}
+	recipes := []models.Recipe{
+		{ID: 1, Name: "One late", Rating: 5},
+		{ID: 2, Name: "Two", Rating: 1},
+		{ID: 3, Name: "One soon, low rating", Rating: 2},
+		{ID: 4, Name: "Nothing expiring", Rating: 5},
+		{ID: 5, Name: "One soon, high rating", Rating: 4},
+		{ID: 6, Name: "One soon, same rating", Rating: 4},
+	}
+	use := map[uint][]ExpiringItem{
+		1: {on(14)},
+		2: {on(12), on(13)},
+		3: {on(11)},
+		5: {on(11)},
+		6: {on(11)},
+	}
+
+	got := rankSuggestions(recipes, use)
+	want := []uint{2, 5, 6, 3, 1}
+	if len(got) != len(want) {
+		t.Fatalf("rankSuggestions = %+v, want recipes %v", got, want)
+	}
+	for i, recipeID := range want {
+		if got[i].RecipeID != recipeID {
+			t.Errorf("position %d: recipe %d (%s), want %d", i, got[i].RecipeID, got[i].Name, recipeID)
+		}
+	}
+	if got[0].Name != "Two" || got[0].Rating != 1 || len(got[0].Expiring) != 2 {
+		t.Errorf("suggestion %+v does not carry the recipe and its items", got[0])
+	}
+	if got := rankSuggestions(recipes, nil); got == nil || len(got) != 0 {
+		t.Errorf("no expiring items: %+v, want an empty list", got)
+	}
+}
+
+// A recipe with a boost of 5 is picked five times as often as one without
+func TestPickBoostWeighting(t *testing.T) {
+	pool := []models.Recipe{{ID: 1}, {ID: 2}}
+	sc := &SelectionContext{Rand: rand.New(rand.NewSource(1)), Boosts: map[uint]float64{1: 5}}
+	const draws = 60000
+	boosted := 0
+	for i := 0; i < draws; i++ {
+		if sc.Pick(pool).ID == 1 {
+			boosted++
+		}
+	}
+	if share := float64(boosted) / draws; math.Abs(share-5.0/6) > 0.01 {
+		t.Errorf("boosted recipe picked %.3f of the time, want %.3f", share, 5.0/6)
+	}
+
+	// Rotation multiplies the recency weight: two unused recipes, one boosted by 3
+	rng := rand.New(rand.NewSource(2))
+	boosted = 0
+	for i := 0; i < draws; i++ {
+		if boostedPick(pool, nil, nil, DefaultRotationWeights, rng, map[uint]float64{2: 3}).recipe.ID == 2 {
+			boosted++
+		}
+	}
+	if share := float64(boosted) / draws; math.Abs(share-0.75) > 0.01 {
+		t.Errorf("boosted rotation pick %.3f of the time, want 0.75", share)
+	}
+}
+
+// Without boosts the pick is the plain uniform one it was before boosts existed
+func TestPickWithoutBoostsUnchanged(t *testing.T) {
+	pool := []models.Recipe{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}, {ID: 5}}
+	for _, key := range []string{"1/2025-03-03/Breakfast - adult@08:00/0", "1/2025-03-04/Lunch - adult@13:00/42", "7/2025-06-10/Dinner - child@18:30/3"} {
+		want := pool[DeterministicSource(key).Intn(len(pool))].ID
+		for _, boosts := range []map[uint]float64{nil, {}} {
+			sc := &SelectionContext{Rand: DeterministicSource(key), Boosts: boosts}
+			if got := sc.Pick(pool).ID; got != want {
+				t.Errorf("key %q, boosts %v: picked %d, want %d", key, boosts, got, want)
+			}
+		}
+		if got := weightedPick(pool, nil, nil, DefaultRotationWeights, DeterministicSource(key)).recipe.ID; got !=
+			boostedPick(pool, nil, nil, DefaultRotationWeights, DeterministicSource(key), nil).recipe.ID {
+			t.Errorf("key %q: weightedPick picked %d, boostedPick without boosts differs", key, got)
+		}
+	}
+}
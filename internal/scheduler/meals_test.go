@@ -0,0 +1,60 @@
+package scheduler
+
+import (
+	"testing"
+
+	"podlevskikh/awesomeProject/internal/models"
+)
+
+func TestFillsMealSlot(t *testing.T) {
+	breakfast := models.MealTime{ID: 1, Name: "Breakfast", FamilyMember: "adult"}
+	lunch := models.MealTime{ID: 2, Name: "Lunch", FamilyMember: "adult"}
+	id := func(v uint) *uint { return &v }
+
+	tests := []struct {
+		name     string
+		task     models.ScheduleTask
+		mealTime models.MealTime
+		slot     string
+		want     bool
+	}{
+		{
+			name:     "generated task",
+			task:     models.ScheduleTask{Title: "Breakfast - adult", Time: "08:00", MealTimeID: id(1), MealSlot: "08:00"},
+			mealTime: breakfast, slot: "08:00", want: true,
+		},
+		{
+			// Regenerating after an admin moved breakfast to 09:30 must not add another breakfast at 08:00
+			name:     "time edited",
+			task:     models.ScheduleTask{Title: "Breakfast - adult", Time: "09:30", MealTimeID: id(1), MealSlot: "08:00", Edited: true},
+			mealTime: breakfast, slot: "08:00", want: true,
+		},
+		{
+			name:     "other slot of the same meal time",
+			task:     models.ScheduleTask{Title: "Breakfast - adult", Time: "08:00", MealTimeID: id(1), MealSlot: "08:00"},
+			mealTime: breakfast, slot: "10:00", want: false,
+		},
+		{
+			name:     "edited onto another meal time's slot",
+			task:     models.ScheduleTask{Title: "Breakfast - adult", Time: "13:00", MealTimeID: id(1), MealSlot: "08:00", Edited: true},
+			mealTime: lunch, slot: "13:00", want: false,
+		},
+		{
+			name:     "task without stored slot",
+			task:     models.ScheduleTask{Title: "Lunch - adult", Time: "13:00"},
+			mealTime: lunch, slot: "13:00", want: true,
+		},
+		{
+			name:     "menu cell with slot but no meal time",
+			task:     models.ScheduleTask{Title: "Lunch - adult", Time: "14:00", MealSlot: "13:00", Edited: true},
+			mealTime: lunch, slot: "13:00", want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fillsMealSlot(tt.task, tt.mealTime, tt.slot); got != tt.want {
+				t.Errorf("fillsMealSlot() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
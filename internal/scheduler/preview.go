@@ -0,0 +1,353 @@
+package scheduler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"podlevskikh/awesomeProject/internal/childcare"
+	"podlevskikh/awesomeProject/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// previewTTL is how long a preview can be accepted after it was created
+const previewTTL = 30 * time.Minute
+
+var (
+	// ErrPreviewClosed is returned when a preview was already accepted or rejected
+	ErrPreviewClosed = errors.New("preview is already accepted or rejected")
+	// ErrPreviewExpired is returned when a preview is accepted after previewTTL
+	ErrPreviewExpired = errors.New("preview has expired, create a new one")
+	// ErrPreviewStale is returned when the schedules changed since the preview was made
+	ErrPreviewStale = errors.New("schedules changed since the preview was made, create a new one")
+)
+
+// Preview is a proposed regeneration: the schedules as they would look after it, the tasks it
+// would remove and the reason behind every generator decision. Making one writes nothing.
+type Preview struct {
+	ID        uint                 `json:"id,omitempty"`
+	Status    models.PreviewStatus `json:"status,omitempty"`
+	ExpiresAt *time.Time           `json:"expires_at,omitempty"`
+	From      models.Date          `json:"from"`
+	To        models.Date          `json:"to"`
+	TaskTypes []string             `json:"task_types"`
+	Reshuffle bool                 `json:"reshuffle"`
+	// Schedules are the proposed days; kept tasks have their IDs, proposed tasks have id 0
+	Schedules []models.DailySchedule `json:"schedules"`
+	// Childcare are the blocks the proposed childcare tasks expand from weekly patterns
+	Childcare []models.ChildcareSchedule `json:"childcare"`
+	Removed   []TaskChange               `json:"removed"`
+	Kept      int                        `json:"kept"`
+	Decisions []Decision                 `json:"decisions"`
+
+	fingerprint string // state of the tasks in range the preview was made against
+}
+
+// PreviewSchedules runs the regeneration described by opts against an in-memory sink and
+// returns what it would produce. The database is left untouched and no rows are locked.
+func (s *Scheduler) PreviewSchedules(orgID uint, opts RegenerateOptions) (*Preview, error) {
+	types, err := taskTypeFilter(opts.TaskTypes)
+	if err != nil {
+		return nil, err
+	}
+	if !opts.From.Before(opts.To) {
+		return nil, fmt.Errorf("empty date range %s – %s", opts.From, opts.To)
+	}
+
+	p := &Preview{
+		From:      opts.From,
+		To:        opts.To,
+		TaskTypes: typeList(types),
+		Reshuffle: opts.Reshuffle,
+		Schedules: []models.DailySchedule{},
+		Childcare: []models.ChildcareSchedule{},
+		Removed:   []TaskChange{},
+		Decisions: []Decision{},
+	}
+
+	// Nothing is deleted: the replaced tasks are hidden from the generator by the memory sink
+	before, err := s.tasksInRange(orgID, opts.From, opts.To)
+	if err != nil {
+		return nil, err
+	}
+	p.fingerprint = fingerprint(before)
+
+	removed, kept := splitReplaceable(before, types)
+	for _, t := range removed {
+		p.Removed = append(p.Removed, t.change())
+	}
+	p.Kept = len(kept)
+
+	out := &memorySink{removed: taskIDs(removed)}
+	dry := s.withDB(s.db)
+	dry.out = out
+	for date := opts.From; date.Before(opts.To); date = date.AddDays(1) {
+		if err := dry.generateDay(orgID, date, types, opts.Reshuffle); err != nil {
+			return nil, fmt.Errorf("failed to generate %s: %w", date, err)
+		}
+	}
+
+	for _, sch := range out.schedules {
+		if len(sch.Tasks) > 0 {
+			p.Schedules = append(p.Schedules, *sch)
+		}
+	}
+	p.Childcare = append(p.Childcare, out.childcare...)
+	p.Decisions = append(p.Decisions, out.decisions...)
+	return p, nil
+}
+
+// CreatePreview makes a preview and stores it so it can be accepted or rejected later
+func (s *Scheduler) CreatePreview(orgID, userID uint, opts RegenerateOptions) (*Preview, error) {
+	p, err := s.PreviewSchedules(orgID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode preview: %w", err)
+	}
+	record := models.SchedulePreview{
+		OrganizationID:  orgID,
+		CreatedByUserID: userID,
+		DateFrom:        p.From,
+		DateTo:          p.To,
+		TaskTypes:       strings.Join(p.TaskTypes, ","),
+		Status:          models.PreviewPending,
+		Payload:         string(payload),
+		Fingerprint:     p.fingerprint,
+		ExpiresAt:       time.Now().Add(previewTTL),
+	}
+	if err := s.db.Create(&record).Error; err != nil {
+		return nil, fmt.Errorf("failed to save preview: %w", err)
+	}
+
+	p.ID = record.ID
+	p.Status = record.Status
+	p.ExpiresAt = &record.ExpiresAt
+	return p, nil
+}
+
+// GetPreview loads a stored preview of the organization. A pending preview past its
+// expiry is reported as expired.
+func (s *Scheduler) GetPreview(orgID, id uint) (*Preview, error) {
+	var record models.SchedulePreview
+	if err := s.db.Where("organization_id = ?", orgID).First(&record, id).Error; err != nil {
+		return nil, err
+	}
+	return decodePreview(record)
+}
+
+// AcceptPreview applies a pending preview as a whole in one transaction: the previewed tasks
+// are removed and the proposed ones are created exactly as shown. It fails with
+// ErrPreviewStale if the tasks in range changed since the preview was made.
+func (s *Scheduler) AcceptPreview(orgID, id, userID uint) (*RegenerateDiff, error) {
+	var diff *RegenerateDiff
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var record models.SchedulePreview
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("organization_id = ?", orgID).First(&record, id).Error; err != nil {
+			return err
+		}
+		p, err := decodePreview(record)
+		if err != nil {
+			return err
+		}
+		switch p.Status {
+		case models.PreviewPending:
+		case models.PreviewExpired:
+			return ErrPreviewExpired
+		default:
+			return ErrPreviewClosed
+		}
+
+		txs := s.withDB(tx)
+		before, err := txs.tasksInRange(orgID, p.From, p.To)
+		if err != nil {
+			return err
+		}
+		if fingerprint(before) != record.Fingerprint {
+			return ErrPreviewStale
+		}
+
+		diff, err = txs.applyPreview(orgID, p)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		return tx.Model(&record).Updates(map[string]interface{}{
+			"status":             models.PreviewAccepted,
+			"decided_by_user_id": userID,
+			"decided_at":         now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Accepted preview %d for org %d: %d removed, %d added, %d kept",
+		id, orgID, len(diff.Removed), len(diff.Added), diff.Kept)
+	return diff, nil
+}
+
+// RejectPreview discards a pending preview
+func (s *Scheduler) RejectPreview(orgID, id, userID uint) error {
+	var record models.SchedulePreview
+	if err := s.db.Where("organization_id = ?", orgID).First(&record, id).Error; err != nil {
+		return err
+	}
+	if record.Status != models.PreviewPending {
+		return ErrPreviewClosed
+	}
+
+	now := time.Now()
+	result := s.db.Model(&models.SchedulePreview{}).
+		Where("id = ? AND status = ?", record.ID, models.PreviewPending).
+		Updates(map[string]interface{}{
+			"status":             models.PreviewRejected,
+			"decided_by_user_id": userID,
+			"decided_at":         now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrPreviewClosed
+	}
+	return nil
+}
+
+// applyPreview writes a preview: clears the range like a regeneration, creates the childcare blocks
+// expanded from patterns and the proposed tasks
+func (s *Scheduler) applyPreview(orgID uint, p *Preview) (*RegenerateDiff, error) {
+	types, err := taskTypeFilter(p.TaskTypes)
+	if err != nil {
+		return nil, err
+	}
+	diff := &RegenerateDiff{From: p.From, To: p.To, Removed: []TaskChange{}, Added: []TaskChange{}}
+
+	removed, kept, err := s.clearRange(orgID, p.From, p.To, types)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range removed {
+		diff.Removed = append(diff.Removed, t.change())
+	}
+	diff.Kept = len(kept)
+
+	for _, block := range p.Childcare {
+		if err := s.applyChildcareBlock(orgID, block); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, proposed := range p.Schedules {
+		var schedule models.DailySchedule
+		err := s.db.Where("organization_id = ? AND date = ?", orgID, proposed.Date).First(&schedule).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("failed to load daily schedule: %w", err)
+		}
+		schedule.OrganizationID = orgID
+		schedule.Date = proposed.Date
+		schedule.Generated = true
+		schedule.Seed = proposed.Seed
+		schedule.CapacityMinutes = proposed.CapacityMinutes
+		schedule.PlannedMinutes = proposed.PlannedMinutes
+		schedule.Warnings = proposed.Warnings
+		if err := (dbSink{}).saveSchedule(s.db, &schedule); err != nil {
+			return nil, err
+		}
+
+		for _, t := range proposed.Tasks {
+			if t.ID != 0 {
+				continue // kept task, already in the schedule
+			}
+			task := t
+			task.OrganizationID = orgID
+			task.ScheduleID = schedule.ID
+			task.CreatedAt, task.UpdatedAt = time.Time{}, time.Time{}
+			if err := s.db.Omit(clause.Associations).Create(&task).Error; err != nil {
+				return nil, fmt.Errorf("failed to create schedule task: %w", err)
+			}
+			if err := linkRecipe(s.db, &task); err != nil {
+				return nil, err
+			}
+			diff.Added = append(diff.Added, datedTask{task: task, date: schedule.Date}.change())
+		}
+	}
+
+	if err := s.deleteEmptySchedules(orgID, p.From, p.To); err != nil {
+		return nil, err
+	}
+	return diff, nil
+}
+
+// applyChildcareBlock creates a block a preview expanded from a childcare pattern, unless the
+// pattern was expanded for that date since the preview was made
+func (s *Scheduler) applyChildcareBlock(orgID uint, block models.ChildcareSchedule) error {
+	if block.PatternID == nil {
+		return nil
+	}
+	origin := childcare.Origin(block)
+	blocks, err := s.patternBlocks(orgID, origin)
+	if err != nil {
+		return err
+	}
+	if childcare.Expanded(blocks, origin)[*block.PatternID] {
+		return nil
+	}
+	block.ID = 0
+	block.OrganizationID = orgID
+	block.CreatedAt, block.UpdatedAt = time.Time{}, time.Time{}
+	return (dbSink{}).saveChildcare(s.db, &block)
+}
+
+// decodePreview restores a Preview from its stored record
+func decodePreview(record models.SchedulePreview) (*Preview, error) {
+	var p Preview
+	if err := json.Unmarshal([]byte(record.Payload), &p); err != nil {
+		return nil, fmt.Errorf("failed to decode preview %d: %w", record.ID, err)
+	}
+	p.ID = record.ID
+	p.Status = record.Status
+	if p.Status == models.PreviewPending && time.Now().After(record.ExpiresAt) {
+		p.Status = models.PreviewExpired
+	}
+	expiresAt := record.ExpiresAt
+	p.ExpiresAt = &expiresAt
+	p.fingerprint = record.Fingerprint
+	return &p, nil
+}
+
+// fingerprint summarizes the tasks of a range in a way that changes whenever a regeneration
+// would treat them differently (added, deleted, completed, edited or locked tasks)
+func fingerprint(tasks []datedTask) string {
+	parts := make([]string, 0, len(tasks))
+	for _, t := range tasks {
+		parts = append(parts, fmt.Sprintf("%d:%s:%t:%t:%t", t.task.ID, t.date, t.task.Completed, t.task.Edited, t.task.Locked))
+	}
+	sort.Strings(parts)
+	sum := sha256.Sum256([]byte(strings.Join(parts, ",")))
+	return hex.EncodeToString(sum[:])
+}
+
+// typeList returns the task types of a filter in GeneratedTaskTypes order
+func typeList(types map[string]bool) []string {
+	var list []string
+	for _, t := range GeneratedTaskTypes {
+		if types[t] {
+			list = append(list, t)
+		}
+	}
+	return list
+}
@@ -0,0 +1,44 @@
+package scheduler
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+
+	"podlevskikh/awesomeProject/internal/models"
+)
+
+// RandSource returns the random generator for a single generator decision. The key identifies
+// the decision: organization, date, slot and the day's seed.
+type RandSource func(key string) *rand.Rand
+
+// DeterministicSource derives the generator from the key alone, so generating the same day
+// again gives the same result as long as recipes, history and the day's seed are unchanged.
+func DeterministicSource(key string) *rand.Rand {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return rand.New(rand.NewSource(int64(h.Sum64())))
+}
+
+// SetRandSource replaces the random source, e.g. with a fixed one in tests
+func (s *Scheduler) SetRandSource(src RandSource) {
+	s.rand = src
+}
+
+// randFor returns the generator for a slot of the schedule
+func (s *Scheduler) randFor(schedule *models.DailySchedule, slot string) *rand.Rand {
+	src := s.rand
+	if src == nil {
+		src = DeterministicSource
+	}
+	return src(fmt.Sprintf("%d/%s/%s/%d", schedule.OrganizationID, schedule.Date, slot, schedule.Seed))
+}
+
+// newSeed picks a fresh seed for a reshuffled day
+func newSeed() int64 {
+	for {
+		if seed := rand.Int63(); seed != 0 {
+			return seed
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package scheduler
+
+import (
+	"podlevskikh/awesomeProject/internal/models"
+	"podlevskikh/awesomeProject/internal/recurrence"
+	"podlevskikh/awesomeProject/internal/timeline"
+)
+
+// generateRecurringTasks creates the occurrences of the organization's active recurring tasks
+// that fall on date. Timed occurrences keep the series' time; flexible ones are placed into the
+// free time the meals and childcare leave. Occurrences kept from a previous generation (edited,
+// moved or completed) are not created again; an occurrence moved here from another day does not
+// stand for this day's own one.
+func (s *Scheduler) generateRecurringTasks(schedule *models.DailySchedule, date models.Date) error {
+	var series []models.RecurringTask
+	if err := s.db.Where("organization_id = ? AND active = ?", schedule.OrganizationID, true).
+		Order("id").Find(&series).Error; err != nil {
+		return err
+	}
+
+	var flexible []*models.ScheduleTask
+	for _, rt := range series {
+		decision := Decision{Date: date, Kind: "recurring", Subject: rt.Title, Time: rt.Time}
+		if !occursOn(rt, date) {
+			continue
+		}
+		seriesID, occurrence := rt.ID, date
+		if hasTask(schedule, "recurring", func(t models.ScheduleTask) bool {
+			return recurrence.IsOccurrence(t, date, seriesID, date)
+		}) {
+			decision.Reason = "occurrence kept from a previous generation"
+			s.output().decide(decision)
+			continue
+		}
+
+		task := &models.ScheduleTask{
+			OrganizationID:   schedule.OrganizationID,
+			ScheduleID:       schedule.ID,
+			TaskType:         "recurring",
+			Time:             rt.Time,
+			Duration:         rt.Duration,
+			Title:            rt.Title,
+			Description:      rt.Description,
+			TaskCategoryID:   rt.TaskCategoryID,
+			AssignedToUserID: rt.AssignedToUserID,
+			RecurringTaskID:  &seriesID,
+			OccurrenceDate:   &occurrence,
+		}
+		decision.Choice = rt.RRule
+		decision.Reason = "occurrence of the recurring task"
+		s.output().decide(decision)
+		if rt.Time == "" {
+			flexible = append(flexible, task)
+			continue
+		}
+		if start, err := timeline.ParseClock(rt.Time); err == nil {
+			task.EndTime = timeline.Clock(min(start+rt.Duration, timeline.Day.End))
+		}
+		if err := s.output().saveTask(s.db, schedule, task); err != nil {
+			return err
+		}
+		schedule.Tasks = append(schedule.Tasks, *task)
+	}
+
+	if err := s.placeTasks(schedule, flexible, make([]string, len(flexible))); err != nil {
+		return err
+	}
+	for _, task := range flexible {
+		if err := s.output().saveTask(s.db, schedule, task); err != nil {
+			return err
+		}
+		schedule.Tasks = append(schedule.Tasks, *task)
+	}
+	return nil
+}
+
+// occursOn reports whether the series has an occurrence on date. Series with an invalid rule
+// (rules are validated when saved) have none.
+func occursOn(rt models.RecurringTask, date models.Date) bool {
+	dates, err := recurrence.Occurrences(rt, date, date.AddDays(1))
+	return err == nil && len(dates) > 0
+}
+
+// RefreshRecurring regenerates the recurring task occurrences of the organization's schedules
+// from the given date on, after a series was created, edited or removed. Edited and completed
+// occurrences are kept; days without a schedule yet get theirs when generated.
+func (s *Scheduler) RefreshRecurring(orgID uint, from models.Date) (*RegenerateDiff, error) {
+	return s.refresh(orgID, from, "recurring")
+}
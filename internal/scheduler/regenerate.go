@@ -0,0 +1,246 @@
+package scheduler
+
+import (
+	"fmt"
+	"log"
+
+	"podlevskikh/awesomeProject/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// GeneratedTaskTypes are the task types produced by the generator and replaced on regeneration
+var GeneratedTaskTypes = []string{"meal", "cleaning", "childcare", "recurring"}
+
+// RegenerateOptions selects what RegenerateSchedules replaces
+type RegenerateOptions struct {
+	From      models.Date // first date, inclusive
+	To        models.Date // last date, exclusive
+	TaskTypes []string    // subset of GeneratedTaskTypes; empty means all of them
+	Reshuffle bool        // pick new seeds instead of reproducing the previous random choices
+}
+
+// TaskChange describes a task removed or added by a regeneration
+type TaskChange struct {
+	ID          uint        `json:"id"`
+	Date        models.Date `json:"date"`
+	TaskType    string      `json:"task_type"`
+	Time        string      `json:"time"`
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	RecipeID    *uint       `json:"recipe_id,omitempty"`
+	ZoneID      *uint       `json:"zone_id,omitempty"`
+}
+
+// RegenerateDiff is the structured result of a regeneration
+type RegenerateDiff struct {
+	From    models.Date  `json:"from"`
+	To      models.Date  `json:"to"`
+	Removed []TaskChange `json:"removed"`
+	Added   []TaskChange `json:"added"`
+	Kept    int          `json:"kept"` // custom, edited, locked and completed tasks left untouched
+}
+
+// RegenerateSchedules replaces the generated tasks of one organization in [From, To) in a single
+// transaction. Custom, manually edited, locked and completed tasks are kept and their slots are not
+// generated again, so running it twice in a row does not duplicate anything.
+func (s *Scheduler) RegenerateSchedules(orgID uint, opts RegenerateOptions) (*RegenerateDiff, error) {
+	types, err := taskTypeFilter(opts.TaskTypes)
+	if err != nil {
+		return nil, err
+	}
+	if !opts.From.Before(opts.To) {
+		return nil, fmt.Errorf("empty date range %s – %s", opts.From, opts.To)
+	}
+
+	diff := &RegenerateDiff{From: opts.From, To: opts.To, Removed: []TaskChange{}, Added: []TaskChange{}}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		txs := s.withDB(tx)
+
+		removed, kept, err := txs.clearRange(orgID, opts.From, opts.To, types)
+		if err != nil {
+			return err
+		}
+		existing := make(map[uint]bool, len(removed))
+		for _, t := range removed {
+			existing[t.task.ID] = true
+			diff.Removed = append(diff.Removed, t.change())
+		}
+		for _, t := range kept {
+			existing[t.task.ID] = true
+		}
+		diff.Kept = len(kept)
+
+		for date := opts.From; date.Before(opts.To); date = date.AddDays(1) {
+			if err := txs.generateDay(orgID, date, types, opts.Reshuffle); err != nil {
+				return fmt.Errorf("failed to generate %s: %w", date, err)
+			}
+		}
+
+		if err := txs.deleteEmptySchedules(orgID, opts.From, opts.To); err != nil {
+			return err
+		}
+
+		after, err := txs.tasksInRange(orgID, opts.From, opts.To)
+		if err != nil {
+			return err
+		}
+		for _, t := range after {
+			if !existing[t.task.ID] {
+				diff.Added = append(diff.Added, t.change())
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Regenerated org %d schedules %s – %s: %d removed, %d added, %d kept",
+		orgID, opts.From, opts.To, len(diff.Removed), len(diff.Added), diff.Kept)
+	return diff, nil
+}
+
+// refresh regenerates the given task types of the organization's schedules from the given date
+// to the last generated day. It does nothing when there are no schedules from that date on.
+func (s *Scheduler) refresh(orgID uint, from models.Date, taskTypes ...string) (*RegenerateDiff, error) {
+	var last models.DailySchedule
+	err := s.db.Where("organization_id = ? AND date >= ?", orgID, from).Order("date DESC").Limit(1).Find(&last).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load schedules: %w", err)
+	}
+	if last.ID == 0 {
+		return &RegenerateDiff{From: from, To: from, Removed: []TaskChange{}, Added: []TaskChange{}}, nil
+	}
+	return s.RegenerateSchedules(orgID, RegenerateOptions{From: from, To: last.Date.AddDays(1), TaskTypes: taskTypes})
+}
+
+// withDB returns a copy of the scheduler working on another DB handle (e.g. a transaction)
+func (s *Scheduler) withDB(db *gorm.DB) *Scheduler {
+	c := *s
+	c.db = db
+	return &c
+}
+
+// clearRange deletes the replaceable tasks of the organization in [from, to) together with their
+// meal_recipes and task_zones rows and marks the schedules in range as not generated.
+// It returns the removed and the kept tasks.
+func (s *Scheduler) clearRange(orgID uint, from, to models.Date, types map[string]bool) (removed, kept []datedTask, err error) {
+	before, err := s.tasksInRange(orgID, from, to)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	removed, kept = splitReplaceable(before, types)
+	removeIDs := taskIDs(removed)
+	if len(removeIDs) > 0 {
+		if err := s.db.Exec("DELETE FROM meal_recipes WHERE schedule_task_id IN ?", removeIDs).Error; err != nil {
+			return nil, nil, fmt.Errorf("failed to delete meal_recipes: %w", err)
+		}
+		if err := s.db.Exec("DELETE FROM task_zones WHERE schedule_task_id IN ?", removeIDs).Error; err != nil {
+			return nil, nil, fmt.Errorf("failed to delete task_zones: %w", err)
+		}
+		if err := s.db.Where("id IN ?", removeIDs).Delete(&models.ScheduleTask{}).Error; err != nil {
+			return nil, nil, fmt.Errorf("failed to delete schedule tasks: %w", err)
+		}
+	}
+
+	if err := s.db.Model(&models.DailySchedule{}).
+		Where("organization_id = ? AND date >= ? AND date < ?", orgID, from, to).
+		Update("generated", false).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to reset generated flag: %w", err)
+	}
+	return removed, kept, nil
+}
+
+// deleteEmptySchedules drops schedules in [from, to) left without tasks (e.g. a day that became a holiday)
+func (s *Scheduler) deleteEmptySchedules(orgID uint, from, to models.Date) error {
+	if err := s.db.Where("organization_id = ? AND date >= ? AND date < ?", orgID, from, to).
+		Where("id NOT IN (SELECT DISTINCT schedule_id FROM schedule_tasks)").
+		Delete(&models.DailySchedule{}).Error; err != nil {
+		return fmt.Errorf("failed to delete empty daily schedules: %w", err)
+	}
+	return nil
+}
+
+// datedTask is a schedule task together with its schedule date
+type datedTask struct {
+	task models.ScheduleTask
+	date models.Date
+}
+
+func (t datedTask) change() TaskChange {
+	return TaskChange{
+		ID:          t.task.ID,
+		Date:        t.date,
+		TaskType:    t.task.TaskType,
+		Time:        t.task.Time,
+		Title:       t.task.Title,
+		Description: t.task.Description,
+		RecipeID:    t.task.RecipeID,
+		ZoneID:      t.task.ZoneID,
+	}
+}
+
+// tasksInRange loads the organization's tasks scheduled in [from, to), ordered by date and time
+func (s *Scheduler) tasksInRange(orgID uint, from, to models.Date) ([]datedTask, error) {
+	var schedules []models.DailySchedule
+	if err := s.db.Preload("Tasks", func(db *gorm.DB) *gorm.DB { return db.Order("time, id") }).
+		Where("organization_id = ? AND date >= ? AND date < ?", orgID, from, to).
+		Order("date").Find(&schedules).Error; err != nil {
+		return nil, fmt.Errorf("failed to load schedules: %w", err)
+	}
+
+	var tasks []datedTask
+	for _, sch := range schedules {
+		for _, t := range sch.Tasks {
+			tasks = append(tasks, datedTask{task: t, date: sch.Date})
+		}
+	}
+	return tasks, nil
+}
+
+// splitReplaceable separates the tasks a regeneration of the given types replaces from the kept ones
+func splitReplaceable(tasks []datedTask, types map[string]bool) (removed, kept []datedTask) {
+	for _, t := range tasks {
+		if isReplaceable(t.task, types) {
+			removed = append(removed, t)
+		} else {
+			kept = append(kept, t)
+		}
+	}
+	return removed, kept
+}
+
+func taskIDs(tasks []datedTask) []uint {
+	ids := make([]uint, len(tasks))
+	for i, t := range tasks {
+		ids[i] = t.task.ID
+	}
+	return ids
+}
+
+// isReplaceable reports whether a regeneration may delete the task
+func isReplaceable(t models.ScheduleTask, types map[string]bool) bool {
+	return types[t.TaskType] && !t.Completed && !t.Edited && !t.Locked
+}
+
+// taskTypeFilter validates requested task types and turns them into a set
+func taskTypeFilter(requested []string) (map[string]bool, error) {
+	if len(requested) == 0 {
+		requested = GeneratedTaskTypes
+	}
+	known := make(map[string]bool, len(GeneratedTaskTypes))
+	for _, t := range GeneratedTaskTypes {
+		known[t] = true
+	}
+	types := make(map[string]bool, len(requested))
+	for _, t := range requested {
+		if !known[t] {
+			return nil, fmt.Errorf("unknown task type %q", t)
+		}
+		types[t] = true
+	}
+	return types, nil
+}
@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"podlevskikh/awesomeProject/internal/models"
+)
+
+// Cells changed in the menu planner are edited or locked and survive a regeneration
+func TestIsReplaceable(t *testing.T) {
+	meals := map[string]bool{"meal": true}
+	tests := []struct {
+		task models.ScheduleTask
+		want bool
+	}{
+		{models.ScheduleTask{TaskType: "meal"}, true},
+		{models.ScheduleTask{TaskType: "meal", Edited: true}, false},
+		{models.ScheduleTask{TaskType: "meal", Locked: true}, false},
+		{models.ScheduleTask{TaskType: "meal", Completed: true}, false},
+		{models.ScheduleTask{TaskType: "cleaning"}, false},
+	}
+	for _, tt := range tests {
+		if got := isReplaceable(tt.task, meals); got != tt.want {
+			t.Errorf("isReplaceable(%+v) = %v, want %v", tt.task, got, tt.want)
+		}
+	}
+}
+
+// A preview hides the tasks it replaces instead of deleting them
+func TestMemorySinkLoadHidesReplacedTasks(t *testing.T) {
+	before := []datedTask{
+		{task: models.ScheduleTask{ID: 1, TaskType: "meal"}},
+		{task: models.ScheduleTask{ID: 2, TaskType: "meal", Locked: true}},
+		{task: models.ScheduleTask{ID: 3, TaskType: "cleaning"}},
+	}
+	removed, kept := splitReplaceable(before, map[string]bool{"meal": true})
+	if len(removed) != 1 || len(kept) != 2 {
+		t.Fatalf("removed %d, kept %d; want 1, 2", len(removed), len(kept))
+	}
+
+	out := &memorySink{removed: taskIDs(removed)}
+	schedule := models.DailySchedule{Generated: true}
+	for _, dt := range before {
+		schedule.Tasks = append(schedule.Tasks, dt.task)
+	}
+	out.load(&schedule)
+	if schedule.Generated || len(schedule.Tasks) != 2 || schedule.Tasks[0].ID != 2 || schedule.Tasks[1].ID != 3 {
+		t.Errorf("loaded schedule: generated %v, tasks %+v; want not generated with tasks 2 and 3", schedule.Generated, schedule.Tasks)
+	}
+	if got := out.hiddenTasks(); len(got) != 1 || got[0] != 1 {
+		t.Errorf("hiddenTasks() = %v, want [1]", got)
+	}
+}
+
+// A preview keeps the childcare blocks it expands from patterns instead of writing them
+func TestMemorySinkHoldsChildcareBlocks(t *testing.T) {
+	patternID := uint(3)
+	monday := models.NewDate(2025, time.June, 2)
+	out := &memorySink{}
+	for _, date := range []models.Date{monday, monday.AddDays(1)} {
+		block := models.ChildcareSchedule{Date: date, OriginDate: &date, PatternID: &patternID, StartTime: "09:00", EndTime: "12:00"}
+		if err := out.saveChildcare(nil, &block); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := out.plannedChildcare(monday); len(got) != 1 || !got[0].Date.Equal(monday) {
+		t.Errorf("plannedChildcare(%s) = %+v, want the Monday block", monday, got)
+	}
+	if got := (dbSink{}).plannedChildcare(monday); got != nil {
+		t.Errorf("dbSink holds blocks: %+v", got)
+	}
+}
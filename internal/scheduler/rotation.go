@@ -0,0 +1,208 @@
+package scheduler
+
+import (
+	"log"
+	"math/rand"
+	"sort"
+
+	"podlevskikh/awesomeProject/internal/models"
+	"podlevskikh/awesomeProject/internal/settings"
+)
+
+// WeightTier gives recipes last used at least MinDays ago the weight Weight
+type WeightTier struct {
+	MinDays int     `json:"min_days"`
+	Weight  float64 `json:"weight"`
+}
+
+// RotationWeights configures the weighted recipe rotation (setting recipe_rotation_weights)
+type RotationWeights struct {
+	NeverUsed    float64      `json:"never_used"`    // weight of recipes not used within the lookback
+	LookbackDays int          `json:"lookback_days"` // how far back the history is analysed
+	Tiers        []WeightTier `json:"tiers"`
+}
+
+// DefaultRotationWeights are the weights from RECIPE_ROTATION_ALGORITHM.md
+var DefaultRotationWeights = RotationWeights{
+	NeverUsed:    5.0,
+	LookbackDays: 30,
+	Tiers: []WeightTier{
+		{MinDays: 21, Weight: 3.0},
+		{MinDays: 14, Weight: 2.0},
+		{MinDays: 7, Weight: 1.5},
+		{MinDays: 5, Weight: 1.0},
+		{MinDays: 4, Weight: 0.8},
+		{MinDays: 3, Weight: 0.5},
+		{MinDays: 2, Weight: 0.3},
+		{MinDays: 1, Weight: 0.1},
+		{MinDays: 0, Weight: 0.05},
+	},
+}
+
+// weight returns the weight of a recipe last used daysSince days ago
+func (w RotationWeights) weight(daysSince int) float64 {
+	tiers := append([]WeightTier(nil), w.Tiers...)
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].MinDays > tiers[j].MinDays })
+	for _, t := range tiers {
+		if daysSince >= t.MinDays {
+			return t.Weight
+		}
+	}
+	return w.NeverUsed
+}
+
+// rotationWeights returns the organization's weights, falling back to the defaults
+func (s *Scheduler) rotationWeights(orgID uint) RotationWeights {
+	w := DefaultRotationWeights
+	if s.settings == nil {
+		return w
+	}
+	var custom RotationWeights
+	if err := s.settings.JSON(orgID, settings.KeyRecipeRotationWeights, &custom); err != nil {
+		log.Printf("Warning: org %d has invalid %s, using defaults: %v", orgID, settings.KeyRecipeRotationWeights, err)
+		return w
+	}
+	if custom.NeverUsed > 0 {
+		w.NeverUsed = custom.NeverUsed
+	}
+	if custom.LookbackDays > 0 {
+		w.LookbackDays = custom.LookbackDays
+	}
+	if len(custom.Tiers) > 0 {
+		w.Tiers = custom.Tiers
+	}
+	return w
+}
+
+// getRecentlyUsedRecipes returns, for every recipe used in the organization's meal slot with the
+// given title within lookbackDays before currentDate (and earlier on currentDate itself), the
+// number of days since its last use.
+// Filtering by title (e.g. "Breakfast - adult") ensures we only consider the same meal slot,
+// so recipes used at lunch don't affect breakfast choices.
+func (s *Scheduler) getRecentlyUsedRecipes(orgID uint, taskTitle string, currentDate models.Date, lookbackDays int) map[uint]int {
+	recentlyUsed := make(map[uint]int)
+	startDate := currentDate.AddDays(-lookbackDays)
+
+	var uses []recipeUse
+	err := s.db.Table("schedule_tasks").
+		Select("schedule_tasks.recipe_id, daily_schedules.date").
+		Joins("JOIN daily_schedules ON daily_schedules.id = schedule_tasks.schedule_id").
+		Where("daily_schedules.organization_id = ?", orgID).
+		Where("daily_schedules.date >= ? AND daily_schedules.date <= ?", startDate, currentDate).
+		Where("schedule_tasks.task_type = 'meal'").
+		Where("schedule_tasks.title = ?", taskTitle).
+		Where("schedule_tasks.recipe_id IS NOT NULL").
+		Scopes(s.withoutHidden).
+		Scan(&uses).Error
+	if err != nil {
+		log.Printf("Warning: failed to query recently used recipes: %v", err)
+	}
+	// Days generated earlier in the same run that are not in the database yet
+	uses = append(uses, s.output().plannedRecipes(taskTitle, startDate, currentDate.AddDays(1))...)
+
+	for _, u := range uses {
+		days := currentDate.DaysSince(u.Date)
+		if last, ok := recentlyUsed[u.RecipeID]; !ok || days < last {
+			recentlyUsed[u.RecipeID] = days
+		}
+	}
+
+	log.Printf("Slot '%s': %d distinct recipes used in the last %d days", taskTitle, len(recentlyUsed), lookbackDays)
+	return recentlyUsed
+}
+
+// getYesterdayRecipes returns the recipes served in the meal slot the day before currentDate
+func (s *Scheduler) getYesterdayRecipes(orgID uint, taskTitle string, currentDate models.Date) map[uint]bool {
+	yesterday := make(map[uint]bool)
+	for id, days := range s.getRecentlyUsedRecipes(orgID, taskTitle, currentDate, 1) {
+		if days == 1 {
+			yesterday[id] = true
+		}
+	}
+	return yesterday
+}
+
+// selectRecipeWithImprovedRotation picks a recipe with the default weights: yesterday's recipes
+// are excluded while there is an alternative, the rest are chosen with probabilities proportional
+// to their recency weights. recentlyUsed maps recipe IDs to days since last use.
+// Returns nil for an empty list.
+func (s *Scheduler) selectRecipeWithImprovedRotation(recipes []models.Recipe, recentlyUsed map[uint]int, yesterdayRecipes map[uint]bool) *models.Recipe {
+	return weightedPick(recipes, recentlyUsed, yesterdayRecipes, DefaultRotationWeights, nil).recipe
+}
+
+// rotationPick is the outcome of weightedPick
+type rotationPick struct {
+	recipe       *models.Recipe
+	daysSince    int     // -1 when not used within the lookback
+	weight       float64 // weight of the chosen recipe
+	total        float64 // sum of the candidates' weights
+	candidates   int
+	allYesterday bool // every recipe was used yesterday, so none was excluded
+}
+
+// weightedPick performs the weighted random choice. rng may be nil to use the global source.
+func weightedPick(recipes []models.Recipe, recentlyUsed map[uint]int, yesterdayRecipes map[uint]bool, w RotationWeights, rng *rand.Rand) rotationPick {
+	return boostedPick(recipes, recentlyUsed, yesterdayRecipes, w, rng, nil)
+}
+
+// boostedPick is weightedPick with the recency weights multiplied by boosts (by recipe ID)
+func boostedPick(recipes []models.Recipe, recentlyUsed map[uint]int, yesterdayRecipes map[uint]bool, w RotationWeights, rng *rand.Rand, boosts map[uint]float64) rotationPick {
+	if len(recipes) == 0 {
+		return rotationPick{}
+	}
+
+	// Hard rule: never the same recipe two days in a row while there is an alternative
+	var candidates []models.Recipe
+	for _, r := range recipes {
+		if !yesterdayRecipes[r.ID] {
+			candidates = append(candidates, r)
+		}
+	}
+	allYesterday := len(candidates) == 0
+	if allYesterday {
+		log.Printf("Warning: all %d recipes were used yesterday, choosing from all of them", len(recipes))
+		candidates = recipes
+	}
+
+	weights := make([]float64, len(candidates))
+	total := 0.0
+	for i, r := range candidates {
+		if days, used := recentlyUsed[r.ID]; used {
+			weights[i] = w.weight(days)
+		} else {
+			weights[i] = w.NeverUsed
+		}
+		if b, ok := boosts[r.ID]; ok {
+			weights[i] *= b
+		}
+		total += weights[i]
+	}
+
+	var x float64
+	if rng != nil {
+		x = rng.Float64() * total
+	} else {
+		x = rand.Float64() * total
+	}
+	chosen := len(candidates) - 1
+	for i, weight := range weights {
+		if x < weight {
+			chosen = i
+			break
+		}
+		x -= weight
+	}
+
+	daysSince, used := recentlyUsed[candidates[chosen].ID]
+	if !used {
+		daysSince = -1
+	}
+	return rotationPick{
+		recipe:       &candidates[chosen],
+		daysSince:    daysSince,
+		weight:       weights[chosen],
+		total:        total,
+		candidates:   len(candidates),
+		allYesterday: allYesterday,
+	}
+}
@@ -4,95 +4,185 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"math/rand"
+	"sort"
 	"time"
 
+	"podlevskikh/awesomeProject/internal/childcare"
 	"podlevskikh/awesomeProject/internal/data"
 	"podlevskikh/awesomeProject/internal/models"
+	"podlevskikh/awesomeProject/internal/settings"
 
 	"gorm.io/gorm"
 )
 
-func init() {
-	// Initialize random number generator with current time
-	rand.Seed(time.Now().UnixNano())
-}
-
 type Scheduler struct {
-	db *gorm.DB
+	db       *gorm.DB
+	settings *settings.Service
+	out      sink       // where generated schedules and decisions go; nil means the database
+	rand     RandSource // nil means DeterministicSource
 }
 
 func NewScheduler(db *gorm.DB) *Scheduler {
-	return &Scheduler{db: db}
+	return &Scheduler{db: db, settings: settings.NewService(db)}
+}
+
+// output returns the sink the generator writes to
+func (s *Scheduler) output() sink {
+	if s.out == nil {
+		return dbSink{}
+	}
+	return s.out
 }
 
-// GenerateScheduleForDate generates a complete schedule for a specific date
-func (s *Scheduler) GenerateScheduleForDate(date time.Time) error {
-	// Normalize date to start of day
-	date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+// withoutHidden leaves the tasks the sink treats as removed out of a schedule_tasks query
+func (s *Scheduler) withoutHidden(q *gorm.DB) *gorm.DB {
+	if ids := s.output().hiddenTasks(); len(ids) > 0 {
+		return q.Where("schedule_tasks.id NOT IN ?", ids)
+	}
+	return q
+}
+
+// GenerateScheduleForDate generates a schedule for a specific calendar date in every organization
+func (s *Scheduler) GenerateScheduleForDate(date models.Date) error {
+	orgs, err := s.organizations()
+	if err != nil {
+		return err
+	}
+
+	for _, org := range orgs {
+		if err := s.GenerateOrgScheduleForDate(org.ID, date); err != nil {
+			log.Printf("Error generating schedule for org %d on %s: %v", org.ID, date, err)
+		}
+	}
+
+	return nil
+}
+
+// GenerateOrgScheduleForDate generates a complete schedule for a single organization on a specific
+// calendar date. The date is interpreted in the organization's own timezone.
+func (s *Scheduler) GenerateOrgScheduleForDate(orgID uint, date models.Date) error {
+	return s.generateDay(orgID, date, nil, false)
+}
 
-	log.Printf("Generating schedule for date: %s", date.Format("2006-01-02"))
+// generateDay generates the schedule for one organization and date. If taskTypes is not nil,
+// only the listed task types are generated. Slots already covered by tasks in the schedule
+// (custom, edited or completed tasks kept by a regeneration) are not generated again.
+// Random choices depend on the day's seed; reshuffle replaces it with a new one.
+func (s *Scheduler) generateDay(orgID uint, date models.Date, taskTypes map[string]bool, reshuffle bool) error {
+	log.Printf("Generating schedule for org %d, date: %s", orgID, date)
 
 	// Check if it's a holiday or Sunday
-	if data.IsHoliday(s.db, date) {
-		log.Printf("Date %s is a holiday or Sunday, skipping schedule generation", date.Format("2006-01-02"))
+	if reason := data.HolidayReason(s.db, date); reason != "" {
+		log.Printf("Date %s is a holiday or Sunday, skipping schedule generation", date)
+		s.output().decide(Decision{Date: date, Kind: "holiday", Subject: date.Weekday().String(), Reason: reason})
 		return nil
 	}
 
 	// Check if schedule already exists
 	var existingSchedule models.DailySchedule
-	result := s.db.Where("date = ?", date).First(&existingSchedule)
+	result := s.db.Preload("Tasks").Where("organization_id = ? AND date = ?", orgID, date).First(&existingSchedule)
 
-	var schedule models.DailySchedule
+	schedule := &models.DailySchedule{
+		OrganizationID: orgID,
+		Date:           date,
+	}
 	if result.Error == nil {
+		s.output().load(&existingSchedule)
 		if existingSchedule.Generated {
-			log.Printf("Schedule already generated for %s, skipping", date.Format("2006-01-02"))
+			log.Printf("Schedule already generated for org %d on %s, skipping", orgID, date)
+			s.output().decide(Decision{Date: date, Kind: "skipped", Subject: date.Weekday().String(), Reason: "schedule already generated"})
 			return nil
 		}
 		// Exists but not yet generated (e.g. holds custom tasks) — generate into it
-		schedule = existingSchedule
-		schedule.Generated = true
-		if err := s.db.Save(&schedule).Error; err != nil {
-			return fmt.Errorf("failed to update daily schedule: %w", err)
-		}
-	} else {
-		// Create new daily schedule
-		schedule = models.DailySchedule{
-			Date:      date,
-			Generated: true,
+		*schedule = existingSchedule
+	}
+	schedule.Generated = true
+	schedule.Warnings = ""
+	if reshuffle {
+		schedule.Seed = newSeed()
+	}
+	if err := s.output().saveSchedule(s.db, schedule); err != nil {
+		return err
+	}
+
+	// Generate meal tasks
+	if taskTypes == nil || taskTypes["meal"] {
+		if err := s.generateMealTasks(schedule, date); err != nil {
+			return fmt.Errorf("failed to generate meal tasks: %w", err)
 		}
-		if err := s.db.Create(&schedule).Error; err != nil {
-			return fmt.Errorf("failed to create daily schedule: %w", err)
+	}
+
+	// Add childcare tasks if they exist
+	if taskTypes == nil || taskTypes["childcare"] {
+		if err := s.addChildcareTasks(schedule, date); err != nil {
+			return fmt.Errorf("failed to add childcare tasks: %w", err)
 		}
 	}
 
-	// Generate meal tasks
-	if err := s.generateMealTasks(&schedule, date); err != nil {
-		return fmt.Errorf("failed to generate meal tasks: %w", err)
+	// Add occurrences of recurring tasks
+	if taskTypes == nil || taskTypes["recurring"] {
+		if err := s.generateRecurringTasks(schedule, date); err != nil {
+			return fmt.Errorf("failed to generate recurring tasks: %w", err)
+		}
 	}
 
-	// Generate cleaning tasks
-	if err := s.generateCleaningTasks(&schedule, date); err != nil {
-		return fmt.Errorf("failed to generate cleaning tasks: %w", err)
+	// Generate cleaning tasks into the working time meals and childcare leave
+	if taskTypes == nil || taskTypes["cleaning"] {
+		if err := s.generateCleaningTasks(schedule, date); err != nil {
+			return fmt.Errorf("failed to generate cleaning tasks: %w", err)
+		}
 	}
 
-	// Add childcare tasks if they exist
-	if err := s.addChildcareTasks(&schedule, date); err != nil {
-		return fmt.Errorf("failed to add childcare tasks: %w", err)
+	s.checkOverlaps(schedule)
+	if err := s.checkWorkload(schedule); err != nil {
+		return fmt.Errorf("failed to check workload: %w", err)
 	}
 
-	log.Printf("Successfully generated schedule for %s with ID %d", date.Format("2006-01-02"), schedule.ID)
+	log.Printf("Successfully generated schedule for org %d on %s with ID %d", orgID, date, schedule.ID)
 	return nil
 }
 
+// hasTask reports whether the schedule already holds a task of the given type matching the predicate
+func hasTask(schedule *models.DailySchedule, taskType string, match func(t models.ScheduleTask) bool) bool {
+	for _, t := range schedule.Tasks {
+		if t.TaskType == taskType && match(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// fillsMealSlot reports whether a kept meal task fills the slot of mealTime at timeSlot. The
+// slot is stored on the task, so a task whose time was edited still holds its slot; tasks
+// saved before the slot was stored are matched by title and time
+func fillsMealSlot(t models.ScheduleTask, mealTime models.MealTime, timeSlot string) bool {
+	if t.MealTimeID != nil {
+		return *t.MealTimeID == mealTime.ID && t.MealSlot == timeSlot
+	}
+	slot := t.MealSlot
+	if slot == "" {
+		slot = t.Time
+	}
+	return t.Title == mealTime.TaskTitle() && slot == timeSlot
+}
+
+// organizations returns every organization the scheduler generates for
+func (s *Scheduler) organizations() ([]models.Organization, error) {
+	var orgs []models.Organization
+	if err := s.db.Order("id").Find(&orgs).Error; err != nil {
+		return nil, fmt.Errorf("failed to load organizations: %w", err)
+	}
+	return orgs, nil
+}
+
 // generateMealTasks creates meal tasks based on configured meal times
-func (s *Scheduler) generateMealTasks(schedule *models.DailySchedule, date time.Time) error {
+func (s *Scheduler) generateMealTasks(schedule *models.DailySchedule, date models.Date) error {
 	var mealTimes []models.MealTime
-	if err := s.db.Where("active = ?", true).Find(&mealTimes).Error; err != nil {
+	if err := s.db.Where("organization_id = ? AND active = ?", schedule.OrganizationID, true).Order("id").Find(&mealTimes).Error; err != nil {
 		return err
 	}
 
-	log.Printf("Active meal times found: %d", len(mealTimes))
+	log.Printf("Org %d: active meal times found: %d", schedule.OrganizationID, len(mealTimes))
 	for _, mt := range mealTimes {
 		log.Printf("  -> meal time: id=%d name=%q family=%q", mt.ID, mt.Name, mt.FamilyMember)
 	}
@@ -103,38 +193,78 @@ func (s *Scheduler) generateMealTasks(schedule *models.DailySchedule, date time.
 
 		// Create a task for each time slot
 		for _, timeSlot := range times {
+			title := mealTime.TaskTitle()
+			decision := Decision{Date: date, Kind: "meal", Subject: title, Time: timeSlot}
+			if hasTask(schedule, "meal", func(t models.ScheduleTask) bool { return fillsMealSlot(t, mealTime, timeSlot) }) {
+				decision.Reason = "slot kept from a previous generation"
+				s.output().decide(decision)
+				continue
+			}
+
 			// Find a suitable recipe for this meal
-			recipe, err := s.selectRecipeForMeal(mealTime.ID, mealTime.Name, mealTime.FamilyMember, date)
+			rng := s.randFor(schedule, title+"@"+timeSlot)
+			recipe, err := s.selectRecipeForMeal(schedule.OrganizationID, mealTime, title, timeSlot, date, rng, &decision)
 			if err != nil {
-				log.Printf("Warning: No recipe found for %s (%s), creating task without recipe", mealTime.Name, mealTime.FamilyMember)
+				log.Printf("Warning: No recipe found for %s (%s), creating task without recipe: %v", mealTime.Name, mealTime.FamilyMember, err)
+				decision.Reason = fmt.Sprintf("%v, task created without recipe", err)
 			}
 
 			task := models.ScheduleTask{
-				ScheduleID:  schedule.ID,
-				TaskType:    "meal",
-				Time:        timeSlot,
-				Title:       fmt.Sprintf("%s - %s", mealTime.Name, mealTime.FamilyMember),
-				Description: "",
-				Completed:   false,
+				OrganizationID: schedule.OrganizationID,
+				ScheduleID:     schedule.ID,
+				TaskType:       "meal",
+				Time:           timeSlot,
+				Title:          title,
+				MealTimeID:     &mealTime.ID,
+				MealSlot:       timeSlot,
+				Description:    "",
+				Completed:      false,
 			}
 
+			task.Servings = mealServings(mealTime, recipe)
+			task.Duration = mealDuration(recipe)
 			if recipe != nil {
 				task.RecipeID = &recipe.ID
 				task.Description = recipe.Name
-				task.Duration = 60 // default 1 hour for meals
-			} else {
-				task.Duration = 60 // default 1 hour
+				decision.RecipeID = &recipe.ID
+				decision.Choice = recipe.Name
 			}
 
-			if err := s.db.Create(&task).Error; err != nil {
+			if err := s.output().saveTask(s.db, schedule, &task); err != nil {
 				return err
 			}
+			schedule.Tasks = append(schedule.Tasks, task)
+			s.output().decide(decision)
 		}
 	}
 
 	return nil
 }
 
+// mealServings returns the portions a meal task cooks: the meal time's headcount when set,
+// otherwise what the recipe serves (0 when neither is known)
+func mealServings(mealTime models.MealTime, recipe *models.Recipe) int {
+	if mealTime.Headcount > 0 {
+		return mealTime.Headcount
+	}
+	if recipe != nil {
+		return recipe.Servings
+	}
+	return 0
+}
+
+// defaultMealDuration is the duration of a meal task whose recipe has no prep or cook time
+const defaultMealDuration = 60
+
+// mealDuration returns how long a meal task takes: the recipe's prep plus cook time,
+// or defaultMealDuration when the recipe is unknown or has no times
+func mealDuration(recipe *models.Recipe) int {
+	if recipe != nil && recipe.PrepTime+recipe.CookTime > 0 {
+		return recipe.PrepTime + recipe.CookTime
+	}
+	return defaultMealDuration
+}
+
 // getMealTimes returns all time slots for a meal time
 func (s *Scheduler) getMealTimes(mealTime models.MealTime) []string {
 	// If DefaultTimes is set (JSON array), parse and return it
@@ -151,66 +281,16 @@ func (s *Scheduler) getMealTimes(mealTime models.MealTime) []string {
 	return []string{mealTime.DefaultTime}
 }
 
-// selectRecipeForMeal picks a random recipe for a meal slot using a full-cycle rotation:
-//   - find all eligible recipes for this meal time + family member
-//   - look back N days (where N = max(recipe count, 7)) to build the "used" set
-//     only counting recipes used for THIS specific meal slot (by title)
-//   - pick randomly from recipes NOT in the used set ("fresh" pool)
-//   - if all recipes have been used (end of cycle), reset and pick from all
-func (s *Scheduler) selectRecipeForMeal(mealTimeID uint, mealTimeName, familyMember string, currentDate time.Time) (*models.Recipe, error) {
-	recipes, err := s.eligibleRecipes(mealTimeID, mealTimeName)
-	if err != nil {
-		return nil, err
-	}
-	log.Printf("DIAG selectRecipeForMeal: meal=%q family=%q -> %d eligible recipes", mealTimeName, familyMember, len(recipes))
-	if len(recipes) == 0 {
-		return nil, fmt.Errorf("no recipes found for meal time %d (%s)", mealTimeID, mealTimeName)
-	}
-	if len(recipes) == 1 {
-		return &recipes[0], nil
-	}
-
-	// Lookback window = recipe pool size (so every recipe appears before any repeats),
-	// but at least 7 days.
-	lookback := len(recipes)
-	if lookback < 7 {
-		lookback = 7
-	}
-
-	// Filter used recipes only for this specific meal slot (title = "MealName - FamilyMember")
-	taskTitle := fmt.Sprintf("%s - %s", mealTimeName, familyMember)
-	usedIDs := s.usedRecipeIDsSince(currentDate, lookback, taskTitle)
-
-	// Partition into fresh (not used recently) and stale
-	var fresh []models.Recipe
-	for _, r := range recipes {
-		if !usedIDs[r.ID] {
-			fresh = append(fresh, r)
-		}
-	}
-
-	pool := fresh
-	if len(pool) == 0 {
-		// Full cycle completed — reset and pick from entire pool
-		pool = recipes
-		log.Printf("Recipe cycle reset for meal time %d (%s): all %d recipes were used in the last %d days",
-			mealTimeID, mealTimeName, len(recipes), lookback)
-	}
-
-	chosen := pool[rand.Intn(len(pool))]
-	log.Printf("Selected recipe '%s' for meal time %d/%s (%d fresh / %d total)",
-		chosen.Name, mealTimeID, mealTimeName, len(fresh), len(recipes))
-	return &chosen, nil
-}
-
-// eligibleRecipes returns active recipes linked to the given meal time via recipe_meal_times.
-// Only recipes explicitly assigned to this meal time are considered.
-func (s *Scheduler) eligibleRecipes(mealTimeID uint, mealTimeName string) ([]models.Recipe, error) {
+// eligibleRecipes returns active recipes of the organization linked to the given meal time
+// via recipe_meal_times. Only recipes explicitly assigned to this meal time are considered.
+func (s *Scheduler) eligibleRecipes(orgID, mealTimeID uint, mealTimeName string) ([]models.Recipe, error) {
 	var recipes []models.Recipe
 	err := s.db.
 		Joins("JOIN recipe_meal_times ON recipe_meal_times.recipe_id = recipes.id").
 		Where("recipe_meal_times.meal_time_id = ?", mealTimeID).
+		Where("recipes.organization_id = ?", orgID).
 		Where("recipes.is_active = ?", true).
+		Order("recipes.id"). // stable order keeps seeded picks reproducible
 		Find(&recipes).Error
 	if err != nil {
 		return nil, err
@@ -219,174 +299,59 @@ func (s *Scheduler) eligibleRecipes(mealTimeID uint, mealTimeName string) ([]mod
 	return recipes, nil
 }
 
-// usedRecipeIDsSince returns the set of recipe IDs used in tasks with the given title
-// within the last `days` days before `before`.
-// Filtering by title (e.g. "Breakfast - adult") ensures we only consider the same meal slot,
-// so recipes used at lunch don't block breakfast choices.
-func (s *Scheduler) usedRecipeIDsSince(before time.Time, days int, taskTitle string) map[uint]bool {
-	used := make(map[uint]bool)
-
-	startDate := before.AddDate(0, 0, -days)
-
-	var tasks []models.ScheduleTask
-	err := s.db.
-		Joins("JOIN daily_schedules ON daily_schedules.id = schedule_tasks.schedule_id").
-		Where("daily_schedules.date >= ? AND daily_schedules.date < ?", startDate, before).
-		Where("schedule_tasks.task_type = 'meal'").
-		Where("schedule_tasks.title = ?", taskTitle).
-		Where("schedule_tasks.recipe_id IS NOT NULL").
-		Find(&tasks).Error
-
-	if err != nil {
-		log.Printf("Warning: failed to query used recipes: %v", err)
-		return used
-	}
-
-	for _, t := range tasks {
-		if t.RecipeID != nil {
-			used[*t.RecipeID] = true
-		}
-	}
-
-	log.Printf("Slot '%s': found %d distinct recipes used in the last %d days", taskTitle, len(used), days)
-	return used
-}
-
-// generateCleaningTasks creates cleaning tasks based on zone frequency
-func (s *Scheduler) generateCleaningTasks(schedule *models.DailySchedule, date time.Time) error {
-	var zones []models.CleaningZone
-	// Order by priority: high > medium > low
-	priorityOrder := "CASE priority WHEN 'high' THEN 1 WHEN 'medium' THEN 2 WHEN 'low' THEN 3 ELSE 4 END"
-	if err := s.db.Order(priorityOrder).Find(&zones).Error; err != nil {
+// addChildcareTasks adds childcare tasks from the childcare schedule
+func (s *Scheduler) addChildcareTasks(schedule *models.DailySchedule, date models.Date) error {
+	if err := s.expandChildcarePatterns(schedule.OrganizationID, date); err != nil {
 		return err
 	}
 
-	// Get the day of week (0 = Sunday, 6 = Saturday)
-	dayOfWeek := int(date.Weekday())
-
-	// Limit to max 2-3 cleaning zones per day
-	maxZonesPerDay := 3
-	zonesAddedToday := 0
-
-	for _, zone := range zones {
-		// Stop if we've reached the daily limit
-		if zonesAddedToday >= maxZonesPerDay {
-			break
-		}
-
-		// Determine if this zone should be cleaned today based on frequency
-		if s.shouldCleanZoneToday(zone, dayOfWeek, date) {
-			task := models.ScheduleTask{
-				ScheduleID:  schedule.ID,
-				TaskType:    "cleaning",
-				Time:        "", // No specific time for cleaning tasks
-				Duration:    30, // Default 30 minutes for cleaning
-				Title:       zone.Name,
-				Description: zone.Description,
-				ZoneID:      &zone.ID,
-				Completed:   false,
-			}
-
-			if err := s.db.Create(&task).Error; err != nil {
-				return err
-			}
-
-			zonesAddedToday++
-		}
-	}
-
-	return nil
-}
-
-// shouldCleanZoneToday determines if a zone should be cleaned on this day
-func (s *Scheduler) shouldCleanZoneToday(zone models.CleaningZone, dayOfWeek int, date time.Time) bool {
-	// Simple distribution algorithm: spread cleanings across the week
-	// For frequency_per_week = 1: clean on specific day based on zone ID
-	// For frequency_per_week = 2: clean on 2 specific days
-	// etc.
-
-	if zone.FrequencyPerWeek >= 7 {
-		return true // clean every day
-	}
-
-	// Use zone ID to determine which days to clean
-	// This ensures consistent scheduling
-	daysToClean := s.calculateCleaningDays(zone.FrequencyPerWeek, int(zone.ID))
-
-	for _, day := range daysToClean {
-		if dayOfWeek == day {
-			return true
-		}
-	}
-
-	return false
-}
-
-// calculateCleaningDays returns which days of the week to clean based on frequency
-func (s *Scheduler) calculateCleaningDays(frequency, zoneID int) []int {
-	if frequency <= 0 {
-		return []int{}
-	}
-
-	// Distribute days evenly across the week
-	interval := 7 / frequency
-	startDay := zoneID % 7 // offset based on zone ID for variety
-
-	days := make([]int, 0, frequency)
-	for i := 0; i < frequency; i++ {
-		day := (startDay + (i * interval)) % 7
-		days = append(days, day)
-	}
-
-	return days
-}
-
-// findAvailableTimeSlot finds a suitable time slot for a task
-func (s *Scheduler) findAvailableTimeSlot(schedule *models.DailySchedule, duration int) string {
-	// Simple implementation: assign cleaning tasks to morning slots
-	// Can be improved to check for conflicts
-
-	// Default cleaning time slots: 9:00, 10:30, 14:00, 15:30
-	defaultSlots := []string{"09:00", "10:30", "14:00", "15:30"}
-
-	// Return first available slot (simplified)
-	// In a more advanced version, check existing tasks for conflicts
-	if len(defaultSlots) > 0 {
-		return defaultSlots[rand.Intn(len(defaultSlots))]
-	}
-
-	return "10:00"
-}
-
-// addChildcareTasks adds childcare tasks from the childcare schedule
-func (s *Scheduler) addChildcareTasks(schedule *models.DailySchedule, date time.Time) error {
 	var childcareSchedules []models.ChildcareSchedule
 
-	// Normalize to UTC midnight — childcare dates are stored as UTC midnight from the frontend
-	normalizedDate := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
-	nextDay := normalizedDate.AddDate(0, 0, 1)
-
-	if err := s.db.Where("date >= ? AND date < ?", normalizedDate, nextDay).Find(&childcareSchedules).Error; err != nil {
+	if err := s.db.Preload("Children").Where("organization_id = ? AND date = ?", schedule.OrganizationID, date).
+		Order("start_time").Find(&childcareSchedules).Error; err != nil {
 		return err
 	}
+	// Blocks expanded in this run that only the sink holds (a preview)
+	if planned := s.output().plannedChildcare(date); len(planned) > 0 {
+		childcareSchedules = append(childcareSchedules, planned...)
+		sort.SliceStable(childcareSchedules, func(i, j int) bool {
+			return childcareSchedules[i].StartTime < childcareSchedules[j].StartTime
+		})
+	}
 
-	log.Printf("Found %d childcare schedules for date %s", len(childcareSchedules), date.Format("2006-01-02"))
+	log.Printf("Found %d childcare schedules for date %s", len(childcareSchedules), date)
 
 	for _, cc := range childcareSchedules {
+		startTime, title := cc.StartTime, childcare.Title(cc.Children)
+		decision := Decision{Date: date, Kind: "childcare", Subject: title, Time: cc.StartTime}
+		if hasTask(schedule, "childcare", func(t models.ScheduleTask) bool { return t.Time == startTime && t.Title == title }) {
+			decision.Reason = "slot kept from a previous generation"
+			s.output().decide(decision)
+			continue
+		}
+
 		task := models.ScheduleTask{
-			ScheduleID:  schedule.ID,
-			TaskType:    "childcare",
-			Time:        cc.StartTime,
-			EndTime:     cc.EndTime,
-			Duration:    s.calculateDuration(cc.StartTime, cc.EndTime),
-			Title:       "Childcare",
-			Description: cc.Notes,
-			Completed:   false,
+			OrganizationID: schedule.OrganizationID,
+			ScheduleID:     schedule.ID,
+			TaskType:       "childcare",
+			Time:           cc.StartTime,
+			EndTime:        cc.EndTime,
+			Duration:       s.calculateDuration(cc.StartTime, cc.EndTime),
+			Title:          title,
+			Description:    cc.Notes,
+			Completed:      false,
 		}
 
-		if err := s.db.Create(&task).Error; err != nil {
+		if err := s.output().saveTask(s.db, schedule, &task); err != nil {
 			return err
 		}
+		schedule.Tasks = append(schedule.Tasks, task)
+		decision.Choice = fmt.Sprintf("%s - %s", cc.StartTime, cc.EndTime)
+		decision.Reason = "childcare schedule entry"
+		if cc.PatternID != nil {
+			decision.Reason = "weekly childcare pattern"
+		}
+		s.output().decide(decision)
 
 		log.Printf("Created childcare task: %s - %s", cc.StartTime, cc.EndTime)
 	}
@@ -403,14 +368,41 @@ func (s *Scheduler) calculateDuration(startTime, endTime string) int {
 	return int(duration.Minutes())
 }
 
-// GenerateScheduleForNextDays generates schedules for the next N days
+// GenerateScheduleForNextDays generates schedules for the next N days in every organization
 func (s *Scheduler) GenerateScheduleForNextDays(days int) error {
-	today := time.Now()
+	orgs, err := s.organizations()
+	if err != nil {
+		return err
+	}
+
+	for _, org := range orgs {
+		if err := s.GenerateOrgScheduleForNextDays(org.ID, days); err != nil {
+			log.Printf("Error generating schedules for org %d: %v", org.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// GenerateOrgUpcomingSchedules generates schedules for the organization's configured
+// schedule_days_ahead horizon, regardless of auto_generate_schedule
+func (s *Scheduler) GenerateOrgUpcomingSchedules(orgID uint) error {
+	return s.GenerateOrgScheduleForNextDays(orgID, s.settings.Int(orgID, settings.KeyScheduleDaysAhead))
+}
+
+// GenerateOrgScheduleForNextDays generates schedules for the next N days in a single organization,
+// starting from "today" in the organization's timezone
+func (s *Scheduler) GenerateOrgScheduleForNextDays(orgID uint, days int) error {
+	var org models.Organization
+	if err := s.db.First(&org, orgID).Error; err != nil {
+		return fmt.Errorf("failed to load organization %d: %w", orgID, err)
+	}
+	today := org.Today()
 
 	for i := 0; i < days; i++ {
-		date := today.AddDate(0, 0, i)
-		if err := s.GenerateScheduleForDate(date); err != nil {
-			log.Printf("Error generating schedule for %s: %v", date.Format("2006-01-02"), err)
+		date := today.AddDays(i)
+		if err := s.GenerateOrgScheduleForDate(orgID, date); err != nil {
+			log.Printf("Error generating schedule for org %d on %s: %v", orgID, date, err)
 		}
 	}
 
@@ -154,3 +154,44 @@ func TestWeightDistribution(t *testing.T) {
 	})
 }
 
+
+// TestDefaultWeightTiers checks the default weights against RECIPE_ROTATION_ALGORITHM.md
+func TestDefaultWeightTiers(t *testing.T) {
+	testCases := []struct {
+		daysSince int
+		weight    float64
+	}{
+		{0, 0.05},
+		{1, 0.1},
+		{2, 0.3},
+		{3, 0.5},
+		{4, 0.8},
+		{5, 1.0},
+		{7, 1.5},
+		{14, 2.0},
+		{21, 3.0},
+		{30, 3.0},
+	}
+
+	for _, tc := range testCases {
+		if got := DefaultRotationWeights.weight(tc.daysSince); got != tc.weight {
+			t.Errorf("weight(%d) = %v, want %v", tc.daysSince, got, tc.weight)
+		}
+	}
+}
+
+// TestWeightedPickIsReproducible checks that the same seed gives the same choice
+func TestWeightedPickIsReproducible(t *testing.T) {
+	recipes := []models.Recipe{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}, {ID: 5}}
+	recentlyUsed := map[uint]int{1: 3, 2: 8}
+
+	for _, key := range []string{"1/2025-03-03/Breakfast - adult@08:00/0", "1/2025-03-04/Lunch - adult@13:00/42"} {
+		first := weightedPick(recipes, recentlyUsed, nil, DefaultRotationWeights, DeterministicSource(key))
+		for i := 0; i < 10; i++ {
+			again := weightedPick(recipes, recentlyUsed, nil, DefaultRotationWeights, DeterministicSource(key))
+			if again.recipe.ID != first.recipe.ID {
+				t.Fatalf("key %q: got recipe %d, then %d", key, first.recipe.ID, again.recipe.ID)
+			}
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package scheduler
+
+import (
+	"fmt"
+	"log"
+	"slices"
+
+	"podlevskikh/awesomeProject/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Decision explains one choice made by the generator
+type Decision struct {
+	Date       models.Date `json:"date"`
+	Kind       string      `json:"kind"`               // holiday, skipped, meal, cleaning, childcare, recurring, timeline, workload
+	Strategy   string      `json:"strategy,omitempty"` // recipe strategy of a meal slot
+	Subject    string      `json:"subject"`            // meal slot title, zone name, ...
+	Time       string      `json:"time,omitempty"`
+	Choice     string      `json:"choice,omitempty"` // chosen recipe or zone
+	RecipeID   *uint       `json:"recipe_id,omitempty"`
+	ZoneID     *uint       `json:"zone_id,omitempty"`
+	Reason     string      `json:"reason"`
+	PoolSize   int         `json:"pool_size,omitempty"`  // eligible recipes for the slot
+	FreshPool  int         `json:"fresh_pool,omitempty"` // eligible recipes not used within the lookback
+	Excluded   int         `json:"excluded,omitempty"`   // eligible recipes left out for dietary conflicts
+	CycleReset bool        `json:"cycle_reset,omitempty"`
+}
+
+// sink receives everything the generator produces. dbSink writes straight to the database,
+// memorySink keeps the proposal in memory so it can be previewed and applied later.
+type sink interface {
+	// saveSchedule stores a daily schedule the generator is about to fill
+	saveSchedule(db *gorm.DB, schedule *models.DailySchedule) error
+	// saveTask stores a generated task; the caller appends it to schedule.Tasks
+	saveTask(db *gorm.DB, schedule *models.DailySchedule, task *models.ScheduleTask) error
+	// saveChildcare stores a childcare block expanded from a weekly pattern
+	saveChildcare(db *gorm.DB, block *models.ChildcareSchedule) error
+	// plannedChildcare returns childcare blocks held by the sink but not yet in the database for date
+	plannedChildcare(date models.Date) []models.ChildcareSchedule
+	// decide records why the generator did what it did
+	decide(d Decision)
+	// plannedRecipes returns recipe uses held by the sink but not yet in the database
+	// for the given meal slot title in [from, before)
+	plannedRecipes(title string, from, before models.Date) []recipeUse
+	// plannedZones returns cleaning zones held by the sink but not yet in the database in [from, before)
+	plannedZones(from, before models.Date) []zoneUse
+	// load adjusts a daily schedule read from the database to the state the sink works against
+	load(schedule *models.DailySchedule)
+	// hiddenTasks returns the IDs of database tasks the sink treats as removed;
+	// history lookups leave them out
+	hiddenTasks() []uint
+}
+
+// recipeUse is a recipe served in a meal slot on a date
+type recipeUse struct {
+	RecipeID uint
+	Date     models.Date
+}
+
+// zoneUse is a cleaning zone scheduled on a date
+type zoneUse struct {
+	ZoneID uint
+	Date   models.Date
+}
+
+// dbSink writes generated schedules and tasks immediately
+type dbSink struct{}
+
+func (dbSink) saveSchedule(db *gorm.DB, schedule *models.DailySchedule) error {
+	if schedule.ID != 0 {
+		if err := db.Omit("Tasks").Save(schedule).Error; err != nil {
+			return fmt.Errorf("failed to update daily schedule: %w", err)
+		}
+		return nil
+	}
+	if err := db.Omit("Tasks").Create(schedule).Error; err != nil {
+		return fmt.Errorf("failed to create daily schedule: %w", err)
+	}
+	return nil
+}
+
+func (dbSink) saveTask(db *gorm.DB, schedule *models.DailySchedule, task *models.ScheduleTask) error {
+	task.ScheduleID = schedule.ID
+	if err := db.Create(task).Error; err != nil {
+		return err
+	}
+	return linkRecipe(db, task)
+}
+
+// linkRecipe mirrors the legacy RecipeID of a generated meal task into the meal_recipes join
+// table, which the menu planner and the helper views work with
+func linkRecipe(db *gorm.DB, task *models.ScheduleTask) error {
+	if task.TaskType != "meal" || task.RecipeID == nil {
+		return nil
+	}
+	if err := db.Exec("INSERT INTO meal_recipes (schedule_task_id, recipe_id) VALUES (?, ?) ON CONFLICT DO NOTHING",
+		task.ID, *task.RecipeID).Error; err != nil {
+		return fmt.Errorf("failed to link recipe to task %d: %w", task.ID, err)
+	}
+	return nil
+}
+
+func (dbSink) saveChildcare(db *gorm.DB, block *models.ChildcareSchedule) error {
+	if err := db.Omit("Children.*").Create(block).Error; err != nil {
+		return fmt.Errorf("failed to create childcare block: %w", err)
+	}
+	return nil
+}
+
+func (dbSink) plannedChildcare(models.Date) []models.ChildcareSchedule {
+	return nil
+}
+
+func (dbSink) decide(d Decision) {
+	log.Printf("Org schedule %s: %s %q -> %q (%s)", d.Date, d.Kind, d.Subject, d.Choice, d.Reason)
+}
+
+func (dbSink) plannedRecipes(string, models.Date, models.Date) []recipeUse {
+	return nil
+}
+
+func (dbSink) plannedZones(models.Date, models.Date) []zoneUse {
+	return nil
+}
+
+func (dbSink) load(*models.DailySchedule) {}
+
+func (dbSink) hiddenTasks() []uint {
+	return nil
+}
+
+// memorySink collects the proposed schedules and decisions without touching the database.
+// Proposed tasks keep ID 0; tasks already present in a schedule keep their IDs.
+// Tasks the proposal replaces stay in the database and are hidden from the generator instead.
+type memorySink struct {
+	schedules []*models.DailySchedule
+	childcare []models.ChildcareSchedule // blocks expanded from childcare patterns
+	decisions []Decision
+	removed   []uint // database tasks the proposal replaces
+}
+
+func (m *memorySink) saveSchedule(_ *gorm.DB, schedule *models.DailySchedule) error {
+	for _, sch := range m.schedules {
+		if sch == schedule {
+			return nil // saved again after the workload check
+		}
+	}
+	m.schedules = append(m.schedules, schedule)
+	return nil
+}
+
+func (m *memorySink) saveTask(_ *gorm.DB, schedule *models.DailySchedule, task *models.ScheduleTask) error {
+	task.ScheduleID = schedule.ID
+	return nil
+}
+
+func (m *memorySink) saveChildcare(_ *gorm.DB, block *models.ChildcareSchedule) error {
+	m.childcare = append(m.childcare, *block)
+	return nil
+}
+
+func (m *memorySink) plannedChildcare(date models.Date) []models.ChildcareSchedule {
+	var blocks []models.ChildcareSchedule
+	for _, b := range m.childcare {
+		if b.Date.Equal(date) {
+			blocks = append(blocks, b)
+		}
+	}
+	return blocks
+}
+
+func (m *memorySink) decide(d Decision) {
+	m.decisions = append(m.decisions, d)
+}
+
+func (m *memorySink) plannedRecipes(title string, from, before models.Date) []recipeUse {
+	var uses []recipeUse
+	for _, sch := range m.schedules {
+		if sch.Date.Before(from) || !sch.Date.Before(before) {
+			continue
+		}
+		for _, t := range sch.Tasks {
+			if t.ID == 0 && t.TaskType == "meal" && t.Title == title && t.RecipeID != nil {
+				uses = append(uses, recipeUse{RecipeID: *t.RecipeID, Date: sch.Date})
+			}
+		}
+	}
+	return uses
+}
+
+func (m *memorySink) plannedZones(from, before models.Date) []zoneUse {
+	var uses []zoneUse
+	for _, sch := range m.schedules {
+		if sch.Date.Before(from) || !sch.Date.Before(before) {
+			continue
+		}
+		for _, t := range sch.Tasks {
+			if t.ID == 0 && t.TaskType == "cleaning" && t.ZoneID != nil {
+				uses = append(uses, zoneUse{ZoneID: *t.ZoneID, Date: sch.Date})
+			}
+		}
+	}
+	return uses
+}
+
+// load drops the replaced tasks from the schedule. Every day a preview loads is regenerated,
+// so the schedule no longer counts as generated.
+func (m *memorySink) load(schedule *models.DailySchedule) {
+	tasks := schedule.Tasks[:0]
+	for _, t := range schedule.Tasks {
+		if !slices.Contains(m.removed, t.ID) {
+			tasks = append(tasks, t)
+		}
+	}
+	schedule.Tasks = tasks
+	schedule.Generated = false
+}
+
+func (m *memorySink) hiddenTasks() []uint {
+	return m.removed
+}
@@ -0,0 +1,351 @@
+package scheduler
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"podlevskikh/awesomeProject/internal/models"
+)
+
+func init() {
+	RegisterStrategy(weightedRotationStrategy{})
+	RegisterStrategy(rotationStrategy{})
+	RegisterStrategy(ratingWeightedStrategy{})
+	RegisterStrategy(fixedWeekdayStrategy{})
+	RegisterStrategy(leastRecentlyCookedStrategy{})
+}
+
+// weightedRotationStrategy is the rotation from RECIPE_ROTATION_ALGORITHM.md: recency weights
+// from the organization's recipe_rotation_weights setting, yesterday's recipes excluded
+type weightedRotationStrategy struct{}
+
+func (weightedRotationStrategy) Name() string { return "weighted_rotation" }
+func (weightedRotationStrategy) Description() string {
+	return "Random pick weighted by days since last use; never repeats yesterday's recipe"
+}
+func (weightedRotationStrategy) ExampleParams() string              { return "" }
+func (weightedRotationStrategy) ValidateParams(params string) error { return nil }
+
+func (weightedRotationStrategy) Select(sc *SelectionContext) (*models.Recipe, error) {
+	weights := sc.Weights()
+	recentlyUsed := sc.RecentlyUsed(weights.LookbackDays)
+	pick := boostedPick(sc.Recipes, recentlyUsed, sc.Yesterday(), weights, sc.Rand, sc.Boosts)
+
+	d := sc.Decision
+	for _, r := range sc.Recipes {
+		if _, used := recentlyUsed[r.ID]; !used {
+			d.FreshPool++
+		}
+	}
+	switch {
+	case len(sc.Recipes) == 1:
+		d.Reason = "only one eligible recipe"
+	case pick.allYesterday:
+		d.Reason = fmt.Sprintf("all %d recipes were used yesterday; weighted pick from all of them", len(sc.Recipes))
+	case pick.daysSince < 0:
+		d.Reason = fmt.Sprintf("weighted pick from %d candidates: not used in the last %d days (weight %.2f of %.2f total)",
+			pick.candidates, weights.LookbackDays, pick.weight, pick.total)
+	default:
+		d.Reason = fmt.Sprintf("weighted pick from %d candidates: last used %d days ago (weight %.2f of %.2f total)",
+			pick.candidates, pick.daysSince, pick.weight, pick.total)
+	}
+	if b := sc.Boost(pick.recipe.ID); b > 1 {
+		d.Reason += fmt.Sprintf("; weight x%.0f for soon-to-expire pantry items", b)
+	}
+	return pick.recipe, nil
+}
+
+// rotationStrategy is a full-cycle rotation: every recipe is served once before any repeats.
+//   - look back N days (where N = max(recipe count, min_lookback_days)) to build the "used" set
+//   - pick randomly from recipes NOT in the used set ("fresh" pool)
+//   - if all recipes have been used (end of cycle), reset and pick from all
+type rotationStrategy struct{}
+
+type rotationParams struct {
+	MinLookbackDays int `json:"min_lookback_days"`
+}
+
+func (rotationStrategy) Name() string { return "rotation" }
+func (rotationStrategy) Description() string {
+	return "Pure rotation: random pick among recipes not served yet in the current cycle"
+}
+func (rotationStrategy) ExampleParams() string { return `{"min_lookback_days": 7}` }
+
+func (rotationStrategy) ValidateParams(params string) error {
+	var p rotationParams
+	if err := decodeParams(params, &p); err != nil {
+		return err
+	}
+	if p.MinLookbackDays < 0 || p.MinLookbackDays > 365 {
+		return fmt.Errorf("min_lookback_days must be between 0 and 365")
+	}
+	return nil
+}
+
+func (rotationStrategy) Select(sc *SelectionContext) (*models.Recipe, error) {
+	p := rotationParams{MinLookbackDays: 7}
+	if err := decodeParams(sc.Params, &p); err != nil {
+		return nil, err
+	}
+
+	// Lookback window = recipe pool size (so every recipe appears before any repeats)
+	lookback := len(sc.Recipes)
+	if lookback < p.MinLookbackDays {
+		lookback = p.MinLookbackDays
+	}
+
+	// Only days before today count, so several time slots of one day may share the cycle state
+	used := sc.RecentlyUsed(lookback)
+	var fresh []models.Recipe
+	for _, r := range sc.Recipes {
+		if days, ok := used[r.ID]; !ok || days == 0 {
+			fresh = append(fresh, r)
+		}
+	}
+
+	d := sc.Decision
+	d.FreshPool = len(fresh)
+	d.Reason = fmt.Sprintf("random pick from %d of %d recipes not used in the last %d days", len(fresh), len(sc.Recipes), lookback)
+
+	pool := fresh
+	if len(pool) == 0 {
+		// Full cycle completed — reset and pick from entire pool
+		pool = sc.Recipes
+		d.CycleReset = true
+		d.Reason = fmt.Sprintf("cycle reset: all %d recipes were used in the last %d days", len(sc.Recipes), lookback)
+	}
+
+	chosen := *sc.Pick(pool)
+	if b := sc.Boost(chosen.ID); b > 1 {
+		d.Reason += fmt.Sprintf("; preferred x%.0f for soon-to-expire pantry items", b)
+	}
+	return &chosen, nil
+}
+
+// ratingWeightedStrategy prefers better rated recipes: weight = rating^exponent
+type ratingWeightedStrategy struct{}
+
+type ratingWeightedParams struct {
+	Exponent         float64 `json:"exponent"`
+	UnratedRating    float64 `json:"unrated_rating"`    // rating assumed for recipes rated 0
+	ExcludeYesterday *bool   `json:"exclude_yesterday"` // default true
+}
+
+func (ratingWeightedStrategy) Name() string { return "rating_weighted" }
+func (ratingWeightedStrategy) Description() string {
+	return "Random pick weighted by recipe rating; never repeats yesterday's recipe by default"
+}
+func (ratingWeightedStrategy) ExampleParams() string {
+	return `{"exponent": 2, "unrated_rating": 2.5, "exclude_yesterday": true}`
+}
+
+func (ratingWeightedStrategy) ValidateParams(params string) error {
+	var p ratingWeightedParams
+	if err := decodeParams(params, &p); err != nil {
+		return err
+	}
+	if p.Exponent < 0 || p.Exponent > 10 {
+		return fmt.Errorf("exponent must be between 0 and 10")
+	}
+	if p.UnratedRating < 0 || p.UnratedRating > 5 {
+		return fmt.Errorf("unrated_rating must be between 0 and 5")
+	}
+	return nil
+}
+
+func (ratingWeightedStrategy) Select(sc *SelectionContext) (*models.Recipe, error) {
+	p := ratingWeightedParams{Exponent: 2, UnratedRating: 2.5}
+	if err := decodeParams(sc.Params, &p); err != nil {
+		return nil, err
+	}
+
+	candidates := sc.Recipes
+	excluded := 0
+	if p.ExcludeYesterday == nil || *p.ExcludeYesterday {
+		yesterday := sc.Yesterday()
+		var rest []models.Recipe
+		for _, r := range sc.Recipes {
+			if !yesterday[r.ID] {
+				rest = append(rest, r)
+			}
+		}
+		if len(rest) > 0 {
+			excluded = len(candidates) - len(rest)
+			candidates = rest
+		}
+	}
+
+	weights := make([]float64, len(candidates))
+	total := 0.0
+	for i, r := range candidates {
+		rating := r.Rating
+		if rating <= 0 {
+			rating = p.UnratedRating
+		}
+		weights[i] = math.Pow(math.Max(rating, 0.1), p.Exponent) * sc.Boost(r.ID)
+		total += weights[i]
+	}
+
+	x := sc.Rand.Float64() * total
+	chosen := len(candidates) - 1
+	for i, w := range weights {
+		if x < w {
+			chosen = i
+			break
+		}
+		x -= w
+	}
+
+	recipe := candidates[chosen]
+	sc.Decision.Reason = fmt.Sprintf("rating-weighted pick from %d candidates (%d excluded as yesterday's): rating %.1f, weight %.2f of %.2f total",
+		len(candidates), excluded, recipe.Rating, weights[chosen], total)
+	return &recipe, nil
+}
+
+// fixedWeekdayStrategy serves a fixed recipe on given weekdays (e.g. a weekend menu) and
+// falls back to another strategy on the other days
+type fixedWeekdayStrategy struct{}
+
+type fixedWeekdayParams struct {
+	Days     map[string]uint `json:"days"`     // weekday name ("saturday") -> recipe ID
+	Fallback string          `json:"fallback"` // strategy for days without a fixed recipe
+}
+
+func (fixedWeekdayStrategy) Name() string { return "fixed_weekday" }
+func (fixedWeekdayStrategy) Description() string {
+	return "Fixed recipe per weekday (e.g. weekend menu); other days use the fallback strategy"
+}
+func (fixedWeekdayStrategy) ExampleParams() string {
+	return `{"days": {"saturday": 12, "sunday": 15}, "fallback": "weighted_rotation"}`
+}
+
+func (fixedWeekdayStrategy) ValidateParams(params string) error {
+	var p fixedWeekdayParams
+	if err := decodeParams(params, &p); err != nil {
+		return err
+	}
+	if len(p.Days) == 0 {
+		return fmt.Errorf("days must map at least one weekday to a recipe ID")
+	}
+	for day, recipeID := range p.Days {
+		if _, ok := parseWeekday(day); !ok {
+			return fmt.Errorf("unknown weekday %q", day)
+		}
+		if recipeID == 0 {
+			return fmt.Errorf("recipe ID for %s must be set", day)
+		}
+	}
+	if p.Fallback == "fixed_weekday" {
+		return fmt.Errorf("fallback cannot be fixed_weekday")
+	}
+	if _, ok := LookupStrategy(p.Fallback); !ok {
+		return fmt.Errorf("unknown fallback strategy %q", p.Fallback)
+	}
+	return nil
+}
+
+func (fixedWeekdayStrategy) Select(sc *SelectionContext) (*models.Recipe, error) {
+	var p fixedWeekdayParams
+	if err := decodeParams(sc.Params, &p); err != nil {
+		return nil, err
+	}
+
+	for day, recipeID := range p.Days {
+		if wd, ok := parseWeekday(day); !ok || wd != sc.Date.Weekday() {
+			continue
+		}
+		for i := range sc.Recipes {
+			if sc.Recipes[i].ID == recipeID {
+				sc.Decision.Reason = fmt.Sprintf("fixed menu for %s", sc.Date.Weekday())
+				return &sc.Recipes[i], nil
+			}
+		}
+		recipe, err := sc.Fallback(p.Fallback)
+		if err == nil {
+			sc.Decision.Reason = fmt.Sprintf("fixed recipe %d for %s is not eligible; %s", recipeID, sc.Date.Weekday(), sc.Decision.Reason)
+		}
+		return recipe, err
+	}
+
+	recipe, err := sc.Fallback(p.Fallback)
+	if err == nil {
+		sc.Decision.Reason = fmt.Sprintf("no fixed recipe for %s; %s", sc.Date.Weekday(), sc.Decision.Reason)
+	}
+	return recipe, err
+}
+
+// FixedRecipeIDs returns the distinct recipe IDs pinned by fixed_weekday params, sorted, so
+// the admin API can check they exist in the organization. Other strategies pin none
+func FixedRecipeIDs(name, params string) []uint {
+	var p fixedWeekdayParams
+	if name != (fixedWeekdayStrategy{}).Name() || decodeParams(params, &p) != nil {
+		return nil
+	}
+	seen := make(map[uint]bool, len(p.Days))
+	var ids []uint
+	for _, id := range p.Days {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// parseWeekday parses an English weekday name, case-insensitively
+func parseWeekday(name string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(d.String(), name) {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// leastRecentlyCookedStrategy serves the recipe whose last use in this slot is the oldest;
+// recipes never served come first, ties are broken randomly
+type leastRecentlyCookedStrategy struct{}
+
+func (leastRecentlyCookedStrategy) Name() string { return "least_recently_cooked" }
+func (leastRecentlyCookedStrategy) Description() string {
+	return "The recipe not served for the longest time; never-served recipes first"
+}
+func (leastRecentlyCookedStrategy) ExampleParams() string              { return "" }
+func (leastRecentlyCookedStrategy) ValidateParams(params string) error { return nil }
+
+func (leastRecentlyCookedStrategy) Select(sc *SelectionContext) (*models.Recipe, error) {
+	used := sc.RecentlyUsed(allHistoryDays)
+
+	const never = math.MaxInt32
+	oldest := -1
+	var best []int
+	for i, r := range sc.Recipes {
+		days, ok := used[r.ID]
+		if !ok {
+			days = never
+		}
+		switch {
+		case days > oldest:
+			oldest = days
+			best = []int{i}
+		case days == oldest:
+			best = append(best, i)
+		}
+	}
+
+	tied := make([]models.Recipe, len(best))
+	for i, idx := range best {
+		tied[i] = sc.Recipes[idx]
+	}
+	chosen := *sc.Pick(tied)
+	if oldest == never {
+		sc.Decision.Reason = fmt.Sprintf("never served; random pick among %d never-served recipes", len(best))
+	} else {
+		sc.Decision.Reason = fmt.Sprintf("last served %d days ago, the longest among %d recipes", oldest, len(sc.Recipes))
+	}
+	return &chosen, nil
+}
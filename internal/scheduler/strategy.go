@@ -0,0 +1,221 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+
+	"podlevskikh/awesomeProject/internal/models"
+)
+
+// DefaultStrategy is used for meal times without a strategy
+const DefaultStrategy = "weighted_rotation"
+
+// allHistoryDays is the lookback used when a strategy needs the whole cooking history
+const allHistoryDays = 3650
+
+// RecipeStrategy chooses the recipe for one meal slot. Each MealTime names its strategy and
+// stores the strategy's parameters as JSON (MealTime.Strategy, MealTime.StrategyParams).
+type RecipeStrategy interface {
+	Name() string
+	Description() string
+	// ExampleParams is a sample parameters object shown to admins ("" when there are none)
+	ExampleParams() string
+	// ValidateParams checks the JSON parameters; empty params mean defaults
+	ValidateParams(params string) error
+	// Select picks one of sc.Recipes (never empty) and explains the choice in sc.Decision.Reason
+	Select(sc *SelectionContext) (*models.Recipe, error)
+}
+
+// SelectionContext is what a strategy knows about the slot it fills
+type SelectionContext struct {
+	OrgID    uint
+	MealTime models.MealTime
+	Slot     string // task title, e.g. "Breakfast - adult"
+	Time     string
+	Date     models.Date
+	Recipes  []models.Recipe // eligible recipes ordered by ID
+	Params   string          // MealTime.StrategyParams
+	Rand     *rand.Rand
+	Decision *Decision
+	// Boosts are weight multipliers of recipes using soon-to-expire pantry items;
+	// nil unless the organization prefers such recipes (prefer_expiring_ingredients)
+	Boosts map[uint]float64
+
+	s *Scheduler
+}
+
+// RecentlyUsed returns days since last use in this slot for recipes used within `days` days
+func (sc *SelectionContext) RecentlyUsed(days int) map[uint]int {
+	return sc.s.getRecentlyUsedRecipes(sc.OrgID, sc.Slot, sc.Date, days)
+}
+
+// Yesterday returns the recipes served in this slot the day before
+func (sc *SelectionContext) Yesterday() map[uint]bool {
+	return sc.s.getYesterdayRecipes(sc.OrgID, sc.Slot, sc.Date)
+}
+
+// Weights returns the organization's rotation weights
+func (sc *SelectionContext) Weights() RotationWeights {
+	return sc.s.rotationWeights(sc.OrgID)
+}
+
+// Fallback selects with another registered strategy, e.g. for days a fixed menu does not cover
+func (sc *SelectionContext) Fallback(name string) (*models.Recipe, error) {
+	strategy, ok := LookupStrategy(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown recipe strategy %q", name)
+	}
+	fallback := *sc
+	fallback.Params = ""
+	recipe, err := strategy.Select(&fallback)
+	if err == nil {
+		sc.Decision.Reason = strategy.Name() + ": " + sc.Decision.Reason
+	}
+	return recipe, err
+}
+
+// Boost returns the weight multiplier of a recipe (1 when it has none)
+func (sc *SelectionContext) Boost(recipeID uint) float64 {
+	if b, ok := sc.Boosts[recipeID]; ok {
+		return b
+	}
+	return 1
+}
+
+// Pick chooses one of pool (never empty) at random, weighted by Boost;
+// a plain uniform pick when there are no boosts
+func (sc *SelectionContext) Pick(pool []models.Recipe) *models.Recipe {
+	if len(sc.Boosts) == 0 {
+		return &pool[sc.Rand.Intn(len(pool))]
+	}
+	total := 0.0
+	for _, r := range pool {
+		total += sc.Boost(r.ID)
+	}
+	x := sc.Rand.Float64() * total
+	for i, r := range pool {
+		if x < sc.Boost(r.ID) {
+			return &pool[i]
+		}
+		x -= sc.Boost(r.ID)
+	}
+	return &pool[len(pool)-1]
+}
+
+// decodeParams unmarshals strategy parameters into dst; empty params leave dst unchanged
+func decodeParams(params string, dst interface{}) error {
+	if params == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(params), dst); err != nil {
+		return fmt.Errorf("invalid strategy params: %w", err)
+	}
+	return nil
+}
+
+var strategies = map[string]RecipeStrategy{}
+
+// RegisterStrategy adds a strategy to the registry, replacing one with the same name
+func RegisterStrategy(strategy RecipeStrategy) {
+	strategies[strategy.Name()] = strategy
+}
+
+// LookupStrategy returns a registered strategy; an empty name means DefaultStrategy
+func LookupStrategy(name string) (RecipeStrategy, bool) {
+	if name == "" {
+		name = DefaultStrategy
+	}
+	strategy, ok := strategies[name]
+	return strategy, ok
+}
+
+// StrategyInfo describes a registered strategy for the admin API
+type StrategyInfo struct {
+	Name          string `json:"name"`
+	Description   string `json:"description"`
+	ExampleParams string `json:"example_params,omitempty"`
+	Default       bool   `json:"default"`
+}
+
+// Strategies lists the registered strategies by name
+func Strategies() []StrategyInfo {
+	list := make([]StrategyInfo, 0, len(strategies))
+	for name, strategy := range strategies {
+		list = append(list, StrategyInfo{
+			Name:          name,
+			Description:   strategy.Description(),
+			ExampleParams: strategy.ExampleParams(),
+			Default:       name == DefaultStrategy,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// ValidateStrategy checks a strategy name and its parameters before they are saved on a MealTime
+func ValidateStrategy(name, params string) error {
+	strategy, ok := LookupStrategy(name)
+	if !ok {
+		return fmt.Errorf("unknown recipe strategy %q", name)
+	}
+	if params != "" && !json.Valid([]byte(params)) {
+		return fmt.Errorf("strategy params must be valid JSON")
+	}
+	return strategy.ValidateParams(params)
+}
+
+// selectRecipeForMeal picks a recipe for a meal slot through the meal time's strategy.
+// The random pick uses rng; the strategy, pool size and the reason for the choice are written to d.
+func (s *Scheduler) selectRecipeForMeal(orgID uint, mealTime models.MealTime, title, timeSlot string, currentDate models.Date, rng *rand.Rand, d *Decision) (*models.Recipe, error) {
+	recipes, err := s.eligibleRecipes(orgID, mealTime.ID, mealTime.Name)
+	if err != nil {
+		return nil, err
+	}
+	if len(recipes) == 0 {
+		return nil, fmt.Errorf("no recipes found for meal time %d (%s)", mealTime.ID, mealTime.Name)
+	}
+	eligible := len(recipes)
+	recipes, dropped, err := s.withoutConflicts(mealTime, recipes, currentDate, timeSlot)
+	if err != nil {
+		return nil, err
+	}
+	d.Excluded = eligible - len(recipes)
+	if len(recipes) == 0 {
+		return nil, fmt.Errorf("all %d recipes for meal time %d (%s) conflict with dietary restrictions: %s", eligible, mealTime.ID, mealTime.Name, dropped)
+	}
+	d.PoolSize = len(recipes)
+
+	strategy, ok := LookupStrategy(mealTime.Strategy)
+	params := mealTime.StrategyParams
+	if !ok {
+		log.Printf("Warning: meal time %d has unknown strategy %q, using %s", mealTime.ID, mealTime.Strategy, DefaultStrategy)
+		strategy, _ = LookupStrategy(DefaultStrategy)
+		params = ""
+	}
+	d.Strategy = strategy.Name()
+
+	recipe, err := strategy.Select(&SelectionContext{
+		OrgID:    orgID,
+		MealTime: mealTime,
+		Slot:     title,
+		Time:     timeSlot,
+		Date:     currentDate,
+		Recipes:  recipes,
+		Params:   params,
+		Rand:     rng,
+		Decision: d,
+		Boosts:   s.expiringBoosts(orgID, recipes, currentDate),
+		s:        s,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if dropped != "" {
+		d.Reason += "; excluded for dietary restrictions: " + dropped
+	}
+	log.Printf("Selected recipe '%s' for meal time %d/%s (%s): %s", recipe.Name, mealTime.ID, mealTime.Name, d.Strategy, d.Reason)
+	return recipe, nil
+}
@@ -0,0 +1,141 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+
+	"podlevskikh/awesomeProject/internal/models"
+	"podlevskikh/awesomeProject/internal/timeline"
+	"podlevskikh/awesomeProject/internal/workload"
+)
+
+// defaultWorkday is where flexible tasks are placed when the organization has no work profiles
+var defaultWorkday = timeline.Span{Start: 8 * 60, End: 20 * 60}
+
+// taskSpan returns when a timed task takes place: from Time to EndTime, or for Duration
+// minutes when it has no end. Tasks without a time are flexible and have no span.
+func taskSpan(t models.ScheduleTask) (timeline.Span, bool) {
+	if t.Time == "" {
+		return timeline.Span{}, false
+	}
+	start, err := timeline.ParseClock(t.Time)
+	if err != nil {
+		return timeline.Span{}, false
+	}
+	if t.EndTime != "" {
+		if end, err := timeline.ParseClock(t.EndTime); err == nil && end > start {
+			return timeline.Span{Start: start, End: end}, true
+		}
+	}
+	return timeline.Span{Start: start, End: min(start+t.Duration, timeline.Day.End)}, true
+}
+
+// fixedTasks returns the spans of the schedule's timed tasks
+func fixedTasks(tasks []models.ScheduleTask) []timeline.Busy {
+	var busy []timeline.Busy
+	for _, t := range tasks {
+		if span, ok := taskSpan(t); ok {
+			busy = append(busy, timeline.Busy{Span: span, Label: t.Title})
+		}
+	}
+	return busy
+}
+
+// placeTasks gives flexible tasks a Time and EndTime in the gaps the schedule's timed tasks
+// (meals, childcare, ...) and the helpers' breaks leave within the working day. preferred holds
+// each task's preferred time (see timeline.ParsePreference); a task that does not fit into it
+// goes to the earliest gap of the day, and one that fits nowhere keeps no time and gets a warning.
+func (s *Scheduler) placeTasks(schedule *models.DailySchedule, tasks []*models.ScheduleTask, preferred []string) error {
+	capacity, err := workload.Load(s.db, schedule.OrganizationID)
+	if err != nil {
+		return err
+	}
+	day := defaultWorkday
+	busy := fixedTasks(schedule.Tasks)
+	if capacity.Known() {
+		day = capacity.Window
+		for _, off := range capacity.Off {
+			busy = append(busy, timeline.Busy{Span: off, Label: "break"})
+		}
+	}
+
+	items := make([]timeline.Item, len(tasks))
+	notes := make([]string, len(tasks))
+	for i, t := range tasks {
+		items[i] = timeline.Item{Label: t.Title, Duration: t.Duration}
+		items[i].Window, notes[i] = preferredWindow(preferred[i], schedule.Tasks, day)
+	}
+
+	for i, p := range timeline.Allocate(day, busy, items) {
+		t := tasks[i]
+		decision := Decision{Date: schedule.Date, Kind: "timeline", Subject: t.Title}
+		if !p.Placed {
+			s.warn(schedule, fmt.Sprintf("no free %s slot for %s between %s and %s",
+				workload.Format(t.Duration), t.Title, timeline.Clock(day.Start), timeline.Clock(day.End)))
+			continue
+		}
+		t.Time, t.EndTime = timeline.Clock(p.Start), timeline.Clock(p.End)
+		decision.Time = t.Time
+		decision.Choice = p.Span.String()
+		switch {
+		case notes[i] != "":
+			decision.Reason = notes[i] + ", placed in the first free slot"
+		case items[i].Window == nil:
+			decision.Reason = "first free slot"
+		case p.InWindow:
+			decision.Reason = fmt.Sprintf("first free slot within the preferred %s", items[i].Window)
+		default:
+			decision.Reason = fmt.Sprintf("no room within the preferred %s, placed in the first free slot", items[i].Window)
+		}
+		s.output().decide(decision)
+	}
+	return nil
+}
+
+// preferredWindow resolves a preferred time against the day's meals: "after dinner" starts when
+// the last dinner task ends, "before lunch" ends when the first lunch task starts. The note
+// explains why a preference could not be applied.
+func preferredWindow(spec string, tasks []models.ScheduleTask, day timeline.Span) (*timeline.Span, string) {
+	pref, err := timeline.ParsePreference(spec)
+	if err != nil {
+		return nil, err.Error()
+	}
+	if pref.Span != nil || (pref.After == "" && pref.Before == "") {
+		return pref.Span, ""
+	}
+
+	meal := pref.After + pref.Before
+	var spans []timeline.Span
+	for _, t := range tasks {
+		name, _, _ := strings.Cut(t.Title, " - ")
+		if t.TaskType != "meal" || !strings.EqualFold(strings.TrimSpace(name), meal) {
+			continue
+		}
+		if span, ok := taskSpan(t); ok {
+			spans = append(spans, span)
+		}
+	}
+	if len(spans) == 0 {
+		return nil, fmt.Sprintf("no %s today for %q", meal, spec)
+	}
+
+	window := day
+	for _, span := range spans {
+		if pref.After != "" {
+			window.Start = max(window.Start, span.End)
+		} else {
+			window.End = min(window.End, span.Start)
+		}
+	}
+	if window.Len() <= 0 {
+		return nil, fmt.Sprintf("no working time %s", spec)
+	}
+	return &window, ""
+}
+
+// checkOverlaps warns admins about timed tasks of the schedule that overlap
+func (s *Scheduler) checkOverlaps(schedule *models.DailySchedule) {
+	for _, o := range timeline.Overlaps(fixedTasks(schedule.Tasks)) {
+		s.warn(schedule, o.String())
+	}
+}
@@ -0,0 +1,52 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+
+	"podlevskikh/awesomeProject/internal/models"
+	"podlevskikh/awesomeProject/internal/workload"
+)
+
+// remainingMinutes returns the working time the helpers have left after the tasks already in
+// the schedule, or -1 when the organization has no work profiles and the day is not limited
+func (s *Scheduler) remainingMinutes(schedule *models.DailySchedule) (int, error) {
+	capacity, err := workload.Load(s.db, schedule.OrganizationID)
+	if err != nil || !capacity.Known() {
+		return -1, err
+	}
+	return max(capacity.Minutes-workload.Planned(schedule.Tasks), 0), nil
+}
+
+// checkWorkload records the helpers' working time and the planned task time on the schedule
+// and warns admins when the tasks that cannot be deferred (meals, childcare, kept tasks) do
+// not fit into it
+func (s *Scheduler) checkWorkload(schedule *models.DailySchedule) error {
+	capacity, err := workload.Load(s.db, schedule.OrganizationID)
+	if err != nil {
+		return err
+	}
+	schedule.CapacityMinutes = capacity.Minutes
+	schedule.PlannedMinutes = workload.Planned(schedule.Tasks)
+	if capacity.Known() && schedule.PlannedMinutes > capacity.Minutes {
+		s.warn(schedule, fmt.Sprintf("tasks take %s, %s over the helpers' working time of %s",
+			workload.Format(schedule.PlannedMinutes), workload.Format(schedule.PlannedMinutes-capacity.Minutes),
+			workload.Format(capacity.Minutes)))
+	}
+	return s.output().saveSchedule(s.db, schedule)
+}
+
+// warn adds a workload warning to the schedule, shown to admins with it
+func (s *Scheduler) warn(schedule *models.DailySchedule, warning string) {
+	if schedule.Warnings != "" {
+		schedule.Warnings += "\n"
+	}
+	schedule.Warnings += warning
+	s.output().decide(Decision{Date: schedule.Date, Kind: "workload", Subject: "capacity", Reason: warning})
+}
+
+// deferredWarning describes the cleaning zones moved to a later day for lack of working time
+func deferredWarning(zones []string, left int) string {
+	return fmt.Sprintf("over capacity: cleaning of %s deferred, %s of working time left",
+		strings.Join(zones, ", "), workload.Format(left))
+}
@@ -0,0 +1,349 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"podlevskikh/awesomeProject/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Kind — тип значения настройки. В БД все значения хранятся строкой.
+type Kind string
+
+const (
+	KindInt    Kind = "int"
+	KindBool   Kind = "bool"
+	KindString Kind = "string"
+	KindJSON   Kind = "json"
+)
+
+// Ключи известных настроек.
+const (
+	KeyScheduleDaysAhead     = "schedule_days_ahead"
+	KeyAutoGenerateSchedule  = "auto_generate_schedule"
+	KeyRecipeRotationWeights = "recipe_rotation_weights"
+	KeyAutoShoppingList      = "auto_generate_shopping_list"
+	KeyShoppingDaysAhead     = "shopping_list_days_ahead"
+	KeyPreferExpiring        = "prefer_expiring_ingredients"
+	KeyExpiringWithinDays    = "expiring_within_days"
+	KeyCleaningMaxZones      = "cleaning_max_zones_per_day"
+)
+
+// Definition — описание настройки: тип, значение по умолчанию и ограничения.
+type Definition struct {
+	Key         string
+	Kind        Kind
+	Default     string
+	Description string
+	Min, Max    int                // для KindInt; Min == Max == 0 — без ограничений
+	Validate    func(string) error // дополнительная проверка (опционально)
+}
+
+// Definitions — реестр всех настроек. Значение по умолчанию берётся отсюда,
+// строка в таблице settings переопределяет его для конкретной организации.
+var Definitions = []Definition{
+	{
+		Key:         KeyScheduleDaysAhead,
+		Kind:        KindInt,
+		Default:     "7",
+		Description: "Number of days to generate schedule ahead",
+		Min:         1,
+		Max:         60,
+	},
+	{
+		Key:         KeyAutoGenerateSchedule,
+		Kind:        KindBool,
+		Default:     "true",
+		Description: "Automatically generate schedule daily",
+	},
+	{
+		Key:  KeyRecipeRotationWeights,
+		Kind: KindJSON,
+		Default: `{"never_used":5.0,"lookback_days":30,"tiers":[` +
+			`{"min_days":21,"weight":3.0},{"min_days":14,"weight":2.0},{"min_days":7,"weight":1.5},` +
+			`{"min_days":5,"weight":1.0},{"min_days":4,"weight":0.8},{"min_days":3,"weight":0.5},` +
+			`{"min_days":2,"weight":0.3},{"min_days":1,"weight":0.1},{"min_days":0,"weight":0.05}]}`,
+		Description: "Recipe rotation weights by days since last use (see RECIPE_ROTATION_ALGORITHM.md)",
+		Validate:    validateRotationWeights,
+	},
+	{
+		Key:         KeyAutoShoppingList,
+		Kind:        KindBool,
+		Default:     "false",
+		Description: "Automatically add ingredients of upcoming meals to the shopping list daily",
+	},
+	{
+		Key:         KeyShoppingDaysAhead,
+		Kind:        KindInt,
+		Default:     "3",
+		Description: "Number of days of upcoming meals covered by the generated shopping list",
+		Min:         1,
+		Max:         14,
+	},
+	{
+		Key:         KeyPreferExpiring,
+		Kind:        KindBool,
+		Default:     "false",
+		Description: "Prefer recipes that use pantry items about to expire when generating meals",
+	},
+	{
+		Key:         KeyExpiringWithinDays,
+		Kind:        KindInt,
+		Default:     "3",
+		Description: "Pantry items expiring within this many days count as soon-to-expire",
+		Min:         1,
+		Max:         30,
+	},
+	{
+		Key:         KeyCleaningMaxZones,
+		Kind:        KindInt,
+		Default:     "3",
+		Description: "Most cleaning zones scheduled on one working day; overdue zones beyond it move to the next day",
+		Min:         1,
+		Max:         20,
+	},
+}
+
+// validateRotationWeights проверяет веса ротации рецептов: положительные веса,
+// разные пороги дней и обязательный порог 0 (использован сегодня).
+func validateRotationWeights(value string) error {
+	var w struct {
+		NeverUsed    float64 `json:"never_used"`
+		LookbackDays int     `json:"lookback_days"`
+		Tiers        []struct {
+			MinDays int     `json:"min_days"`
+			Weight  float64 `json:"weight"`
+		} `json:"tiers"`
+	}
+	if err := json.Unmarshal([]byte(value), &w); err != nil {
+		return fmt.Errorf("must be an object with never_used, lookback_days and tiers")
+	}
+	if w.NeverUsed <= 0 {
+		return fmt.Errorf("never_used must be positive")
+	}
+	if w.LookbackDays < 1 || w.LookbackDays > 365 {
+		return fmt.Errorf("lookback_days must be between 1 and 365")
+	}
+	seen := make(map[int]bool, len(w.Tiers))
+	for _, t := range w.Tiers {
+		if t.MinDays < 0 || t.Weight <= 0 {
+			return fmt.Errorf("tiers need min_days >= 0 and a positive weight")
+		}
+		if seen[t.MinDays] {
+			return fmt.Errorf("duplicate tier for min_days %d", t.MinDays)
+		}
+		seen[t.MinDays] = true
+	}
+	if !seen[0] {
+		return fmt.Errorf("tiers must include min_days 0")
+	}
+	return nil
+}
+
+// ErrUnknownKey — настройки с таким ключом нет в реестре.
+var ErrUnknownKey = errors.New("unknown setting")
+
+// ValidationError — значение не прошло проверку типа или диапазона.
+type ValidationError struct {
+	Key     string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid value for %s: %s", e.Key, e.Message)
+}
+
+// Value — эффективное значение настройки для организации.
+type Value struct {
+	Key         string     `json:"key"`
+	Kind        Kind       `json:"kind"`
+	Value       string     `json:"value"`
+	Default     string     `json:"default"`
+	Description string     `json:"description"`
+	Overridden  bool       `json:"overridden"` // значение задано организацией, а не взято по умолчанию
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
+
+// Service — типизированный доступ к models.Settings с умолчаниями и валидацией.
+type Service struct {
+	db *gorm.DB
+}
+
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+// Lookup возвращает описание настройки по ключу.
+func Lookup(key string) (Definition, bool) {
+	for _, def := range Definitions {
+		if def.Key == key {
+			return def, true
+		}
+	}
+	return Definition{}, false
+}
+
+// Check проверяет значение на соответствие типу и ограничениям настройки.
+func (def Definition) Check(value string) error {
+	switch def.Kind {
+	case KindInt:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return &ValidationError{Key: def.Key, Message: "must be an integer"}
+		}
+		if def.Min != 0 || def.Max != 0 {
+			if n < def.Min || n > def.Max {
+				return &ValidationError{Key: def.Key, Message: fmt.Sprintf("must be between %d and %d", def.Min, def.Max)}
+			}
+		}
+	case KindBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return &ValidationError{Key: def.Key, Message: "must be true or false"}
+		}
+	case KindJSON:
+		if !json.Valid([]byte(value)) {
+			return &ValidationError{Key: def.Key, Message: "must be valid JSON"}
+		}
+	}
+	if def.Validate != nil {
+		if err := def.Validate(value); err != nil {
+			return &ValidationError{Key: def.Key, Message: err.Error()}
+		}
+	}
+	return nil
+}
+
+// List возвращает все известные настройки организации с учётом переопределений.
+func (s *Service) List(orgID uint) ([]Value, error) {
+	var rows []models.Settings
+	if err := s.db.Where("organization_id = ?", orgID).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	byKey := make(map[string]models.Settings, len(rows))
+	for _, r := range rows {
+		byKey[r.Key] = r
+	}
+
+	values := make([]Value, 0, len(Definitions))
+	for _, def := range Definitions {
+		values = append(values, resolve(def, byKey[def.Key]))
+	}
+	return values, nil
+}
+
+// Get возвращает эффективное значение одной настройки.
+func (s *Service) Get(orgID uint, key string) (Value, error) {
+	def, ok := Lookup(key)
+	if !ok {
+		return Value{}, ErrUnknownKey
+	}
+	var row models.Settings
+	err := s.db.Where("organization_id = ? AND key = ?", orgID, key).First(&row).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return Value{}, err
+	}
+	return resolve(def, row), nil
+}
+
+// Set проверяет и сохраняет переопределение настройки для организации.
+func (s *Service) Set(orgID uint, key, value string) (Value, error) {
+	def, ok := Lookup(key)
+	if !ok {
+		return Value{}, ErrUnknownKey
+	}
+	if err := def.Check(value); err != nil {
+		return Value{}, err
+	}
+
+	var row models.Settings
+	err := s.db.Where("organization_id = ? AND key = ?", orgID, key).First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		row = models.Settings{OrganizationID: orgID, Key: key}
+	} else if err != nil {
+		return Value{}, err
+	}
+	row.Value = value
+	row.Description = def.Description
+	if err := s.db.Save(&row).Error; err != nil {
+		return Value{}, err
+	}
+	return resolve(def, row), nil
+}
+
+// Reset удаляет переопределение — настройка возвращается к значению по умолчанию.
+func (s *Service) Reset(orgID uint, key string) (Value, error) {
+	def, ok := Lookup(key)
+	if !ok {
+		return Value{}, ErrUnknownKey
+	}
+	if err := s.db.Where("organization_id = ? AND key = ?", orgID, key).Delete(&models.Settings{}).Error; err != nil {
+		return Value{}, err
+	}
+	return resolve(def, models.Settings{}), nil
+}
+
+// Int возвращает целочисленную настройку; при ошибке — значение по умолчанию.
+func (s *Service) Int(orgID uint, key string) int {
+	n, _ := strconv.Atoi(s.value(orgID, key))
+	return n
+}
+
+// Bool возвращает булеву настройку; при ошибке — значение по умолчанию.
+func (s *Service) Bool(orgID uint, key string) bool {
+	b, _ := strconv.ParseBool(s.value(orgID, key))
+	return b
+}
+
+// String возвращает строковую настройку; при ошибке — значение по умолчанию.
+func (s *Service) String(orgID uint, key string) string {
+	return s.value(orgID, key)
+}
+
+// JSON декодирует JSON-настройку в dst; при ошибке — значение по умолчанию.
+func (s *Service) JSON(orgID uint, key string, dst interface{}) error {
+	return json.Unmarshal([]byte(s.value(orgID, key)), dst)
+}
+
+// value возвращает проверенное эффективное значение, откатываясь к умолчанию
+// при ошибке БД или некорректной строке в таблице.
+func (s *Service) value(orgID uint, key string) string {
+	def, ok := Lookup(key)
+	if !ok {
+		log.Printf("settings: unknown key %q requested", key)
+		return ""
+	}
+	v, err := s.Get(orgID, key)
+	if err != nil {
+		log.Printf("settings: org %d, %s: %v — using default", orgID, key, err)
+		return def.Default
+	}
+	if err := def.Check(v.Value); err != nil {
+		log.Printf("settings: org %d: %v — using default", orgID, err)
+		return def.Default
+	}
+	return v.Value
+}
+
+// resolve накладывает строку организации (если есть) на значение по умолчанию.
+func resolve(def Definition, row models.Settings) Value {
+	v := Value{
+		Key:         def.Key,
+		Kind:        def.Kind,
+		Value:       def.Default,
+		Default:     def.Default,
+		Description: def.Description,
+	}
+	if row.ID != 0 {
+		v.Value = row.Value
+		v.Overridden = true
+		updatedAt := row.UpdatedAt
+		v.UpdatedAt = &updatedAt
+	}
+	return v
+}
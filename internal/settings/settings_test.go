@@ -0,0 +1,110 @@
+package settings
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"podlevskikh/awesomeProject/internal/models"
+)
+
+// Значения по умолчанию из реестра проходят собственную проверку, а ключи не повторяются.
+func TestDefinitionsDefaults(t *testing.T) {
+	seen := make(map[string]bool, len(Definitions))
+	for _, def := range Definitions {
+		if seen[def.Key] {
+			t.Errorf("%s defined twice", def.Key)
+		}
+		seen[def.Key] = true
+		if err := def.Check(def.Default); err != nil {
+			t.Errorf("default of %s: %v", def.Key, err)
+		}
+	}
+	if _, ok := Lookup("no_such_setting"); ok {
+		t.Error("Lookup of an unknown key succeeded")
+	}
+}
+
+// Строка организации перекрывает значение по умолчанию; без неё действует умолчание.
+func TestResolve(t *testing.T) {
+	def, ok := Lookup(KeyScheduleDaysAhead)
+	if !ok {
+		t.Fatalf("%s is not defined", KeyScheduleDaysAhead)
+	}
+	updated := time.Date(2025, time.June, 10, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name       string
+		row        models.Settings
+		want       string
+		overridden bool
+	}{
+		{"default", models.Settings{}, "7", false},
+		{"org override", models.Settings{ID: 3, OrganizationID: 2, Key: KeyScheduleDaysAhead, Value: "14", UpdatedAt: updated}, "14", true},
+		{"unsaved row", models.Settings{OrganizationID: 2, Key: KeyScheduleDaysAhead, Value: "14"}, "7", false},
+	}
+	for _, c := range cases {
+		v := resolve(def, c.row)
+		if v.Value != c.want || v.Overridden != c.overridden || v.Default != "7" || v.Kind != KindInt {
+			t.Errorf("%s: %+v, want value %s, overridden %v", c.name, v, c.want, c.overridden)
+		}
+		if c.overridden && (v.UpdatedAt == nil || !v.UpdatedAt.Equal(updated)) {
+			t.Errorf("%s: updated_at %v, want %s", c.name, v.UpdatedAt, updated)
+		}
+		if !c.overridden && v.UpdatedAt != nil {
+			t.Errorf("%s: updated_at %v, want none", c.name, v.UpdatedAt)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	unbounded := Definition{Key: "n", Kind: KindInt}
+	cases := []struct {
+		key     string
+		def     *Definition // вместо Lookup(key)
+		value   string
+		message string // "" — значение допустимо
+	}{
+		{key: KeyScheduleDaysAhead, value: "1"},
+		{key: KeyScheduleDaysAhead, value: "60"},
+		{key: KeyScheduleDaysAhead, value: "0", message: "must be between 1 and 60"},
+		{key: KeyScheduleDaysAhead, value: "61", message: "must be between 1 and 60"},
+		{key: KeyScheduleDaysAhead, value: "seven", message: "must be an integer"},
+		{key: KeyScheduleDaysAhead, value: "7.5", message: "must be an integer"},
+		{key: KeyCleaningMaxZones, value: "21", message: "must be between 1 and 20"},
+		{key: "n", def: &unbounded, value: "-100"},
+		{key: KeyAutoGenerateSchedule, value: "false"},
+		{key: KeyAutoGenerateSchedule, value: "yes", message: "must be true or false"},
+		{key: KeyRecipeRotationWeights, value: "{", message: "must be valid JSON"},
+		{key: KeyRecipeRotationWeights, value: `{"never_used":5,"lookback_days":30,"tiers":[{"min_days":0,"weight":1}]}`},
+		{key: KeyRecipeRotationWeights, value: `{"never_used":0,"lookback_days":30,"tiers":[{"min_days":0,"weight":1}]}`, message: "never_used must be positive"},
+		{key: KeyRecipeRotationWeights, value: `{"never_used":5,"lookback_days":400,"tiers":[{"min_days":0,"weight":1}]}`, message: "lookback_days must be between 1 and 365"},
+		{key: KeyRecipeRotationWeights, value: `{"never_used":5,"lookback_days":30,"tiers":[{"min_days":7,"weight":1}]}`, message: "tiers must include min_days 0"},
+		{key: KeyRecipeRotationWeights, value: `{"never_used":5,"lookback_days":30,"tiers":[{"min_days":0,"weight":1},{"min_days":0,"weight":2}]}`, message: "duplicate tier for min_days 0"},
+		{key: KeyRecipeRotationWeights, value: `{"never_used":5,"lookback_days":30,"tiers":[{"min_days":0,"weight":-1}]}`, message: "positive weight"},
+	}
+	for _, c := range cases {
+		def, ok := Lookup(c.key)
+		if c.def != nil {
+			def, ok = *c.def, true
+		}
+		if !ok {
+			t.Fatalf("%s is not defined", c.key)
+		}
+		err := def.Check(c.value)
+		if c.message == "" {
+			if err != nil {
+				t.Errorf("%s = %q: %v", c.key, c.value, err)
+			}
+			continue
+		}
+		var verr *ValidationError
+		if !errors.As(err, &verr) {
+			t.Errorf("%s = %q: error %v, want a ValidationError", c.key, c.value, err)
+			continue
+		}
+		if verr.Key != c.key || !strings.Contains(verr.Message, c.message) {
+			t.Errorf("%s = %q: %v, want %q", c.key, c.value, err, c.message)
+		}
+	}
+}
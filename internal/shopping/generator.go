@@ -0,0 +1,413 @@
+// Package shopping — список покупок из запланированных приёмов пищи: состав рецептов
+// ближайших дней суммируется по продуктам и сливается с открытым списком организации.
+package shopping
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"podlevskikh/awesomeProject/internal/ingredients"
+	"podlevskikh/awesomeProject/internal/models"
+	"podlevskikh/awesomeProject/internal/pantry"
+	"podlevskikh/awesomeProject/internal/units"
+
+	"gorm.io/gorm"
+)
+
+// AddedByGenerator — значение ShoppingListItem.AddedBy для позиций, созданных генератором.
+const AddedByGenerator = "generator"
+
+// Generator собирает потребности в продуктах из meal-задач расписания.
+type Generator struct {
+	db *gorm.DB
+}
+
+func NewGenerator(db *gorm.DB) *Generator {
+	return &Generator{db: db}
+}
+
+// Result — итог генерации.
+type Result struct {
+	From               models.Date               `json:"from"`
+	To                 models.Date               `json:"to"`    // включительно
+	Meals              int                       `json:"meals"` // незавершённых приёмов пищи с рецептами
+	Added              []models.ShoppingListItem `json:"added"`
+	Updated            []models.ShoppingListItem `json:"updated"`
+	AlreadyCounted     int                       `json:"already_counted"`               // потребности, учтённые прошлыми запусками
+	WithoutIngredients []string                  `json:"without_ingredients,omitempty"` // рецепты без структурированного состава
+	CoveredByPantry    []string                  `json:"covered_by_pantry,omitempty"`   // продукты, которых хватает в запасах
+}
+
+// needKey — потребность одного рецепта одного приёма пищи в одном продукте (ключ ShoppingItemSource).
+type needKey struct {
+	date               string // models.Date.String(): time.Time в ключе map сравнивается вместе с зоной
+	slot               string
+	recipe, ingredient uint
+	unit               string
+}
+
+func keyOf(s models.ShoppingItemSource) needKey {
+	return needKey{date: s.Date.String(), slot: s.Slot, recipe: s.RecipeID, ingredient: s.IngredientID, unit: s.Unit}
+}
+
+// need — потребность и название продукта для новой позиции.
+type need struct {
+	source models.ShoppingItemSource
+	name   string
+}
+
+// Generate суммирует состав рецептов незавершённых приёмов пищи за days дней начиная с from
+// и добавляет в открытый список покупок то, чего нет в запасах. Повторный запуск добавляет только потребности,
+// которых ещё не было: уже учтённые приёмы пищи и рецепты пропускаются.
+func (g *Generator) Generate(orgID uint, from models.Date, days int) (*Result, error) {
+	if days < 1 {
+		return nil, fmt.Errorf("days must be positive")
+	}
+	res := &Result{From: from, To: from.AddDays(days - 1)}
+	err := g.db.Transaction(func(tx *gorm.DB) error {
+		needs, err := collect(tx, orgID, from, days, res)
+		if err != nil {
+			return err
+		}
+		fresh, counted, err := skipCounted(tx, orgID, from, days, needs, res)
+		if err != nil {
+			return err
+		}
+		return merge(tx, orgID, from, fresh, counted, res)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// collect загружает meal-задачи периода и раскладывает состав их рецептов на потребности.
+func collect(tx *gorm.DB, orgID uint, from models.Date, days int, res *Result) ([]need, error) {
+	var schedules []models.DailySchedule
+	err := tx.Where("organization_id = ? AND date >= ? AND date < ?", orgID, from, from.AddDays(days)).
+		Preload("Tasks", "task_type = ? AND completed = ?", "meal", false).
+		Preload("Tasks.Recipes.RecipeIngredients.Ingredient").
+		Preload("Tasks.Recipe.RecipeIngredients.Ingredient").
+		Order("date").
+		Find(&schedules).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load meal tasks: %w", err)
+	}
+
+	byKey := make(map[needKey]*need)
+	var order []needKey
+	missing := make(map[uint]bool)
+	for _, schedule := range schedules {
+		for _, task := range schedule.Tasks {
+			recipes := taskRecipes(task)
+			if len(recipes) == 0 {
+				continue
+			}
+			res.Meals++
+			for _, recipe := range recipes {
+				if len(recipe.RecipeIngredients) == 0 {
+					if !missing[recipe.ID] {
+						missing[recipe.ID] = true
+						res.WithoutIngredients = append(res.WithoutIngredients, recipe.Name)
+					}
+					continue
+				}
+				scale := servingsScale(task, recipe)
+				for _, line := range recipe.RecipeIngredients {
+					if line.IngredientID == nil {
+						continue
+					}
+					source := models.ShoppingItemSource{
+						OrganizationID: orgID,
+						Date:           schedule.Date,
+						Slot:           task.Title,
+						RecipeID:       recipe.ID,
+						IngredientID:   *line.IngredientID,
+						Unit:           line.Unit,
+						ScheduleTaskID: task.ID,
+						RecipeName:     recipe.Name,
+					}
+					key := keyOf(source)
+					n, ok := byKey[key]
+					if !ok {
+						name := line.Name
+						if line.Ingredient != nil {
+							name = line.Ingredient.Name
+						}
+						n = &need{name: name, source: source}
+						byKey[key] = n
+						order = append(order, key)
+					}
+					if line.Quantity != nil {
+						q := units.Quantity{Amount: *line.Quantity, Unit: line.Unit}.Scale(scale)
+						n.source.Quantity = addQuantity(n.source.Quantity, q.Amount)
+					}
+				}
+			}
+		}
+	}
+
+	needs := make([]need, 0, len(order))
+	for _, key := range order {
+		needs = append(needs, *byKey[key])
+	}
+	return needs, nil
+}
+
+// taskRecipes возвращает рецепты задачи; для задач, созданных до meal_recipes,
+// — рецепт из устаревшего recipe_id.
+func taskRecipes(task models.ScheduleTask) []models.Recipe {
+	if len(task.Recipes) == 0 && task.Recipe != nil {
+		return []models.Recipe{*task.Recipe}
+	}
+	return task.Recipes
+}
+
+// servingsScale — во сколько раз умножить состав рецепта для задачи: порции задачи
+// (ScheduleTask.Servings) к порциям рецепта. Если одно из чисел неизвестно — один раз по рецепту.
+func servingsScale(task models.ScheduleTask, recipe models.Recipe) float64 {
+	if task.Servings > 0 && recipe.Servings > 0 {
+		return float64(task.Servings) / float64(recipe.Servings)
+	}
+	return 1
+}
+
+// skipCounted отделяет потребности, уже записанные в shopping_item_sources (counted), от новых.
+func skipCounted(tx *gorm.DB, orgID uint, from models.Date, days int, needs []need, res *Result) (fresh, counted []need, err error) {
+	if len(needs) == 0 {
+		return nil, nil, nil
+	}
+	var existing []models.ShoppingItemSource
+	err = tx.Where("organization_id = ? AND date >= ? AND date < ?", orgID, from, from.AddDays(days)).
+		Find(&existing).Error
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load counted needs: %w", err)
+	}
+	seen := make(map[needKey]bool, len(existing))
+	for _, s := range existing {
+		seen[keyOf(s)] = true
+	}
+
+	for _, n := range needs {
+		if seen[keyOf(n.source)] {
+			res.AlreadyCounted++
+			counted = append(counted, n)
+			continue
+		}
+		fresh = append(fresh, n)
+	}
+	return fresh, counted, nil
+}
+
+// merge суммирует новые потребности по продукту и размерности (200 г + 0.5 кг = 700 г), вычитает
+// запасы и добавляет остаток в открытый список: к подходящей позиции (тот же продукт или то же
+// название, совместимая единица) или новой позицией.
+func merge(tx *gorm.DB, orgID uint, from models.Date, needs, counted []need, res *Result) error {
+	if len(needs) == 0 {
+		return nil
+	}
+	var open []models.ShoppingListItem
+	if err := tx.Where("organization_id = ? AND purchased = ?", orgID, false).Order("id").Find(&open).Error; err != nil {
+		return fmt.Errorf("failed to load shopping list: %w", err)
+	}
+
+	order, groups := groupNeeds(needs)
+	_, countedBy := groupNeeds(counted)
+
+	updated := make(map[uint]bool)
+	for _, key := range order {
+		group := groups[key]
+		total, err := sum(group)
+		if err != nil {
+			return err
+		}
+		unit := group[0].source.Unit
+		if total != nil {
+			unit = total.Unit
+		}
+
+		product := pantry.Product{IngredientID: &group[0].source.IngredientID, Name: group[0].name}
+		total, covered, err := fromPantry(tx, orgID, from, product, unit, total, countedBy[key])
+		if err != nil {
+			return err
+		}
+		if covered {
+			// Потребность учтена, но покупать нечего: источники без позиции списка
+			for _, n := range group {
+				source := n.source
+				if err := tx.Create(&source).Error; err != nil {
+					return fmt.Errorf("failed to record shopping item source: %w", err)
+				}
+			}
+			res.CoveredByPantry = append(res.CoveredByPantry, group[0].name)
+			continue
+		}
+
+		item, isNew := findOpen(open, key.ingredient, group[0].name, unit, total)
+		if isNew {
+			item = &models.ShoppingListItem{
+				OrganizationID: orgID,
+				Item:           group[0].name,
+				AddedBy:        AddedByGenerator,
+			}
+		}
+		if item.IngredientID == nil {
+			id := key.ingredient
+			item.IngredientID = &id
+		}
+		if total != nil {
+			q := *total
+			if item.Amount != nil {
+				if q, err = (units.Quantity{Amount: *item.Amount, Unit: item.Unit}).Add(*total); err != nil {
+					return err
+				}
+			}
+			item.Amount, item.Unit, item.Quantity = &q.Amount, q.Unit, q.String()
+		}
+		if err := tx.Omit("Sources").Save(item).Error; err != nil {
+			return fmt.Errorf("failed to save shopping item %q: %w", item.Item, err)
+		}
+
+		for _, n := range group {
+			source := n.source
+			source.ShoppingListItemID = &item.ID
+			if err := tx.Create(&source).Error; err != nil {
+				return fmt.Errorf("failed to record shopping item source: %w", err)
+			}
+			item.Sources = append(item.Sources, source)
+		}
+
+		if isNew {
+			open = append(open, *item)
+			res.Added = append(res.Added, *item)
+		} else {
+			updated[item.ID] = true
+		}
+	}
+
+	for _, item := range open {
+		if updated[item.ID] {
+			res.Updated = append(res.Updated, item)
+		}
+	}
+	sort.Slice(res.Updated, func(i, j int) bool { return res.Updated[i].ID < res.Updated[j].ID })
+	return nil
+}
+
+// groupKey — продукт и размерность: потребности одной группы складываются в одну позицию.
+type groupKey struct {
+	ingredient uint
+	dimension  units.Dimension
+}
+
+// groupNeeds раскладывает потребности по продукту и размерности; order — группы в порядке
+// первой потребности.
+func groupNeeds(needs []need) (order []groupKey, groups map[groupKey][]need) {
+	groups = make(map[groupKey][]need)
+	for _, n := range needs {
+		key := groupKey{n.source.IngredientID, units.Lookup(n.source.Unit).Dimension}
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], n)
+	}
+	return order, groups
+}
+
+// fromPantry вычитает запас продукта к дате date из новой потребности total (см. toBuy).
+func fromPantry(tx *gorm.DB, orgID uint, date models.Date, product pantry.Product, unit string, total *units.Quantity, counted []need) (*units.Quantity, bool, error) {
+	stock, present, err := pantry.Stock(tx, orgID, product, unit, date)
+	if err != nil {
+		return nil, false, err
+	}
+	return toBuy(stock, present, unit, total, counted)
+}
+
+// toBuy — сколько докупить при запасе stock. Запас в первую очередь покрывает уже учтённые
+// потребности тех же дней (counted), так что покупается max(0, всё − запас) за вычетом того,
+// что уже докуплено: max(0, учтённое − запас). covered — покупать ничего не нужно.
+// Потребность без количества («по вкусу») покрыта, если продукт вообще есть дома (present).
+func toBuy(stock units.Quantity, present bool, unit string, total *units.Quantity, counted []need) (*units.Quantity, bool, error) {
+	if total == nil {
+		return nil, present, nil
+	}
+
+	already := 0.0
+	if c, err := sum(counted); err != nil {
+		return nil, false, err
+	} else if c != nil {
+		q, err := c.In(unit)
+		if err != nil {
+			return nil, false, err
+		}
+		already = q.Amount
+	}
+	buy := math.Max(0, already+total.Amount-stock.Amount) - math.Max(0, already-stock.Amount)
+	if buy <= 1e-9 {
+		return nil, true, nil
+	}
+	q := units.Quantity{Amount: buy, Unit: unit}.Humanize()
+	return &q, false, nil
+}
+
+// sum складывает количества потребностей одной размерности; nil — если ни у одной нет количества.
+func sum(group []need) (*units.Quantity, error) {
+	var total *units.Quantity
+	for _, n := range group {
+		if n.source.Quantity == nil {
+			continue
+		}
+		q := units.Quantity{Amount: *n.source.Quantity, Unit: n.source.Unit}
+		if total != nil {
+			var err error
+			if q, err = total.Add(q); err != nil {
+				return nil, err
+			}
+		}
+		total = &q
+	}
+	return total, nil
+}
+
+// findOpen ищет в открытом списке позицию для продукта. Позиция подходит, если это тот же
+// продукт (или то же название) и количество можно сложить: совместимая единица, пустое
+// количество или новая потребность без количества («по вкусу»). Ручное количество-строку
+// ("1 кг") разбирает в Amount. Возвращает isNew=true, если подходящей позиции нет.
+func findOpen(open []models.ShoppingListItem, ingredientID uint, name, unit string, total *units.Quantity) (*models.ShoppingListItem, bool) {
+	normalized := ingredients.NormalizeName(name)
+	for i := range open {
+		item := &open[i]
+		sameProduct := (item.IngredientID != nil && *item.IngredientID == ingredientID) ||
+			ingredients.NormalizeName(item.Item) == normalized
+		if !sameProduct {
+			continue
+		}
+		if total == nil {
+			return item, false
+		}
+		if item.Amount == nil {
+			if item.Quantity == "" {
+				return item, false
+			}
+			q, ok := units.Parse(item.Quantity)
+			if ok && units.Compatible(q.Unit, unit) {
+				item.Amount, item.Unit = &q.Amount, q.Unit
+				return item, false
+			}
+			continue
+		}
+		if units.Compatible(item.Unit, unit) {
+			return item, false
+		}
+	}
+	return nil, true
+}
+
+func addQuantity(sum *float64, q float64) *float64 {
+	v := q
+	if sum != nil {
+		v += *sum
+	}
+	return &v
+}
@@ -0,0 +1,111 @@
+package shopping
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"podlevskikh/awesomeProject/internal/models"
+	"podlevskikh/awesomeProject/internal/units"
+)
+
+func needOf(ingredient uint, amount float64, unit string) need {
+	return need{source: models.ShoppingItemSource{IngredientID: ingredient, Quantity: &amount, Unit: unit}}
+}
+
+func toTaste(ingredient uint) need {
+	return need{source: models.ShoppingItemSource{IngredientID: ingredient}}
+}
+
+func TestSum(t *testing.T) {
+	cases := []struct {
+		name  string
+		group []need
+		want  string
+	}{
+		{"метрические единицы", []need{needOf(1, 200, units.Gram), needOf(1, 0.5, units.Kilogram), needOf(1, 300, units.Gram)}, "1 кг"},
+		{"по вкусу не складывается", []need{toTaste(1), needOf(1, 2, units.Tablespoon), toTaste(1)}, "2 ст.л."},
+		{"штуки без единицы", []need{needOf(1, 3, ""), needOf(1, 2, units.Piece)}, "5 шт"},
+		{"только по вкусу", []need{toTaste(1)}, "<nil>"},
+	}
+	for _, c := range cases {
+		got, err := sum(c.group)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		s := "<nil>"
+		if got != nil {
+			s = got.String()
+		}
+		if s != c.want {
+			t.Errorf("%s: sum = %s, want %s", c.name, s, c.want)
+		}
+	}
+	if _, err := sum([]need{needOf(1, 200, units.Gram), needOf(1, 1, units.Liter)}); err == nil {
+		t.Errorf("sum of grams and liters: expected a dimension error")
+	}
+}
+
+// Потребности раскладываются по продукту и размерности: мука в граммах и килограммах — одна
+// позиция, мука в стаканах — другая.
+func TestGroupNeeds(t *testing.T) {
+	needs := []need{
+		needOf(1, 200, units.Gram), needOf(2, 3, ""), needOf(1, 1, units.Cup),
+		needOf(1, 0.5, units.Kilogram), needOf(2, 2, units.Piece), toTaste(3),
+	}
+	order, groups := groupNeeds(needs)
+	var got []string
+	for _, key := range order {
+		total, err := sum(groups[key])
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := fmt.Sprintf("%d:%d", key.ingredient, len(groups[key]))
+		if total != nil {
+			s += "=" + total.String()
+		}
+		got = append(got, s)
+	}
+	want := "1:2=700 г 2:2=5 шт 1:1=1 стакан 3:1"
+	if strings.Join(got, " ") != want {
+		t.Errorf("groups %q, want %q", strings.Join(got, " "), want)
+	}
+}
+
+func TestToBuy(t *testing.T) {
+	qty := func(amount float64, unit string) *units.Quantity { return &units.Quantity{Amount: amount, Unit: unit} }
+	cases := []struct {
+		name    string
+		stock   float64
+		present bool
+		total   *units.Quantity
+		counted []need
+		want    string // "covered" или сколько купить
+	}{
+		{"запаса нет", 0, false, qty(500, units.Gram), nil, "500 г"},
+		{"запас покрывает часть", 300, true, qty(500, units.Gram), nil, "200 г"},
+		{"запас покрывает всё", 600, true, qty(500, units.Gram), nil, "covered"},
+		// Прошлый запуск учёл 400 г при запасе 300 г и докупил 100 г; новые 500 г покупаются целиком
+		{"запас уже ушёл на учтённое", 300, true, qty(500, units.Gram), []need{needOf(1, 400, units.Gram)}, "500 г"},
+		// Учтённые 100 г покрыты запасом в 300 г, остаток запаса идёт на новые 500 г
+		{"остаток запаса после учтённого", 300, true, qty(500, units.Gram), []need{needOf(1, 100, units.Gram)}, "300 г"},
+		{"по вкусу, продукт есть", 0, true, nil, nil, "covered"},
+		{"по вкусу, продукта нет", 0, false, nil, nil, "<nil>"},
+	}
+	for _, c := range cases {
+		got, covered, err := toBuy(units.Quantity{Amount: c.stock, Unit: units.Gram}, c.present, units.Gram, c.total, c.counted)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		s := "<nil>"
+		switch {
+		case covered:
+			s = "covered"
+		case got != nil:
+			s = got.String()
+		}
+		if s != c.want {
+			t.Errorf("%s: %s, want %s", c.name, s, c.want)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+// Package timeline — раскладка задач дня по времени: свободные промежутки между задачами
+// с фиксированным временем (приёмы пищи, присмотр за ребёнком), размещение гибких задач
+// в них с учётом длительности и желаемого окна, поиск пересечений.
+package timeline
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Span — промежуток дня в минутах от полуночи, [Start, End).
+type Span struct {
+	Start, End int
+}
+
+// Len — длина промежутка в минутах.
+func (s Span) Len() int {
+	return s.End - s.Start
+}
+
+// Overlaps сообщает, пересекаются ли промежутки.
+func (s Span) Overlaps(o Span) bool {
+	return s.Start < o.End && o.Start < s.End
+}
+
+// intersect — общая часть промежутков (пустая, если они не пересекаются).
+func (s Span) intersect(o Span) Span {
+	return Span{max(s.Start, o.Start), min(s.End, o.End)}
+}
+
+func (s Span) String() string {
+	return Clock(s.Start) + "–" + Clock(s.End)
+}
+
+// Day — сутки целиком.
+var Day = Span{0, 24 * 60}
+
+// ParseClock разбирает время "HH:MM" в минуты от полуночи.
+func ParseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Clock записывает минуты от полуночи как "HH:MM"; 24:00 — конец суток.
+func Clock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+// ParseSpan разбирает промежуток "HH:MM-HH:MM".
+func ParseSpan(s string) (Span, error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return Span{}, fmt.Errorf("invalid time range %q, expected HH:MM-HH:MM", s)
+	}
+	start, err := ParseClock(from)
+	if err != nil {
+		return Span{}, err
+	}
+	end := Day.End
+	if strings.TrimSpace(to) != "24:00" {
+		if end, err = ParseClock(to); err != nil {
+			return Span{}, err
+		}
+	}
+	if end <= start {
+		return Span{}, fmt.Errorf("time range %q ends before it starts", s)
+	}
+	return Span{start, end}, nil
+}
+
+// Preference — желаемое время гибкой задачи: промежуток дня ("09:00-12:00", "morning",
+// "afternoon", "evening") или время относительно приёма пищи ("after dinner", "before lunch").
+// After и Before — название приёма пищи, промежуток для них зависит от расписания дня.
+type Preference struct {
+	Span   *Span
+	After  string
+	Before string
+}
+
+// Части дня.
+var dayParts = map[string]Span{
+	"morning": {0, 12 * 60}, "утро": {0, 12 * 60}, "утром": {0, 12 * 60},
+	"afternoon": {12 * 60, 17 * 60}, "день": {12 * 60, 17 * 60}, "днём": {12 * 60, 17 * 60},
+	"evening": {17 * 60, Day.End}, "вечер": {17 * 60, Day.End}, "вечером": {17 * 60, Day.End},
+}
+
+// ParsePreference разбирает желаемое время; пустая строка — без предпочтения.
+func ParsePreference(s string) (Preference, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return Preference{}, nil
+	}
+	if span, ok := dayParts[s]; ok {
+		return Preference{Span: &span}, nil
+	}
+	for _, prefix := range []string{"after ", "после "} {
+		if meal, ok := strings.CutPrefix(s, prefix); ok && strings.TrimSpace(meal) != "" {
+			return Preference{After: strings.TrimSpace(meal)}, nil
+		}
+	}
+	for _, prefix := range []string{"before ", "до ", "перед "} {
+		if meal, ok := strings.CutPrefix(s, prefix); ok && strings.TrimSpace(meal) != "" {
+			return Preference{Before: strings.TrimSpace(meal)}, nil
+		}
+	}
+	span, err := ParseSpan(s)
+	if err != nil {
+		return Preference{}, fmt.Errorf("invalid preferred time %q: use HH:MM-HH:MM, morning, afternoon, evening, after <meal> or before <meal>", s)
+	}
+	return Preference{Span: &span}, nil
+}
+
+// Busy — занятый промежуток: задача с фиксированным временем или перерыв.
+type Busy struct {
+	Span
+	Label string
+}
+
+// Item — гибкая задача, которой нужно время: длительность и, если задано, желаемое окно.
+type Item struct {
+	Label    string
+	Duration int
+	Window   *Span
+}
+
+// Placement — куда попала гибкая задача. Placed=false — свободного промежутка нужной
+// длины нет; InWindow=false при заданном окне — задача размещена вне него.
+type Placement struct {
+	Span
+	Placed   bool
+	InWindow bool
+}
+
+// Allocate размещает задачи в свободных промежутках day между busy. Сначала — задачи с окном
+// (самые узкие окна первыми), затем остальные, длинные первыми; каждая встаёт в начало первого
+// подходящего промежутка: в своём окне, а если там нет места — где угодно в течение дня.
+// Результат — в порядке items.
+func Allocate(day Span, busy []Busy, items []Item) []Placement {
+	free := []Span{day}
+	for _, b := range busy {
+		free = subtract(free, b.Span)
+	}
+
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		x, y := items[order[a]], items[order[b]]
+		if (x.Window != nil) != (y.Window != nil) {
+			return x.Window != nil
+		}
+		if x.Window != nil && x.Window.Len() != y.Window.Len() {
+			return x.Window.Len() < y.Window.Len()
+		}
+		return x.Duration > y.Duration
+	})
+
+	placements := make([]Placement, len(items))
+	for _, i := range order {
+		it := items[i]
+		p := Placement{InWindow: it.Window == nil}
+		if it.Window != nil {
+			if span, ok := fit(free, *it.Window, it.Duration); ok {
+				p.Span, p.Placed, p.InWindow = span, true, true
+			}
+		}
+		if !p.Placed {
+			p.Span, p.Placed = fit(free, day, it.Duration)
+		}
+		if p.Placed {
+			free = subtract(free, p.Span)
+		}
+		placements[i] = p
+	}
+	return placements
+}
+
+// fit находит в free первые duration минут подряд внутри window.
+func fit(free []Span, window Span, duration int) (Span, bool) {
+	for _, gap := range free {
+		g := gap.intersect(window)
+		if g.Len() >= duration && duration > 0 {
+			return Span{g.Start, g.Start + duration}, true
+		}
+	}
+	return Span{}, false
+}
+
+// subtract вырезает s из упорядоченных непересекающихся промежутков.
+func subtract(spans []Span, s Span) []Span {
+	var out []Span
+	for _, f := range spans {
+		if !f.Overlaps(s) {
+			out = append(out, f)
+			continue
+		}
+		if f.Start < s.Start {
+			out = append(out, Span{f.Start, s.Start})
+		}
+		if s.End < f.End {
+			out = append(out, Span{s.End, f.End})
+		}
+	}
+	return out
+}
+
+// Overlap — два пересекающихся занятых промежутка.
+type Overlap struct {
+	A, B Busy
+}
+
+func (o Overlap) String() string {
+	return fmt.Sprintf("%s %s overlaps %s %s", o.A.Label, o.A.Span, o.B.Label, o.B.Span)
+}
+
+// Overlaps возвращает пересекающиеся пары промежутков в порядке начала.
+func Overlaps(busy []Busy) []Overlap {
+	sorted := append([]Busy(nil), busy...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+	var out []Overlap
+	for i, a := range sorted {
+		for _, b := range sorted[i+1:] {
+			if b.Start >= a.End {
+				break
+			}
+			if a.Len() > 0 && b.Len() > 0 {
+				out = append(out, Overlap{a, b})
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+package timeline
+
+import (
+	"testing"
+)
+
+func span(t *testing.T, s string) Span {
+	t.Helper()
+	sp, err := ParseSpan(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sp
+}
+
+func TestAllocate(t *testing.T) {
+	day := span(t, "08:00-20:00")
+	busy := []Busy{
+		{span(t, "09:00-10:00"), "Breakfast"},
+		{span(t, "10:00-12:30"), "Childcare"},
+		{span(t, "13:00-14:00"), "Lunch"},
+		{span(t, "18:00-19:00"), "Dinner"},
+	}
+	afterDinner := span(t, "19:00-20:00")
+	morning := span(t, "00:00-12:00")
+	items := []Item{
+		{Label: "Bathroom", Duration: 45},                      // после более длинной Bedroom
+		{Label: "Kitchen", Duration: 30, Window: &afterDinner}, // после ужина
+		{Label: "Hall", Duration: 30, Window: &morning},        // утром есть только 08:00-09:00
+		{Label: "Bedroom", Duration: 90, Window: &afterDinner}, // в окно не помещается
+		{Label: "Garage", Duration: 300},                       // не помещается никуда
+	}
+	got := Allocate(day, busy, items)
+	want := []struct {
+		span     string
+		placed   bool
+		inWindow bool
+	}{
+		{"15:30-16:15", true, true},
+		{"19:00-19:30", true, true},
+		{"08:00-08:30", true, true},
+		{"14:00-15:30", true, false},
+		{"", false, true},
+	}
+	for i, w := range want {
+		p := got[i]
+		if p.Placed != w.placed || p.InWindow != w.inWindow || (w.placed && p.Span != span(t, w.span)) {
+			t.Errorf("%s: got %s placed=%t in window=%t, want %s placed=%t in window=%t",
+				items[i].Label, p.Span, p.Placed, p.InWindow, w.span, w.placed, w.inWindow)
+		}
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+	busy := []Busy{
+		{span(t, "13:00-14:00"), "Lunch"},
+		{span(t, "09:00-10:00"), "Breakfast"},
+		{span(t, "09:30-12:00"), "Childcare"},
+		{span(t, "12:00-13:00"), "Snack"},
+	}
+	got := Overlaps(busy)
+	if len(got) != 1 || got[0].String() != "Breakfast 09:00–10:00 overlaps Childcare 09:30–12:00" {
+		t.Errorf("Overlaps = %v", got)
+	}
+}
+
+func TestParsePreference(t *testing.T) {
+	if p, err := ParsePreference("After Dinner"); err != nil || p.After != "dinner" {
+		t.Errorf("after: %+v %v", p, err)
+	}
+	if p, err := ParsePreference("до обеда"); err != nil || p.Before != "обеда" {
+		t.Errorf("before: %+v %v", p, err)
+	}
+	if p, err := ParsePreference("evening"); err != nil || *p.Span != span(t, "17:00-24:00") {
+		t.Errorf("evening: %+v %v", p, err)
+	}
+	if _, err := ParsePreference("sometime"); err == nil {
+		t.Error("sometime: want an error")
+	}
+}
@@ -0,0 +1,242 @@
+// Package units — единицы измерения продуктов и арифметика количеств: разбор русских и
+// английских сокращений ("г", "kg", "ст.л.", "cup"), перевод внутри одной размерности
+// (масса, объём, штуки) без учёта плотности и сложение количеств в разных единицах.
+package units
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Dimension — размерность: переводить можно только внутри одной.
+type Dimension string
+
+const (
+	Mass   Dimension = "mass"   // база — грамм
+	Volume Dimension = "volume" // база — миллилитр
+	Count  Dimension = "count"  // база — штука
+)
+
+// Канонические единицы. Хранятся в RecipeIngredient.Unit и ShoppingListItem.Unit.
+const (
+	Gram       = "г"
+	Kilogram   = "кг"
+	Milliliter = "мл"
+	Liter      = "л"
+	Piece      = "шт"
+	Cup        = "стакан"
+	Tablespoon = "ст.л."
+	Teaspoon   = "ч.л."
+)
+
+// Unit — каноническая единица и её размер в базовой единице размерности.
+type Unit struct {
+	Code      string
+	Dimension Dimension
+	Factor    float64
+}
+
+// known — единицы, которые переводятся друг в друга. Стакан — 250 мл (гранёный).
+var known = map[string]Unit{
+	Gram:       {Gram, Mass, 1},
+	Kilogram:   {Kilogram, Mass, 1000},
+	Milliliter: {Milliliter, Volume, 1},
+	Liter:      {Liter, Volume, 1000},
+	Cup:        {Cup, Volume, 250},
+	Tablespoon: {Tablespoon, Volume, 15},
+	Teaspoon:   {Teaspoon, Volume, 5},
+	Piece:      {Piece, Count, 1},
+}
+
+// aliases приводит написания единиц к каноническим.
+var aliases = map[string]string{
+	"г": Gram, "гр": Gram, "грамм": Gram, "грамма": Gram, "граммов": Gram, "g": Gram, "gr": Gram, "gram": Gram, "grams": Gram,
+	"кг": Kilogram, "килограмм": Kilogram, "килограмма": Kilogram, "килограммов": Kilogram, "kg": Kilogram, "kilogram": Kilogram, "kilograms": Kilogram,
+	"мл": Milliliter, "миллилитр": Milliliter, "миллилитров": Milliliter, "ml": Milliliter,
+	"л": Liter, "литр": Liter, "литра": Liter, "литров": Liter, "l": Liter, "liter": Liter, "liters": Liter, "litre": Liter,
+	"шт": Piece, "штук": Piece, "штуки": Piece, "штука": Piece, "pcs": Piece, "pc": Piece, "piece": Piece, "pieces": Piece,
+	"ст.л": Tablespoon, "ст. л": Tablespoon, "ст.ложка": Tablespoon, "столовая ложка": Tablespoon, "столовые ложки": Tablespoon, "столовых ложек": Tablespoon, "tbsp": Tablespoon, "tablespoon": Tablespoon, "tablespoons": Tablespoon,
+	"ч.л": Teaspoon, "ч. л": Teaspoon, "ч.ложка": Teaspoon, "чайная ложка": Teaspoon, "чайные ложки": Teaspoon, "чайных ложек": Teaspoon, "tsp": Teaspoon, "teaspoon": Teaspoon, "teaspoons": Teaspoon,
+	"стакан": Cup, "стакана": Cup, "стаканов": Cup, "cup": Cup, "cups": Cup,
+	// Штучные единицы без перевода: зубчик не переводится ни в штуки, ни в граммы
+	"зубчик": "зубчик", "зубчика": "зубчик", "зубчиков": "зубчик",
+	"ломтик": "ломтик", "ломтика": "ломтик", "ломтиков": "ломтик",
+	"пучок": "пучок", "пучка": "пучок", "пучков": "пучок",
+	"щепотка": "щепотка", "щепотки": "щепотка",
+	"банка": "банка", "банки": "банка", "банок": "банка",
+}
+
+// Normalize возвращает каноническое написание единицы; ok=false для неизвестной
+// (тогда возвращается исходная строка в нижнем регистре). Пустая единица — штуки без названия.
+func Normalize(s string) (string, bool) {
+	key := strings.ToLower(strings.TrimSpace(s))
+	key = strings.TrimSuffix(key, ".")
+	if key == "" {
+		return "", true
+	}
+	if code, ok := aliases[key]; ok {
+		return code, true
+	}
+	return key, false
+}
+
+// Lookup возвращает единицу по любому написанию. Пустая единица — штуки ("3 яйца" и "3 шт
+// яиц" складываются). Единицы без перевода (зубчик, банка) и неизвестные получают собственную
+// размерность и переводятся только сами в себя.
+func Lookup(s string) Unit {
+	code, _ := Normalize(s)
+	if code == "" {
+		code = Piece
+	}
+	if u, ok := known[code]; ok {
+		return u
+	}
+	return Unit{Code: code, Dimension: Dimension("unit:" + code), Factor: 1}
+}
+
+// Compatible — можно ли перевести одну единицу в другую.
+func Compatible(a, b string) bool {
+	return Lookup(a).Dimension == Lookup(b).Dimension
+}
+
+// Convert переводит количество из одной единицы в другую той же размерности.
+func Convert(amount float64, from, to string) (float64, error) {
+	f, t := Lookup(from), Lookup(to)
+	if f.Dimension != t.Dimension {
+		return 0, fmt.Errorf("cannot convert %q to %q", f.Code, t.Code)
+	}
+	return amount * f.Factor / t.Factor, nil
+}
+
+// Quantity — количество в единице.
+type Quantity struct {
+	Amount float64
+	Unit   string
+}
+
+var (
+	// число: "2", "1.5", "1,5", "1/2", "1 1/2", диапазон "2-3"
+	numberRe = regexp.MustCompile(`^(\d+\s+\d+/\d+|\d+/\d+|\d+(?:[.,]\d+)?(?:\s*-\s*\d+(?:[.,]\d+)?)?)\s*(.*)$`)
+)
+
+// Split отделяет число от остатка строки: "200г" → "200", "г". ok=false, если строка не
+// начинается с числа.
+func Split(s string) (number, rest string, ok bool) {
+	m := numberRe.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return "", "", false
+	}
+	return m[1], strings.TrimSpace(m[2]), true
+}
+
+// ParseNumber разбирает "2", "1,5", "1/2", "1 1/2" и диапазон "2-3" (верхняя граница).
+func ParseNumber(s string) (float64, bool) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), ",", ".")
+	if i := strings.Index(s, "-"); i >= 0 {
+		s = strings.TrimSpace(s[i+1:])
+	}
+	whole := 0.0
+	if parts := strings.Fields(s); len(parts) == 2 {
+		w, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil {
+			return 0, false
+		}
+		whole, s = w, parts[1]
+	}
+	if num, den, ok := strings.Cut(s, "/"); ok {
+		n, err1 := strconv.ParseFloat(strings.TrimSpace(num), 64)
+		d, err2 := strconv.ParseFloat(strings.TrimSpace(den), 64)
+		if err1 != nil || err2 != nil || d == 0 {
+			return 0, false
+		}
+		return whole + n/d, true
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false
+	}
+	return whole + v, true
+}
+
+// Parse разбирает количество с единицей: "200 g", "0.5 кг", "1,5 л", "2". ok=false, если
+// числа нет или единица неизвестна.
+func Parse(s string) (Quantity, bool) {
+	number, rest, ok := Split(s)
+	if !ok {
+		return Quantity{}, false
+	}
+	amount, ok := ParseNumber(number)
+	if !ok {
+		return Quantity{}, false
+	}
+	unit, ok := Normalize(rest)
+	if !ok {
+		return Quantity{}, false
+	}
+	return Quantity{Amount: amount, Unit: unit}, true
+}
+
+// In переводит количество в другую единицу той же размерности.
+func (q Quantity) In(unit string) (Quantity, error) {
+	amount, err := Convert(q.Amount, q.Unit, unit)
+	if err != nil {
+		return Quantity{}, err
+	}
+	code, _ := Normalize(unit)
+	return Quantity{Amount: amount, Unit: code}, nil
+}
+
+// Scale умножает количество на коэффициент (пересчёт рецепта на другое число порций).
+func (q Quantity) Scale(factor float64) Quantity {
+	return Quantity{Amount: q.Amount * factor, Unit: q.Unit}
+}
+
+// Add складывает количества одной размерности. Результат — в единице q, а для метрических
+// единиц — в удобной: 200 г + 0.5 кг = 700 г, 800 г + 0.5 кг = 1.3 кг.
+func (q Quantity) Add(other Quantity) (Quantity, error) {
+	o, err := other.In(q.Unit)
+	if err != nil {
+		return Quantity{}, err
+	}
+	unit := q.Unit
+	if unit == "" && other.Unit != "" {
+		unit = Piece // 3 + 2 шт = 5 шт
+	}
+	return Quantity{Amount: q.Amount + o.Amount, Unit: unit}.Humanize(), nil
+}
+
+// Humanize переводит граммы и миллилитры в килограммы и литры от 1000 и обратно ниже 1.
+// Бытовые единицы (стакан, ложки) не меняются.
+func (q Quantity) Humanize() Quantity {
+	var small, large string
+	switch q.Unit {
+	case Gram, Kilogram:
+		small, large = Gram, Kilogram
+	case Milliliter, Liter:
+		small, large = Milliliter, Liter
+	default:
+		return q
+	}
+	base, _ := q.In(small)
+	if base.Amount >= 1000 {
+		h, _ := q.In(large)
+		return h
+	}
+	return base
+}
+
+// String печатает количество: "1.5 кг", "2".
+func (q Quantity) String() string {
+	if q.Unit == "" {
+		return FormatNumber(q.Amount)
+	}
+	return FormatNumber(q.Amount) + " " + q.Unit
+}
+
+// FormatNumber печатает число без лишних нулей, округляя до тысячных: 1.5, 200, 0.333.
+func FormatNumber(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1000)/1000, 'f', -1, 64)
+}
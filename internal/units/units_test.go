@@ -0,0 +1,52 @@
+package units
+
+import "testing"
+
+func TestParseAliases(t *testing.T) {
+	cases := map[string]Quantity{
+		"200 g":     {200, Gram},
+		"0.5 kg":    {0.5, Kilogram},
+		"1,5 кг":    {1.5, Kilogram},
+		"400мл":     {400, Milliliter},
+		"2 стакана": {2, Cup},
+		"1 ст.л.":   {1, Tablespoon},
+		"1/2 tsp":   {0.5, Teaspoon},
+		"3 pcs":     {3, Piece},
+		"2":         {2, ""},
+	}
+	for in, want := range cases {
+		got, ok := Parse(in)
+		if !ok || got != want {
+			t.Errorf("Parse(%q) = %+v, %v; want %+v", in, got, ok, want)
+		}
+	}
+	if _, ok := Parse("2 куска"); ok {
+		t.Errorf("unknown unit must not parse")
+	}
+}
+
+func TestAdd(t *testing.T) {
+	cases := []struct {
+		a, b Quantity
+		want string
+	}{
+		{Quantity{200, Gram}, Quantity{0.5, Kilogram}, "700 г"},
+		{Quantity{800, Gram}, Quantity{0.5, Kilogram}, "1.3 кг"},
+		{Quantity{1, Cup}, Quantity{2, Tablespoon}, "1.12 стакан"},
+		{Quantity{500, Milliliter}, Quantity{1, Cup}, "750 мл"},
+		{Quantity{2, "зубчик"}, Quantity{3, "зубчик"}, "5 зубчик"},
+		{Quantity{3, ""}, Quantity{2, Piece}, "5 шт"},
+		{Quantity{2, Piece}, Quantity{1, ""}, "3 шт"},
+	}
+	for _, c := range cases {
+		got, err := c.a.Add(c.b)
+		if err != nil || got.String() != c.want {
+			t.Errorf("%v + %v = %v, %v; want %s", c.a, c.b, got, err, c.want)
+		}
+	}
+	for _, pair := range [][2]Quantity{{{1, Gram}, {1, Milliliter}}, {{1, Piece}, {1, "зубчик"}}} {
+		if _, err := pair[0].Add(pair[1]); err == nil {
+			t.Errorf("%v + %v: expected a dimension error", pair[0], pair[1])
+		}
+	}
+}
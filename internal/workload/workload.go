@@ -0,0 +1,158 @@
+// Package workload — рабочее время помощников и объём дня: сколько минут работы помещается
+// в день по профилям рабочих часов и сколько из них занимают задачи расписания.
+package workload
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"podlevskikh/awesomeProject/internal/models"
+	"podlevskikh/awesomeProject/internal/timeline"
+
+	"gorm.io/gorm"
+)
+
+// ParseBreaks разбирает перерывы "13:00-14:00, 16:30-16:45" в промежутки по возрастанию.
+func ParseBreaks(s string) ([]timeline.Span, error) {
+	var spans []timeline.Span
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		span, err := timeline.ParseSpan(part)
+		if err != nil {
+			return nil, err
+		}
+		spans = append(spans, span)
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Start < spans[j].Start })
+	return spans, nil
+}
+
+// Validate проверяет профиль: конец дня позже начала, перерывы внутри рабочего дня
+// и не пересекаются.
+func Validate(p models.WorkProfile) error {
+	hours, err := timeline.ParseSpan(p.StartTime + "-" + p.EndTime)
+	if err != nil {
+		return fmt.Errorf("working hours: %w", err)
+	}
+	breaks, err := ParseBreaks(p.Breaks)
+	if err != nil {
+		return fmt.Errorf("breaks: %w", err)
+	}
+	for i, b := range breaks {
+		if b.Start < hours.Start || b.End > hours.End {
+			return fmt.Errorf("breaks must be within working hours")
+		}
+		if i > 0 && b.Start < breaks[i-1].End {
+			return fmt.Errorf("breaks must not overlap")
+		}
+	}
+	return nil
+}
+
+// Minutes — рабочее время профиля за день в минутах: от начала до конца без перерывов.
+// Некорректный профиль даёт 0.
+func Minutes(p models.WorkProfile) int {
+	total := 0
+	for _, s := range working(p) {
+		total += s.Len()
+	}
+	return total
+}
+
+// working — рабочие промежутки профиля: рабочие часы за вычетом перерывов.
+// Некорректный профиль не работает.
+func working(p models.WorkProfile) []timeline.Span {
+	if Validate(p) != nil {
+		return nil
+	}
+	hours, _ := timeline.ParseSpan(p.StartTime + "-" + p.EndTime)
+	breaks, _ := ParseBreaks(p.Breaks)
+	var spans []timeline.Span
+	start := hours.Start
+	for _, b := range breaks {
+		if b.Start > start {
+			spans = append(spans, timeline.Span{Start: start, End: b.Start})
+		}
+		start = b.End
+	}
+	if start < hours.End {
+		spans = append(spans, timeline.Span{Start: start, End: hours.End})
+	}
+	return spans
+}
+
+// Capacity — рабочее время всех помощников организации за день.
+type Capacity struct {
+	Minutes int `json:"minutes"`
+	Helpers int `json:"helpers"` // помощников с профилем рабочих часов
+
+	// Window — от начала самого раннего до конца самого позднего рабочего дня;
+	// Off — промежутки внутри Window, когда не работает никто (общие перерывы).
+	Window timeline.Span   `json:"-"`
+	Off    []timeline.Span `json:"-"`
+}
+
+// Known сообщает, заданы ли профили: без них объём дня не ограничивается.
+func (c Capacity) Known() bool {
+	return c.Helpers > 0
+}
+
+// Load суммирует профили рабочих часов участников организации с активным членством.
+func Load(db *gorm.DB, orgID uint) (Capacity, error) {
+	var profiles []models.WorkProfile
+	err := db.Joins("JOIN memberships ON memberships.user_id = work_profiles.user_id AND memberships.organization_id = work_profiles.organization_id").
+		Where("work_profiles.organization_id = ? AND memberships.status = ?", orgID, models.MembershipActive).
+		Find(&profiles).Error
+	if err != nil {
+		return Capacity{}, fmt.Errorf("failed to load work profiles: %w", err)
+	}
+	var c Capacity
+	var spans []timeline.Span
+	for _, p := range profiles {
+		w := working(p)
+		if len(w) == 0 {
+			continue
+		}
+		c.Minutes += Minutes(p)
+		c.Helpers++
+		spans = append(spans, w...)
+	}
+	if len(spans) == 0 {
+		return c, nil
+	}
+
+	// Объединение рабочих промежутков; разрывы между ними — время, когда не работает никто
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Start < spans[j].Start })
+	c.Window = timeline.Span{Start: spans[0].Start, End: spans[0].End}
+	for _, s := range spans[1:] {
+		if s.Start > c.Window.End {
+			c.Off = append(c.Off, timeline.Span{Start: c.Window.End, End: s.Start})
+		}
+		c.Window.End = max(c.Window.End, s.End)
+	}
+	return c, nil
+}
+
+// Planned — суммарная длительность задач в минутах.
+func Planned(tasks []models.ScheduleTask) int {
+	total := 0
+	for _, t := range tasks {
+		total += t.Duration
+	}
+	return total
+}
+
+// Format записывает длительность как "2 h 30 min".
+func Format(minutes int) string {
+	switch {
+	case minutes < 60:
+		return fmt.Sprintf("%d min", minutes)
+	case minutes%60 == 0:
+		return fmt.Sprintf("%d h", minutes/60)
+	}
+	return fmt.Sprintf("%d h %d min", minutes/60, minutes%60)
+}
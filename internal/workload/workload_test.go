@@ -0,0 +1,38 @@
+package workload
+
+import (
+	"testing"
+
+	"podlevskikh/awesomeProject/internal/models"
+)
+
+func TestMinutes(t *testing.T) {
+	cases := []struct {
+		profile models.WorkProfile
+		want    int
+	}{
+		{models.WorkProfile{StartTime: "09:00", EndTime: "17:00"}, 480},
+		{models.WorkProfile{StartTime: "09:00", EndTime: "17:00", Breaks: "13:00-14:00, 16:30-16:45"}, 405},
+		{models.WorkProfile{StartTime: "9:30", EndTime: "12:00", Breaks: " "}, 150},
+		{models.WorkProfile{StartTime: "17:00", EndTime: "09:00"}, 0},
+	}
+	for _, c := range cases {
+		if got := Minutes(c.profile); got != c.want {
+			t.Errorf("Minutes(%s-%s, %q) = %d, want %d", c.profile.StartTime, c.profile.EndTime, c.profile.Breaks, got, c.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	bad := []models.WorkProfile{
+		{StartTime: "09:00", EndTime: "25:00"},
+		{StartTime: "09:00", EndTime: "17:00", Breaks: "13:00"},
+		{StartTime: "09:00", EndTime: "17:00", Breaks: "08:00-09:30"},              // до начала дня
+		{StartTime: "09:00", EndTime: "17:00", Breaks: "13:00-14:00, 13:30-14:30"}, // пересекаются
+	}
+	for _, p := range bad {
+		if err := Validate(p); err == nil {
+			t.Errorf("Validate(%+v) = nil, want an error", p)
+		}
+	}
+}
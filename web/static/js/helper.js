@@ -641,19 +641,33 @@ window.onclick = function(event) {
 
 // CHILDCARE MANAGEMENT
 
+// Today's childcare blocks as last loaded
+let todayChildcare = [];
+// Block being edited in the childcare form; null when the form adds a new block
+let editingChildcareID = null;
+
 function loadTodayChildcare() {
+    editingChildcareID = null;
     fetch('/helper/api/childcare/today')
         .then(r => r.json())
         .then(schedules => {
             const currentDiv = document.getElementById('childcare-current');
             const formDiv = document.getElementById('childcare-form');
-
-            if (schedules && schedules.length > 0) {
-                // Show current childcare time
-                const schedule = schedules[0];
-                document.getElementById('current-start-time').textContent = schedule.start_time;
-                document.getElementById('current-end-time').textContent = schedule.end_time;
-                document.getElementById('current-notes').textContent = schedule.notes || 'No notes';
+            todayChildcare = schedules || [];
+
+            if (todayChildcare.length > 0) {
+                // Show every block of the day with its own actions
+                document.getElementById('childcare-blocks').innerHTML = todayChildcare.map(block => `
+                    <div class="list-item">
+                        <p><strong>Time:</strong> ${block.start_time} - ${block.end_time}</p>
+                        ${block.children && block.children.length > 0 ? `<p><strong>Children:</strong> ${block.children.map(c => c.name).join(', ')}</p>` : ''}
+                        <p><strong>Notes:</strong> ${block.notes || 'No notes'}</p>
+                        <div class="actions">
+                            <button onclick="editChildcareTime(${block.id})" class="btn btn-primary">Edit</button>
+                            <button onclick="deleteChildcareTime(${block.id})" class="btn btn-danger">Delete</button>
+                        </div>
+                    </div>
+                `).join('');
 
                 currentDiv.style.display = 'block';
                 formDiv.style.display = 'none';
@@ -669,21 +683,27 @@ function loadTodayChildcare() {
         });
 }
 
-function editChildcareTime() {
-    fetch('/helper/api/childcare/today')
-        .then(r => r.json())
-        .then(schedules => {
-            if (schedules && schedules.length > 0) {
-                const schedule = schedules[0];
-                document.getElementById('childcare-start').value = schedule.start_time;
-                document.getElementById('childcare-end').value = schedule.end_time;
-                document.getElementById('childcare-notes').value = schedule.notes || '';
-
-                document.getElementById('childcare-current').style.display = 'none';
-                document.getElementById('childcare-form').style.display = 'block';
-                document.getElementById('childcare-form-title').textContent = 'Edit Childcare Time';
-            }
-        });
+function editChildcareTime(id) {
+    const block = todayChildcare.find(b => b.id === id);
+    if (!block) {
+        return;
+    }
+    editingChildcareID = block.id;
+    document.getElementById('childcare-start').value = block.start_time;
+    document.getElementById('childcare-end').value = block.end_time;
+    document.getElementById('childcare-notes').value = block.notes || '';
+
+    document.getElementById('childcare-current').style.display = 'none';
+    document.getElementById('childcare-form').style.display = 'block';
+    document.getElementById('childcare-form-title').textContent = 'Edit Childcare Time';
+}
+
+function addChildcareTime() {
+    editingChildcareID = null;
+    document.getElementById('childcareForm').reset();
+    document.getElementById('childcare-current').style.display = 'none';
+    document.getElementById('childcare-form').style.display = 'block';
+    document.getElementById('childcare-form-title').textContent = 'Add Childcare Time';
 }
 
 function saveChildcareTime(e) {
@@ -694,6 +714,10 @@ function saveChildcareTime(e) {
         end_time: document.getElementById('childcare-end').value,
         notes: document.getElementById('childcare-notes').value
     };
+    // Without an id the server adds a new block
+    if (editingChildcareID) {
+        data.id = editingChildcareID;
+    }
 
     fetch('/helper/api/childcare/today', {
         method: 'POST',
@@ -715,12 +739,12 @@ function saveChildcareTime(e) {
     });
 }
 
-function deleteChildcareTime() {
-    if (!confirm('Are you sure you want to delete today\'s childcare time?')) {
+function deleteChildcareTime(id) {
+    if (!confirm('Are you sure you want to delete this childcare time?')) {
         return;
     }
 
-    fetch('/helper/api/childcare/today', {
+    fetch(`/helper/api/childcare/today?id=${id}`, {
         method: 'DELETE'
     })
     .then(() => {